@@ -7,6 +7,7 @@ import (
 	"os"
 
 	"github.com/TouchBistro/goutils/progress"
+	"github.com/TouchBistro/tb/config"
 	"github.com/TouchBistro/tb/engine"
 	"github.com/spf13/cobra"
 )
@@ -30,9 +31,15 @@ func ExpectSingleArg(name string) cobra.PositionalArgs {
 // where they are guaranteed to be initialized. Outside of Run, the only usage of
 // a Container instance should be to pass it to command constructors so they can capture it.
 type Container struct {
-	Engine  *engine.Engine
-	Tracker progress.Tracker
-	Verbose bool
+	// Config is the config used by tb, after applying environment variables and flags.
+	Config config.Config
+	// ConfigErr is the error that occurred loading the config, in which case Config is empty.
+	// It is only set for config commands, since they are used to fix broken config files,
+	// all other commands fail if the config cannot be loaded.
+	ConfigErr error
+	Engine    *engine.Engine
+	Tracker   progress.Tracker
+	Verbose   bool
 	// OfflineMode skips any operations requiring internet connectivity
 	OfflineMode bool
 	// Ctx is the context that should be used within a command to carry deadlines and cancellation signals.
@@ -0,0 +1,24 @@
+package config
+
+import (
+	"github.com/TouchBistro/tb/cli"
+	"github.com/spf13/cobra"
+)
+
+func NewConfigCommand(c *cli.Container) *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the tb config from the command line",
+		Long: `tb config manages the tb config from the command line.
+
+The config is read from ~/.tbrc.yml and any project config or included files,
+see https://github.com/TouchBistro/tb#configuration for more details.`,
+	}
+	configCmd.AddCommand(
+		newMigrateCommand(c),
+		newSetCommand(c),
+		newShowCommand(c),
+		newValidateCommand(c),
+	)
+	return configCmd
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"github.com/TouchBistro/goutils/color"
+	"github.com/TouchBistro/goutils/fatal"
+	"github.com/TouchBistro/tb/cli"
+	"github.com/TouchBistro/tb/config"
+	"github.com/spf13/cobra"
+)
+
+func newMigrateCommand(c *cli.Container) *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate [path]",
+		Args:  cobra.MaximumNArgs(1),
+		Short: "Update a config file to the latest format",
+		Long: `Updates a config file to the latest version of the config format. Comments in the file are preserved.
+If no path is provided, ~/.tbrc.yml is updated.
+
+Before the file is changed, the original is backed up next to it as <file>.v<version>.bak.
+If that backup already exists, a number is added to the name so it is never overwritten.
+
+The following changes are made to config files from older versions of tb:
+
+- The deprecated debug field is removed, use the --verbose flag instead.
+- Overrides that use the short name of a service are renamed to use the full name,
+  if the file has a single registry.
+- The version field is set to the latest version.
+
+Examples:
+
+Update ~/.tbrc.yml:
+
+	tb config migrate
+
+Update a project config:
+
+	tb config migrate tb.yml`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var path string
+			if len(args) > 0 {
+				path = args[0]
+			}
+			result, err := config.Migrate(path, "")
+			if err != nil {
+				return &fatal.Error{Msg: "Failed to migrate config file", Err: err}
+			}
+			if result.BackupPath == "" {
+				c.Tracker.Infof(color.Green("✅ %s is already up to date"), result.Path)
+				return nil
+			}
+			c.Tracker.Infof(color.Cyan("Migrated %s from version %d to %d"), result.Path, result.FromVersion, config.SchemaVersion)
+			for _, change := range result.Changes {
+				c.Tracker.Infof("- %s", change)
+			}
+			c.Tracker.Infof("The original file was backed up to %s", result.BackupPath)
+			c.Tracker.Info(color.Green("✅ Successfully migrated config file"))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"github.com/TouchBistro/goutils/color"
+	"github.com/TouchBistro/goutils/fatal"
+	"github.com/TouchBistro/tb/cli"
+	"github.com/TouchBistro/tb/config"
+	"github.com/spf13/cobra"
+)
+
+func newSetCommand(c *cli.Container) *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <path> <value>",
+		Args:  cobra.ExactArgs(2),
+		Short: "Set a field in ~/.tbrc.yml",
+		Long: `Sets a field in ~/.tbrc.yml. Comments in the file are preserved.
+
+The path is a dot separated list of field names, or keys for fields like playlists and overrides.
+Fields that are lists are set using comma separated values. Fields that contain other fields,
+like registries, cannot be set directly, use 'tb registry add' to add a registry.
+
+Examples:
+
+Set the timeout for operations to 10 minutes:
+
+	tb config set timeoutSeconds 600
+
+Build the postgres service locally instead of pulling it:
+
+	tb config set overrides.TouchBistro/tb-registry/postgres.mode build
+
+Set the services of the my-core custom playlist:
+
+	tb config set playlists.my-core.services postgres,redis`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, value := args[0], args[1]
+			if err := config.Set(path, value, ""); err != nil {
+				return &fatal.Error{
+					Msg: "Failed to set config field, run 'tb config show' to see the current config",
+					Err: err,
+				}
+			}
+			c.Tracker.Infof(color.Green("Successfully set %s to %q"), path, value)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/TouchBistro/goutils/fatal"
+	"github.com/TouchBistro/tb/cli"
+	"github.com/TouchBistro/tb/config"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+type showOptions struct {
+	effective bool
+}
+
+func newShowCommand(c *cli.Container) *cobra.Command {
+	var opts showOptions
+	showCmd := &cobra.Command{
+		Use:   "show",
+		Args:  cobra.NoArgs,
+		Short: "Show the tb config",
+		Long: `Shows the contents of each config file used by tb, in the order they are merged.
+
+Use --effective to show the config that tb actually uses instead. This is the result of merging
+~/.tbrc.yml with any project config and included files, with defaults set for fields that were not set.
+
+Examples:
+
+Show the config files:
+
+	tb config show
+
+Show the effective config:
+
+	tb config show --effective`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if c.ConfigErr != nil {
+				return &fatal.Error{Msg: "Failed to load tbrc", Err: c.ConfigErr}
+			}
+			cfg := c.Config
+			if !opts.effective {
+				for i, path := range cfg.Sources {
+					data, err := os.ReadFile(path)
+					if err != nil {
+						return &fatal.Error{Msg: fmt.Sprintf("Failed to read config file %s", path), Err: err}
+					}
+					if i > 0 {
+						fmt.Println()
+					}
+					fmt.Printf("# %s\n%s", path, data)
+					if len(data) > 0 && data[len(data)-1] != '\n' {
+						fmt.Println()
+					}
+				}
+				return nil
+			}
+
+			var doc yaml.Node
+			if err := doc.Encode(config.WithDefaults(cfg)); err != nil {
+				return &fatal.Error{Msg: "Failed to encode config", Err: err}
+			}
+			// Remove fields that are not set, like debug, to reduce noise.
+			var content []*yaml.Node
+			for i := 0; i+1 < len(doc.Content); i += 2 {
+				if doc.Content[i+1].Tag != "!!null" {
+					content = append(content, doc.Content[i], doc.Content[i+1])
+				}
+			}
+			doc.Content = content
+			if len(cfg.Sources) > 0 {
+				doc.HeadComment = "Effective config merged from:\n  " + strings.Join(cfg.Sources, "\n  ")
+			} else {
+				doc.HeadComment = "Effective config from environment variables and flags"
+			}
+			var sb strings.Builder
+			enc := yaml.NewEncoder(&sb)
+			enc.SetIndent(2)
+			if err := enc.Encode(&doc); err != nil {
+				return &fatal.Error{Msg: "Failed to encode config", Err: err}
+			}
+			fmt.Print(sb.String())
+			return nil
+		},
+	}
+	showCmd.Flags().BoolVar(&opts.effective, "effective", false, "Show the merged config with defaults")
+	return showCmd
+}
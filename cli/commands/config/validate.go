@@ -0,0 +1,78 @@
+package config
+
+import (
+	"github.com/TouchBistro/goutils/color"
+	"github.com/TouchBistro/goutils/errors"
+	"github.com/TouchBistro/goutils/fatal"
+	"github.com/TouchBistro/tb/cli"
+	"github.com/TouchBistro/tb/config"
+	"github.com/spf13/cobra"
+)
+
+func newValidateCommand(c *cli.Container) *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Args:  cobra.NoArgs,
+		Short: "Validate the tb config",
+		Long: `Validates ~/.tbrc.yml along with any project config and included files.
+
+The following problems are reported:
+
+- Unknown fields, which are usually typos.
+- Invalid registry names and registries that are defined more than once.
+- timeoutSeconds, timeouts, and gitConcurrency values that are out of bounds.
+- Overrides that do not use the full service name, or that are for services that do not exist.
+- Invalid custom playlist names.
+
+Checking that overridden services exist requires the registries to have been cloned, so it is
+skipped if they have not been.
+
+Examples:
+
+Validate the tb config:
+
+	tb config validate`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if c.ConfigErr != nil {
+				c.Tracker.Infof("❌ Found problems in config\n%v", c.ConfigErr)
+				return &fatal.Error{Msg: color.Red("❌ config is invalid")}
+			}
+			cfg := c.Config
+			var problems errors.List
+			for _, path := range cfg.Sources {
+				c.Tracker.Infof(color.Cyan("Validating config file %s"), path)
+				if err := config.ValidateFile(path); err != nil {
+					problems = appendProblems(problems, err)
+				}
+			}
+
+			var checkOpts config.CheckOptions
+			result, err := config.ReadRegistries(cfg, nil)
+			if err != nil {
+				c.Tracker.Warnf("⚠️  Skipping checking that overridden services exist, unable to read registries: %v", err)
+			} else {
+				checkOpts.Services = result.Services
+			}
+			if err := config.Check(cfg, checkOpts); err != nil {
+				problems = appendProblems(problems, err)
+			}
+
+			if len(problems) > 0 {
+				c.Tracker.Infof("❌ Found problems in config\n%v", problems)
+				return &fatal.Error{Msg: color.Red("❌ config is invalid")}
+			}
+			c.Tracker.Info(color.Green("✅ config is valid"))
+			return nil
+		},
+	}
+}
+
+// appendProblems appends the problems in err to problems and returns the result.
+// If err is an errors.List its errors are appended individually.
+func appendProblems(problems errors.List, err error) errors.List {
+	var errs errors.List
+	if errors.As(err, &errs) {
+		return append(problems, errs...)
+	}
+	return append(problems, err)
+}
@@ -14,7 +14,7 @@ func newDownCommand(c *cli.Container) *cobra.Command {
 		Short: "Stop and remove containers",
 		Long: `Stops and removes running service containers.
 By default all running service containers are stopped and removed.
-Args can be provided to only stop and remove specific containers.
+Args can be provided to only stop and remove specific containers. Args can also be patterns like 'payments-*'.
 
 Examples:
 
@@ -24,7 +24,11 @@ Stop and remove all service containers:
 
 Stop and remove on the postgres and redis containers:
 
-	tb down postgres redis`,
+	tb down postgres redis
+
+Stop and remove all containers for services in the TouchBistro/tb-registry registry:
+
+	tb down 'TouchBistro/tb-registry/*'`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			err := c.Engine.Down(c.Ctx, engine.DownOptions{ServiceNames: args})
 			if err != nil {
@@ -22,6 +22,8 @@ func newExecCommand(c *cli.Container) *cobra.Command {
 		Short: "Execute a command in a service container",
 		Long: `Executes a command in a service container.
 
+The service can be a pattern like 'core-*' as long as it matches exactly one service.
+
 Examples:
 
 Run yarn db:prepare:test in the core-database container:
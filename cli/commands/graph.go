@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/TouchBistro/goutils/fatal"
+	"github.com/TouchBistro/tb/cli"
+	"github.com/TouchBistro/tb/engine"
+	"github.com/spf13/cobra"
+)
+
+type graphOptions struct {
+	format string
+}
+
+func newGraphCommand(c *cli.Container) *cobra.Command {
+	var opts graphOptions
+	graphCmd := &cobra.Command{
+		Use:   "graph [service|playlist]",
+		Args:  cobra.MaximumNArgs(1),
+		Short: "Show the dependency graph of a service or playlist",
+		Long: `Shows the dependency graph of a service or playlist. If no name is provided, the graph of all services is shown.
+
+The graph follows the dependencies of each service and the extends chain of each playlist.
+Cycles and services or playlists that do not exist are highlighted.
+
+The graph can be output as an ASCII tree, in the Graphviz DOT language, or as a Mermaid flowchart.
+
+Examples:
+
+Show the dependencies of the venue-core-service service:
+
+	tb graph venue-core-service
+
+Render the graph of the core playlist as an image using Graphviz:
+
+	tb graph core --format dot | dot -Tpng -o core.png
+
+Output the graph of the core playlist as a Mermaid flowchart, which can be embedded in markdown:
+
+	tb graph core --format mermaid`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var name string
+			if len(args) > 0 {
+				name = args[0]
+			}
+			g, err := c.Engine.Graph(engine.GraphOptions{Name: name})
+			if err != nil {
+				return &fatal.Error{
+					Msg: "Try running 'tb list' to see available services and playlists",
+					Err: err,
+				}
+			}
+			switch opts.format {
+			case "tree":
+				fmt.Print(g.Tree())
+			case "dot":
+				fmt.Print(g.DOT())
+			case "mermaid":
+				fmt.Print(g.Mermaid())
+			default:
+				return &fatal.Error{Msg: fmt.Sprintf("Invalid format %q, must be one of: tree, dot, mermaid", opts.format)}
+			}
+			if g.HasCycle() {
+				c.Tracker.Warn("⚠️  The graph contains a cycle")
+			}
+			if g.HasMissing() {
+				c.Tracker.Warn("⚠️  The graph references services or playlists that do not exist")
+			}
+			return nil
+		},
+	}
+
+	flags := graphCmd.Flags()
+	flags.StringVar(&opts.format, "format", "tree", "The output format: tree, dot, or mermaid")
+	return graphCmd
+}
@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/TouchBistro/goutils/color"
+	"github.com/TouchBistro/goutils/fatal"
+	"github.com/TouchBistro/tb/cli"
+	"github.com/TouchBistro/tb/resource/service"
+	"github.com/spf13/cobra"
+)
+
+func newInfoCommand(c *cli.Container) *cobra.Command {
+	return &cobra.Command{
+		Use:   "info <service>",
+		Args:  cli.ExpectSingleArg("service name"),
+		Short: "Show details about a service",
+		Long: `Shows details about a service, including what it does, who owns it, where to find its
+documentation, and how it is run by tb.
+
+Examples:
+
+Show details about the venue-core-service service:
+
+	tb info venue-core-service`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info, err := c.Engine.Info(args[0])
+			if err != nil {
+				return &fatal.Error{
+					Msg: "Try running 'tb list --services' to see available services",
+					Err: err,
+				}
+			}
+
+			s := info.Service
+			fmt.Println(s.FullName())
+			if s.Description != "" {
+				fmt.Printf("  %s\n", s.Description)
+			}
+			if s.Deprecated != nil {
+				fmt.Printf("  %s\n", color.Yellow("Deprecated: "+s.Deprecated.Message))
+			}
+			printField("Owners", joinOrNone(s.Owners))
+			printField("Docs", valueOrNone(s.Docs))
+			printField("Slack", valueOrNone(s.Slack))
+			printURLs(s.URLs)
+			fmt.Println()
+			printField("Mode", s.Mode)
+			image := s.ImageURI()
+			if s.Mode == service.ModeBuild {
+				image = "built locally from " + s.Build.DockerfilePath
+			}
+			printField("Image", valueOrNone(image))
+			printItems("Ports", s.Ports)
+			printField("Repo", valueOrNone(s.GitRepo.Name))
+			if info.RepoPath != "" {
+				printField("Repo Path", info.RepoPath)
+			}
+			printItems("Dependencies", info.Dependencies)
+			printItems("Playlists", info.Playlists)
+			return nil
+		},
+	}
+}
+
+func printField(name, value string) {
+	fmt.Printf("  %s: %s\n", name, value)
+}
+
+func printItems(name string, items []string) {
+	fmt.Printf("  %s:\n", name)
+	if len(items) == 0 {
+		fmt.Println("    none")
+		return
+	}
+	for _, item := range items {
+		fmt.Printf("    - %s\n", item)
+	}
+}
+
+func printURLs(urls map[string]string) {
+	names := make([]string, 0, len(urls))
+	for name := range urls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	items := make([]string, len(names))
+	for i, name := range names {
+		items[i] = fmt.Sprintf("%s: %s", name, urls[name])
+	}
+	printItems("URLs", items)
+}
+
+func valueOrNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+func joinOrNone(items []string) string {
+	if len(items) == 0 {
+		return "none"
+	}
+	return strings.Join(items, ", ")
+}
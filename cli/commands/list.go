@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"sort"
 
+	"github.com/TouchBistro/goutils/color"
 	"github.com/TouchBistro/tb/cli"
 	"github.com/TouchBistro/tb/engine"
+	"github.com/TouchBistro/tb/resource"
 	"github.com/spf13/cobra"
 )
 
@@ -57,7 +59,7 @@ List only custom playlists along with the services in each playlist (tree mode):
 				fmt.Println("Services:")
 				sort.Strings(listResult.Services)
 				for _, n := range listResult.Services {
-					fmt.Printf("  - %s\n", n)
+					fmt.Printf("  - %s%s\n", n, deprecationNote(listResult.DeprecatedServices[n]))
 				}
 			}
 			if opts.listPlaylists {
@@ -84,7 +86,7 @@ func printPlaylists(playlists []engine.PlaylistSummary, tree bool) {
 		return playlists[i].Name < playlists[j].Name
 	})
 	for _, ps := range playlists {
-		fmt.Printf("  - %s\n", ps.Name)
+		fmt.Printf("  - %s%s\n", ps.Name, deprecationNote(ps.Deprecated))
 		if !tree {
 			continue
 		}
@@ -94,3 +96,19 @@ func printPlaylists(playlists []engine.PlaylistSummary, tree bool) {
 		}
 	}
 }
+
+// deprecationNote returns a note to display next to a deprecated resource, or
+// an empty string if d is nil.
+func deprecationNote(d *resource.Deprecation) string {
+	if d == nil {
+		return ""
+	}
+	note := " (deprecated"
+	if d.Replacement != "" {
+		note += ", use " + d.Replacement + " instead"
+	}
+	if d.RemoveAfter != "" {
+		note += ", removed after " + d.RemoveAfter
+	}
+	return color.Yellow(note + ")")
+}
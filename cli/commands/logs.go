@@ -15,6 +15,7 @@ func newLogsCommand(c *cli.Container) *cobra.Command {
 		Short: "View logs from containers",
 		Long: `View logs from service containers. By default logs from all running service containers are shown.
 Service names can be provided as args to filter logs to only containers for those services.
+Patterns like 'payments-*' can be used to match multiple services.
 
 Examples:
 
@@ -24,7 +25,11 @@ Show logs from all service containers:
 
 Show logs only from the postgres and redis containers:
 
-	tb logs postgres redis`,
+	tb logs postgres redis
+
+Show logs from all payments services:
+
+	tb logs 'payments-*'`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return c.Engine.Logs(c.Ctx, os.Stdout, engine.LogsOptions{
 				ServiceNames: args,
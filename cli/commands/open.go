@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+
+	"github.com/TouchBistro/goutils/fatal"
+	"github.com/TouchBistro/tb/cli"
+	"github.com/spf13/cobra"
+)
+
+type openOptions struct {
+	print bool
+}
+
+func newOpenCommand(c *cli.Container) *cobra.Command {
+	var opts openOptions
+	openCmd := &cobra.Command{
+		Use:   "open <service> [endpoint]",
+		Args:  cobra.RangeArgs(1, 2),
+		Short: "Open a URL of a running service in the browser",
+		Long: `Opens a URL of a running service in the default browser.
+
+URLs are defined by the urls field of the service in its registry. If the service has more than
+one URL, the name of the endpoint to open must be provided.
+
+The port of the URL is looked up from the running container, so the URL is correct even if the port was remapped.
+
+Examples:
+
+Open the admin URL of the venue-core-service service:
+
+	tb open venue-core-service admin
+
+Print the URL instead of opening it, useful on headless machines or over ssh:
+
+	tb open venue-core-service admin --print`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var endpoint string
+			if len(args) > 1 {
+				endpoint = args[1]
+			}
+			u, err := c.Engine.ServiceURL(c.Ctx, args[0], endpoint)
+			if err != nil {
+				return &fatal.Error{
+					Msg: "Make sure the service is running with 'tb up' and has urls, run 'tb info' to see them",
+					Err: err,
+				}
+			}
+			if opts.print {
+				fmt.Println(u)
+				return nil
+			}
+			if err := openBrowser(u); err != nil {
+				// Fallback to showing the URL so it can still be opened manually.
+				c.Tracker.Warnf("⚠️  Unable to open a browser: %v", err)
+				fmt.Println(u)
+				return nil
+			}
+			c.Tracker.Infof("Opened %s", u)
+			return nil
+		},
+	}
+	openCmd.Flags().BoolVar(&opts.print, "print", false, "Print the URL instead of opening it")
+	return openCmd
+}
+
+// openBrowser opens url in the default browser.
+func openBrowser(url string) error {
+	var name string
+	switch runtime.GOOS {
+	case "darwin":
+		name = "open"
+	case "linux":
+		name = "xdg-open"
+	default:
+		return fmt.Errorf("opening a browser is not supported on %s, use --print instead", runtime.GOOS)
+	}
+	return exec.Command(name, url).Run()
+}
@@ -0,0 +1,37 @@
+package playlist
+
+import (
+	"github.com/TouchBistro/goutils/color"
+	"github.com/TouchBistro/tb/cli"
+	"github.com/TouchBistro/tb/config"
+	"github.com/spf13/cobra"
+)
+
+func newAddCommand(c *cli.Container) *cobra.Command {
+	return &cobra.Command{
+		Use:   "add <playlist> <services...>",
+		Args:  cobra.MinimumNArgs(2),
+		Short: "Add services to a custom playlist",
+		Long: `Adds services to a custom playlist. Services that are already in the playlist are skipped.
+
+Services can be patterns like 'payments-*'. Each service must exist in one of your registries.
+
+Examples:
+
+Add the redis service to the my-core playlist:
+
+	tb playlist add my-core redis`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			serviceNames, err := resolveServiceNames(c, args[1:])
+			if err != nil {
+				return err
+			}
+			if err := config.AddPlaylistServices(name, serviceNames, ""); err != nil {
+				return playlistError(err, name, "update")
+			}
+			c.Tracker.Infof(color.Green("Successfully added services to playlist %s"), name)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,93 @@
+package playlist
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/TouchBistro/goutils/color"
+	"github.com/TouchBistro/goutils/fatal"
+	"github.com/TouchBistro/tb/cli"
+	"github.com/TouchBistro/tb/config"
+	"github.com/TouchBistro/tb/resource/playlist"
+	"github.com/spf13/cobra"
+)
+
+type createOptions struct {
+	extends []string
+	exclude []string
+}
+
+func newCreateCommand(c *cli.Container) *cobra.Command {
+	var opts createOptions
+	createCmd := &cobra.Command{
+		Use:   "create <name> [services...]",
+		Args:  cobra.MinimumNArgs(1),
+		Short: "Create a custom playlist",
+		Long: `Creates a custom playlist in ~/.tbrc.yml.
+
+Services can be provided as args, and can be patterns like 'payments-*'. Each service must exist
+in one of your registries. Other playlists can be extended with the --extends flag, and services
+from them can be excluded with the --exclude flag.
+
+Examples:
+
+Create a playlist named my-core with the postgres and venue-core-service services:
+
+	tb playlist create my-core postgres venue-core-service
+
+Create a playlist that extends the core playlist without the analytics-worker service:
+
+	tb playlist create my-core --extends core --exclude analytics-worker`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := playlist.Playlist{Name: args[0], Extends: opts.extends}
+			for _, name := range opts.extends {
+				if _, err := c.Engine.PlaylistInfo(name); err != nil {
+					return &fatal.Error{
+						Msg: fmt.Sprintf("Unable to extend playlist %s", name),
+						Err: err,
+					}
+				}
+			}
+			var err error
+			p.Services, err = resolveServiceNames(c, args[1:])
+			if err != nil {
+				return err
+			}
+			p.Exclude, err = resolveServiceNames(c, opts.exclude)
+			if err != nil {
+				return err
+			}
+
+			err = config.CreatePlaylist(p, false, "")
+			if errors.Is(err, config.ErrPlaylistExists) {
+				return &fatal.Error{
+					Msg: fmt.Sprintf("playlist %s already exists, use 'tb playlist add' to add services to it", p.Name),
+				}
+			} else if err != nil {
+				return playlistError(err, p.Name, "create")
+			}
+			c.Tracker.Infof(color.Green("Successfully created playlist %s"), p.Name)
+			return nil
+		},
+	}
+	flags := createCmd.Flags()
+	flags.StringSliceVar(&opts.extends, "extends", nil, "Playlists to extend")
+	flags.StringSliceVar(&opts.exclude, "exclude", nil, "Services to exclude from the extended playlists")
+	return createCmd
+}
+
+// resolveServiceNames validates serviceNames against the services in the registries
+// and returns the names that should be stored in the playlist.
+func resolveServiceNames(c *cli.Container, serviceNames []string) ([]string, error) {
+	if len(serviceNames) == 0 {
+		return nil, nil
+	}
+	names, err := c.Engine.ResolveServiceNames(serviceNames)
+	if err != nil {
+		return nil, &fatal.Error{
+			Msg: "Try running 'tb list --services' to see available services",
+			Err: err,
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,31 @@
+package playlist
+
+import (
+	"github.com/TouchBistro/goutils/color"
+	"github.com/TouchBistro/tb/cli"
+	"github.com/TouchBistro/tb/config"
+	"github.com/spf13/cobra"
+)
+
+func newDeleteCommand(c *cli.Container) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <playlist>",
+		Args:  cli.ExpectSingleArg("playlist name"),
+		Short: "Delete a custom playlist",
+		Long: `Deletes a custom playlist from ~/.tbrc.yml. Playlists from registries cannot be deleted.
+
+Examples:
+
+Delete the my-core playlist:
+
+	tb playlist delete my-core`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if err := config.DeletePlaylist(name, ""); err != nil {
+				return playlistError(err, name, "delete")
+			}
+			c.Tracker.Infof(color.Green("Successfully deleted playlist %s"), name)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,44 @@
+package playlist
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/TouchBistro/goutils/fatal"
+	"github.com/TouchBistro/tb/cli"
+	"github.com/TouchBistro/tb/config"
+	"github.com/spf13/cobra"
+)
+
+func NewPlaylistCommand(c *cli.Container) *cobra.Command {
+	playlistCmd := &cobra.Command{
+		Use:   "playlist",
+		Short: "Manage custom playlists from the command line",
+		Long: `tb playlist manages custom playlists from the command line.
+
+Custom playlists are stored in the playlists section of ~/.tbrc.yml. Commands that change
+custom playlists preserve any comments in the file.`,
+	}
+	playlistCmd.AddCommand(
+		newAddCommand(c),
+		newCreateCommand(c),
+		newDeleteCommand(c),
+		newRemoveCommand(c),
+		newSaveCommand(c),
+		newShowCommand(c),
+	)
+	return playlistCmd
+}
+
+// playlistError converts an error from changing the playlist with name into a fatal.Error.
+func playlistError(err error, name, action string) error {
+	if errors.Is(err, config.ErrPlaylistNotFound) {
+		return &fatal.Error{
+			Msg: fmt.Sprintf("%s is not a custom playlist, run 'tb list --custom-playlists' to see available custom playlists", name),
+		}
+	}
+	return &fatal.Error{
+		Msg: fmt.Sprintf("failed to %s playlist %s", action, name),
+		Err: err,
+	}
+}
@@ -0,0 +1,35 @@
+package playlist
+
+import (
+	"github.com/TouchBistro/goutils/color"
+	"github.com/TouchBistro/tb/cli"
+	"github.com/TouchBistro/tb/config"
+	"github.com/spf13/cobra"
+)
+
+func newRemoveCommand(c *cli.Container) *cobra.Command {
+	return &cobra.Command{
+		Use:     "remove <playlist> <services...>",
+		Aliases: []string{"rm"},
+		Args:    cobra.MinimumNArgs(2),
+		Short:   "Remove services from a custom playlist",
+		Long: `Removes services from a custom playlist. The services must be written the same way as they are
+in the playlist, run 'tb playlist show' to see them.
+
+To remove services that come from an extended playlist, use the exclude field of the playlist instead.
+
+Examples:
+
+Remove the redis service from the my-core playlist:
+
+	tb playlist remove my-core redis`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if err := config.RemovePlaylistServices(name, args[1:], ""); err != nil {
+				return playlistError(err, name, "update")
+			}
+			c.Tracker.Infof(color.Green("Successfully removed services from playlist %s"), name)
+			return nil
+		},
+	}
+}
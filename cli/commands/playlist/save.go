@@ -0,0 +1,63 @@
+package playlist
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/TouchBistro/goutils/color"
+	"github.com/TouchBistro/goutils/fatal"
+	"github.com/TouchBistro/tb/cli"
+	"github.com/TouchBistro/tb/config"
+	"github.com/TouchBistro/tb/resource/playlist"
+	"github.com/spf13/cobra"
+)
+
+type saveOptions struct {
+	force bool
+}
+
+func newSaveCommand(c *cli.Container) *cobra.Command {
+	var opts saveOptions
+	saveCmd := &cobra.Command{
+		Use:   "save <name>",
+		Args:  cli.ExpectSingleArg("playlist name"),
+		Short: "Save the running services as a custom playlist",
+		Long: `Saves all the services that are currently running as a custom playlist.
+This makes it easy to run the same set of services again later with 'tb up -p <name>'.
+
+Examples:
+
+Save the running services as the my-setup playlist:
+
+	tb playlist save my-setup
+
+Replace the my-setup playlist with the running services:
+
+	tb playlist save my-setup --force`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			serviceNames, err := c.Engine.RunningServices(c.Ctx)
+			if err != nil {
+				return &fatal.Error{
+					Msg: "Failed to find running services",
+					Err: err,
+				}
+			}
+			if len(serviceNames) == 0 {
+				return &fatal.Error{Msg: "No services are running, start some with 'tb up' first"}
+			}
+			err = config.CreatePlaylist(playlist.Playlist{Name: name, Services: serviceNames}, opts.force, "")
+			if errors.Is(err, config.ErrPlaylistExists) {
+				return &fatal.Error{
+					Msg: fmt.Sprintf("playlist %s already exists, use --force to replace it", name),
+				}
+			} else if err != nil {
+				return playlistError(err, name, "save")
+			}
+			c.Tracker.Infof(color.Green("Successfully saved %d running services as playlist %s"), len(serviceNames), name)
+			return nil
+		},
+	}
+	saveCmd.Flags().BoolVarP(&opts.force, "force", "f", false, "Replace the playlist if it already exists")
+	return saveCmd
+}
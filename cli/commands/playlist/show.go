@@ -0,0 +1,83 @@
+package playlist
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/TouchBistro/goutils/color"
+	"github.com/TouchBistro/goutils/fatal"
+	"github.com/TouchBistro/tb/cli"
+	"github.com/spf13/cobra"
+)
+
+func newShowCommand(c *cli.Container) *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <playlist>",
+		Args:  cli.ExpectSingleArg("playlist name"),
+		Short: "Show details about a playlist",
+		Long: `Shows details about a playlist, including the playlists it extends and all the services it runs.
+Both custom playlists and playlists from registries can be shown.
+
+Examples:
+
+Show details about the my-core playlist:
+
+	tb playlist show my-core`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info, err := c.Engine.PlaylistInfo(args[0])
+			if err != nil {
+				return &fatal.Error{
+					Msg: "Try running 'tb list --playlists --custom-playlists' to see available playlists",
+					Err: err,
+				}
+			}
+
+			p := info.Playlist
+			fmt.Println(p.FullName())
+			if info.Custom {
+				fmt.Println("  Custom playlist from ~/.tbrc.yml")
+			}
+			if p.Deprecated != nil {
+				fmt.Printf("  %s\n", color.Yellow("Deprecated: "+p.Deprecated.Message))
+			}
+			printItems("Extends", p.Extends)
+			printItems("Exclude", p.Exclude)
+			printItems("Services", p.Services)
+			if len(p.EnvVars) > 0 {
+				printItems("Env Vars", envVarItems(p.EnvVars))
+			}
+			if len(p.Overrides) > 0 {
+				overrides := make([]string, 0, len(p.Overrides))
+				for k := range p.Overrides {
+					overrides = append(overrides, k)
+				}
+				sort.Strings(overrides)
+				printItems("Overrides", overrides)
+			}
+			fmt.Println()
+			printItems("All Services", info.Services)
+			return nil
+		},
+	}
+}
+
+func printItems(name string, items []string) {
+	fmt.Printf("  %s:\n", name)
+	if len(items) == 0 {
+		fmt.Println("    none")
+		return
+	}
+	for _, item := range items {
+		fmt.Printf("    - %s\n", item)
+	}
+}
+
+// envVarItems returns the env vars as sorted NAME=value items.
+func envVarItems(envVars map[string]string) []string {
+	items := make([]string, 0, len(envVars))
+	for k, v := range envVars {
+		items = append(items, k+"="+v)
+	}
+	sort.Strings(items)
+	return items
+}
@@ -0,0 +1,132 @@
+package registry
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/TouchBistro/goutils/color"
+	"github.com/TouchBistro/goutils/fatal"
+	"github.com/TouchBistro/tb/cli"
+	"github.com/TouchBistro/tb/registry"
+	"github.com/TouchBistro/tb/resource/service"
+	"github.com/spf13/cobra"
+)
+
+type addServiceOptions struct {
+	path string
+}
+
+func newAddServiceCommand(c *cli.Container) *cobra.Command {
+	var opts addServiceOptions
+	addServiceCmd := &cobra.Command{
+		Use:   "add-service [name]",
+		Args:  cobra.MaximumNArgs(1),
+		Short: "Add a service to a registry",
+		Long: `Interactively adds a service to the services.yml file of a registry.
+The service is validated before being added. Any existing comments in services.yml are preserved.
+
+Examples:
+
+Add a service to the registry in the current directory:
+
+	tb registry add-service
+
+Add a service named postgres to the registry in the my-registry directory:
+
+	tb registry add-service postgres --path my-registry`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var s service.Service
+			if len(args) > 0 {
+				s.Name = args[0]
+			}
+			if err := promptService(&s); err != nil {
+				return &fatal.Error{Msg: "Failed to prompt for service details", Err: err}
+			}
+			if err := registry.AddService(opts.path, s); err != nil {
+				return &fatal.Error{
+					Msg: fmt.Sprintf("Failed to add service %s", s.Name),
+					Err: err,
+				}
+			}
+			c.Tracker.Infof(color.Green("Successfully added service %s to %s"), s.Name, registry.ServicesFileName)
+			return nil
+		},
+	}
+
+	flags := addServiceCmd.Flags()
+	flags.StringVar(&opts.path, "path", ".", "Path to the registry")
+	return addServiceCmd
+}
+
+// promptService prompts the user for the details of a service and sets them on s.
+// If s.Name is already set, the user is not prompted for the name.
+func promptService(s *service.Service) error {
+	if s.Name == "" {
+		err := survey.AskOne(&survey.Input{Message: "Service name:"}, &s.Name, survey.WithValidator(survey.Required))
+		if err != nil {
+			return err
+		}
+	}
+	err := survey.AskOne(&survey.Select{
+		Message: "Mode:",
+		Options: []string{service.ModeRemote, service.ModeBuild},
+		Help:    "remote pulls an image from a docker registry, build builds the image locally",
+	}, &s.Mode)
+	if err != nil {
+		return err
+	}
+
+	var repo string
+	if err := survey.AskOne(&survey.Input{Message: "GitHub repo (org/repo, optional):"}, &repo); err != nil {
+		return err
+	}
+	s.GitRepo.Name = repo
+
+	if s.Mode == service.ModeRemote {
+		err := survey.AskOne(&survey.Input{Message: "Image:"}, &s.Remote.Image, survey.WithValidator(survey.Required))
+		if err != nil {
+			return err
+		}
+		if err := survey.AskOne(&survey.Input{Message: "Tag (optional):"}, &s.Remote.Tag); err != nil {
+			return err
+		}
+	} else {
+		prompt := &survey.Input{Message: "Dockerfile path:"}
+		if repo != "" {
+			prompt.Default = "${@REPOPATH}"
+		}
+		if err := survey.AskOne(prompt, &s.Build.DockerfilePath, survey.WithValidator(survey.Required)); err != nil {
+			return err
+		}
+	}
+
+	var ports, deps string
+	if err := survey.AskOne(&survey.Input{Message: "Ports (EXTERNAL:INTERNAL, comma separated, optional):"}, &ports); err != nil {
+		return err
+	}
+	s.Ports = splitList(ports)
+	err = survey.AskOne(&survey.Input{
+		Message: "Dependencies (comma separated, optional):",
+		Help:    "Use ${@<service>} to reference other services in the registry, ex: ${@postgres}",
+	}, &deps)
+	if err != nil {
+		return err
+	}
+	s.Dependencies = splitList(deps)
+	if err := survey.AskOne(&survey.Input{Message: "Env file (optional):"}, &s.EnvFile); err != nil {
+		return err
+	}
+	return survey.AskOne(&survey.Input{Message: "Pre-run script (optional):"}, &s.PreRun)
+}
+
+// splitList splits a comma separated list into its trimmed non-empty elements.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
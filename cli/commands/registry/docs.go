@@ -0,0 +1,87 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/TouchBistro/goutils/color"
+	"github.com/TouchBistro/goutils/fatal"
+	"github.com/TouchBistro/tb/cli"
+	"github.com/TouchBistro/tb/config"
+	"github.com/TouchBistro/tb/registry"
+	"github.com/spf13/cobra"
+)
+
+type docsOptions struct {
+	format string
+	output string
+	title  string
+}
+
+func newDocsCommand(c *cli.Container) *cobra.Command {
+	var opts docsOptions
+	docsCmd := &cobra.Command{
+		Use:   "docs [registry-name...]",
+		Short: "Generate a catalog of services, playlists, and apps",
+		Long: `Generates a catalog documenting the services, playlists, and apps in registries.
+If no registry names are provided, all registries in the tbrc are included.
+
+The catalog contains the mode, image, ports, dependencies, repo, and env var names of every service,
+the services in every playlist, including those from playlists it extends, and the details of every app.
+Env var values are not included since they may be sensitive.
+
+Examples:
+
+Print a markdown catalog of all registries:
+
+	tb registry docs
+
+Write an HTML catalog of the TouchBistro/tb-registry registry to catalog.html:
+
+	tb registry docs TouchBistro/tb-registry --format html --output catalog.html`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := c.Config
+			result, err := config.ReadRegistries(cfg, args)
+			if errors.Is(err, config.ErrRegistryNotFound) {
+				return &fatal.Error{
+					Msg: "Try running 'tb registry list' to see available registries",
+					Err: err,
+				}
+			} else if err != nil {
+				return &fatal.Error{
+					Msg: "Failed to read registries, try running 'tb registry update'",
+					Err: err,
+				}
+			}
+
+			var w io.Writer = os.Stdout
+			if opts.output != "" {
+				f, err := os.Create(opts.output)
+				if err != nil {
+					return &fatal.Error{
+						Msg: fmt.Sprintf("Failed to create %s", opts.output),
+						Err: err,
+					}
+				}
+				defer f.Close()
+				w = f
+			}
+			err = registry.WriteCatalog(w, result, registry.CatalogOptions{Format: opts.format, Title: opts.title})
+			if err != nil {
+				return &fatal.Error{Msg: "Failed to generate catalog", Err: err}
+			}
+			if opts.output != "" {
+				c.Tracker.Infof(color.Green("Successfully wrote catalog to %s"), opts.output)
+			}
+			return nil
+		},
+	}
+
+	flags := docsCmd.Flags()
+	flags.StringVar(&opts.format, "format", registry.CatalogFormatMarkdown, "The format of the catalog: markdown or html")
+	flags.StringVar(&opts.output, "output", "", "File to write the catalog to, defaults to stdout")
+	flags.StringVar(&opts.title, "title", "", "Title of the catalog")
+	return docsCmd
+}
@@ -0,0 +1,80 @@
+package registry
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/TouchBistro/goutils/color"
+	"github.com/TouchBistro/goutils/fatal"
+	"github.com/TouchBistro/tb/cli"
+	"github.com/TouchBistro/tb/registry"
+	"github.com/spf13/cobra"
+)
+
+type importComposeOptions struct {
+	path string
+	repo string
+}
+
+func newImportComposeCommand(c *cli.Container) *cobra.Command {
+	var opts importComposeOptions
+	importComposeCmd := &cobra.Command{
+		Use:   "import-compose <file>",
+		Args:  cli.ExpectSingleArg("compose file"),
+		Short: "Import services from a docker compose file into a registry",
+		Long: `Converts the services in a docker compose file to tb services and adds them to the services.yml file of a registry.
+
+Relative paths in the compose file are converted to be relative to the repo using ${@REPOPATH}.
+Use --repo to set the repo containing the compose file. Dependencies between services are converted to use ${@<service>}.
+
+Any compose features that tb does not support are printed as warnings and are not imported.
+If any service cannot be added, for example because it is already defined in the registry, no services are imported.
+
+Examples:
+
+Import the services from the docker-compose.yml file of a repo into the registry in the current directory:
+
+	tb registry import-compose ~/dev/api/docker-compose.yml --repo example/api
+
+Import services into the registry in the my-registry directory:
+
+	tb registry import-compose docker-compose.yml --path my-registry`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			composePath := args[0]
+			data, err := os.ReadFile(composePath)
+			if err != nil {
+				return &fatal.Error{
+					Msg: fmt.Sprintf("Failed to read %s", composePath),
+					Err: err,
+				}
+			}
+			result, err := registry.ImportCompose(data, registry.ImportComposeOptions{RepoName: opts.repo})
+			if err != nil {
+				return &fatal.Error{
+					Msg: fmt.Sprintf("Failed to import %s", composePath),
+					Err: err,
+				}
+			}
+			for _, w := range result.Warnings {
+				c.Tracker.Warnf("⚠️  %s", w)
+			}
+			// Add all services at once so nothing is added if any of them are invalid.
+			if err := registry.AddServices(opts.path, result.Services); err != nil {
+				return &fatal.Error{
+					Msg: fmt.Sprintf("Failed to import %s, no services were added", composePath),
+					Err: err,
+				}
+			}
+			for _, s := range result.Services {
+				c.Tracker.Infof("Added service %s", s.Name)
+			}
+			c.Tracker.Infof(color.Green("Successfully imported %d services to %s"), len(result.Services), registry.ServicesFileName)
+			return nil
+		},
+	}
+
+	flags := importComposeCmd.Flags()
+	flags.StringVar(&opts.path, "path", ".", "Path to the registry")
+	flags.StringVar(&opts.repo, "repo", "", "Git repo containing the compose file, of the form org/repo")
+	return importComposeCmd
+}
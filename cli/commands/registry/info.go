@@ -0,0 +1,65 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/TouchBistro/goutils/fatal"
+	"github.com/TouchBistro/tb/cli"
+	"github.com/TouchBistro/tb/config"
+	"github.com/spf13/cobra"
+)
+
+func newInfoCommand(c *cli.Container) *cobra.Command {
+	return &cobra.Command{
+		Use:   "info <registry-name>",
+		Args:  cli.ExpectSingleArg("registry name"),
+		Short: "Show details about a registry",
+		Long: `Shows details about a registry, including the number of resources it contains,
+the base images it uses, and the login strategies it requires.
+
+Examples:
+
+Show details about the registry named TouchBistro/tb-registry:
+
+	tb registry info TouchBistro/tb-registry`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			registryName := args[0]
+			cfg := c.Config
+			result, err := config.ReadRegistry(cfg, registryName)
+			if errors.Is(err, config.ErrRegistryNotFound) {
+				return &fatal.Error{
+					Msg: "Try running 'tb registry list' to see available registries",
+					Err: err,
+				}
+			} else if err != nil {
+				return &fatal.Error{
+					Msg: fmt.Sprintf("Failed to read registry %s, try running 'tb registry update %s'", registryName, registryName),
+					Err: err,
+				}
+			}
+
+			fmt.Println(registryName)
+			fmt.Printf("  Services: %d\n", result.Services.Len())
+			fmt.Printf("  Playlists: %d\n", len(result.Playlists.Names()))
+			fmt.Printf("  iOS Apps: %d\n", result.IOSApps.Len())
+			fmt.Printf("  Desktop Apps: %d\n", result.DesktopApps.Len())
+			printList("Base Images", result.BaseImages)
+			printList("Login Strategies", result.LoginStrategies)
+			return nil
+		},
+	}
+}
+
+func printList(title string, items []string) {
+	fmt.Printf("  %s:\n", title)
+	if len(items) == 0 {
+		fmt.Println("    none")
+		return
+	}
+	sort.Strings(items)
+	for _, item := range items {
+		fmt.Printf("    - %s\n", item)
+	}
+}
@@ -0,0 +1,59 @@
+package registry
+
+import (
+	"fmt"
+
+	"github.com/TouchBistro/goutils/fatal"
+	"github.com/TouchBistro/tb/cli"
+	"github.com/TouchBistro/tb/config"
+	"github.com/spf13/cobra"
+)
+
+func newListCommand(c *cli.Container) *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Args:    cobra.NoArgs,
+		Short:   "List registries",
+		Long: `Lists all the registries that have been added to tb along with details about each one.
+
+Examples:
+
+List all registries:
+
+	tb registry list`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := c.Config
+			statuses, err := config.RegistryStatuses(c.Ctx, cfg)
+			if err != nil {
+				return &fatal.Error{Msg: "Failed to get registry details", Err: err}
+			}
+			if len(statuses) == 0 {
+				c.Tracker.Info("No registries have been added, add one with 'tb registry add'")
+				return nil
+			}
+			for _, s := range statuses {
+				kind := "remote"
+				if s.Registry.LocalPath != "" {
+					kind = "local"
+				}
+				commit := "unknown"
+				if !s.Exists {
+					commit = "not cloned"
+				} else if len(s.Commit) >= 7 {
+					commit = s.Commit[:7]
+				}
+				lastUpdated := "unknown"
+				if !s.LastUpdated.IsZero() {
+					lastUpdated = s.LastUpdated.Format("2006-01-02 15:04:05")
+				}
+				fmt.Println(s.Registry.Name)
+				fmt.Printf("  Path: %s\n", s.Registry.Path)
+				fmt.Printf("  Type: %s\n", kind)
+				fmt.Printf("  Commit: %s\n", commit)
+				fmt.Printf("  Last updated: %s\n", lastUpdated)
+			}
+			return nil
+		},
+	}
+}
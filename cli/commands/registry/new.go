@@ -0,0 +1,41 @@
+package registry
+
+import (
+	"fmt"
+
+	"github.com/TouchBistro/goutils/color"
+	"github.com/TouchBistro/goutils/fatal"
+	"github.com/TouchBistro/tb/cli"
+	"github.com/TouchBistro/tb/registry"
+	"github.com/spf13/cobra"
+)
+
+func newNewCommand(c *cli.Container) *cobra.Command {
+	return &cobra.Command{
+		Use:   "new <dir>",
+		Args:  cli.ExpectSingleArg("registry directory"),
+		Short: "Create a new registry",
+		Long: `Creates a new registry in the given directory. The directory will be created if it does not exist.
+
+The registry contains services.yml, playlists.yml, and apps.yml files with commented examples
+of every supported field, along with a static directory for any files services need.
+
+Examples:
+
+Create a new registry in the my-registry directory:
+
+	tb registry new my-registry`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := args[0]
+			if err := registry.Scaffold(dir); err != nil {
+				return &fatal.Error{
+					Msg: fmt.Sprintf("Failed to create registry in %s", dir),
+					Err: err,
+				}
+			}
+			c.Tracker.Infof(color.Green("Successfully created registry in %s"), dir)
+			c.Tracker.Infof("Add a service by running 'tb registry add-service --path %s'", dir)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,45 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/TouchBistro/goutils/color"
+	"github.com/TouchBistro/goutils/fatal"
+	"github.com/TouchBistro/tb/cli"
+	"github.com/TouchBistro/tb/config"
+	"github.com/spf13/cobra"
+)
+
+func newRemoveCommand(c *cli.Container) *cobra.Command {
+	return &cobra.Command{
+		Use:     "remove <registry-name>",
+		Aliases: []string{"rm"},
+		Args:    cli.ExpectSingleArg("registry name"),
+		Short:   "Remove a registry",
+		Long: `Removes a registry from tb. If the registry was cloned by tb, the clone is also deleted.
+Local registries are never deleted from the filesystem.
+
+Examples:
+
+Remove the registry named TouchBistro/tb-registry-example:
+
+	tb registry remove TouchBistro/tb-registry-example`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			registryName := args[0]
+			err := config.RemoveRegistry(registryName, "")
+			if errors.Is(err, config.ErrRegistryNotFound) {
+				return &fatal.Error{
+					Msg: fmt.Sprintf("registry %s has not been added, run 'tb registry list' to see available registries", registryName),
+				}
+			} else if err != nil {
+				return &fatal.Error{
+					Msg: fmt.Sprintf("failed to remove registry %s", registryName),
+					Err: err,
+				}
+			}
+			c.Tracker.Infof(color.Green("Successfully removed registry %s"), registryName)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,44 @@
+package registry
+
+import (
+	"errors"
+
+	"github.com/TouchBistro/goutils/color"
+	"github.com/TouchBistro/goutils/fatal"
+	"github.com/TouchBistro/tb/cli"
+	"github.com/TouchBistro/tb/config"
+	"github.com/spf13/cobra"
+)
+
+func newUpdateCommand(c *cli.Container) *cobra.Command {
+	return &cobra.Command{
+		Use:   "update [registry-name...]",
+		Short: "Update registries",
+		Long: `Updates registries to the latest version. Any registries that have not been cloned yet are cloned.
+If no registry names are provided, all registries are updated. Local registries are skipped
+since you are responsible for keeping them up to date.
+
+Examples:
+
+Update all registries:
+
+	tb registry update
+
+Update only the registry named TouchBistro/tb-registry:
+
+	tb registry update TouchBistro/tb-registry`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := config.UpdateRegistries(c.Ctx, c.Config, args)
+			if errors.Is(err, config.ErrRegistryNotFound) {
+				return &fatal.Error{
+					Msg: "Try running 'tb registry list' to see available registries",
+					Err: err,
+				}
+			} else if err != nil {
+				return &fatal.Error{Msg: "Failed to update registries", Err: err}
+			}
+			c.Tracker.Info(color.Green("Successfully updated registries"))
+			return nil
+		},
+	}
+}
@@ -2,19 +2,31 @@ package registry
 
 import (
 	"errors"
+	"fmt"
 	"io/fs"
+	"path/filepath"
+	"strings"
 
 	"github.com/TouchBistro/goutils/color"
 	"github.com/TouchBistro/goutils/fatal"
 	"github.com/TouchBistro/tb/cli"
+	"github.com/TouchBistro/tb/config"
 	"github.com/TouchBistro/tb/registry"
 	"github.com/spf13/cobra"
 )
 
 type validateOptions struct {
-	strict bool
+	strict  bool
+	format  string
+	resolve bool
 }
 
+// Output formats supported by validate.
+const (
+	formatText   = "text"
+	formatGitHub = "github"
+)
+
 func newValidateCommand(c *cli.Container) *cobra.Command {
 	var opts validateOptions
 	validateCmd := &cobra.Command{
@@ -22,48 +34,83 @@ func newValidateCommand(c *cli.Container) *cobra.Command {
 		Args:  cli.ExpectSingleArg("registry path"),
 		Short: "Validate the config files in a registry",
 		Long: `Validates the config files in a registry at the given path.
+This includes any files in the services.d, playlists.d, and apps.d directories.
 
 Examples:
 
 Validate the config files in the current directory:
 
-	tb registry validate .`,
+	tb registry validate .
+
+Validate the config files and also report unknown fields, which are usually typos:
+
+	tb registry validate . --strict
+
+Validate the config files and check references to services and playlists in the registries in your tbrc:
+
+	tb registry validate . --resolve
+
+Validate the config files in a GitHub Actions workflow so problems are shown inline on pull requests:
+
+	tb registry validate . --format github`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.format != formatText && opts.format != formatGitHub {
+				return &fatal.Error{
+					Msg: fmt.Sprintf("invalid format %q, must be %q or %q", opts.format, formatText, formatGitHub),
+				}
+			}
 			registryPath := args[0]
+			validateOpts := registry.ValidateOptions{
+				Strict: opts.strict,
+				Logger: c.Tracker,
+			}
+			if opts.resolve {
+				var err error
+				validateOpts.Registries, err = config.ResolveRegistries(c.Config, "")
+				if err != nil {
+					return &fatal.Error{Msg: "Failed to resolve registries", Err: err}
+				}
+				validateOpts.ReposPath, err = config.ReposPath("")
+				if err != nil {
+					return &fatal.Error{Msg: "Failed to resolve repos path", Err: err}
+				}
+			}
 			c.Tracker.Infof(color.Cyan("Validating registry files at path %q"), registryPath)
 
 			valid := true
-			result := registry.Validate(registryPath, registry.ValidateOptions{
-				Strict: opts.strict,
-				Logger: c.Tracker,
-			})
+			result := registry.Validate(registryPath, validateOpts)
 			if errors.Is(result.AppsErr, fs.ErrNotExist) {
-				c.Tracker.Infof(color.Yellow("No %s file"), registry.AppsFileName)
+				c.Tracker.Infof(color.Yellow("No %s file or %s directory"), registry.AppsFileName, registry.AppsDirName)
 			} else if result.AppsErr == nil {
-				c.Tracker.Infof(color.Green("✅ %s is valid"), registry.AppsFileName)
+				c.Tracker.Info(color.Green("✅ apps config is valid"))
 			} else {
-				c.Tracker.Infof("❌ %s is invalid\n%v", registry.AppsFileName, result.AppsErr)
+				c.Tracker.Infof("❌ apps config is invalid\n%v", result.AppsErr)
 				valid = false
 			}
 
 			if errors.Is(result.PlaylistsErr, fs.ErrNotExist) {
-				c.Tracker.Infof(color.Yellow("No %s file"), registry.PlaylistsFileName)
+				c.Tracker.Infof(color.Yellow("No %s file or %s directory"), registry.PlaylistsFileName, registry.PlaylistsDirName)
 			} else if result.PlaylistsErr == nil {
-				c.Tracker.Infof(color.Green("✅ %s is valid"), registry.PlaylistsFileName)
+				c.Tracker.Info(color.Green("✅ playlists config is valid"))
 			} else {
-				c.Tracker.Infof("❌ %s is invalid\n%v", registry.PlaylistsFileName, result.PlaylistsErr)
+				c.Tracker.Infof("❌ playlists config is invalid\n%v", result.PlaylistsErr)
 				valid = false
 			}
 
 			if errors.Is(result.ServicesErr, fs.ErrNotExist) {
-				c.Tracker.Infof(color.Yellow("No %s file"), registry.ServicesFileName)
+				c.Tracker.Infof(color.Yellow("No %s file or %s directory"), registry.ServicesFileName, registry.ServicesDirName)
 			} else if result.ServicesErr == nil {
-				c.Tracker.Infof(color.Green("✅ %s is valid"), registry.ServicesFileName)
+				c.Tracker.Info(color.Green("✅ services config is valid"))
 			} else {
-				c.Tracker.Infof("❌ %s is invalid\n%v", registry.ServicesFileName, result.ServicesErr)
+				c.Tracker.Infof("❌ services config is invalid\n%v", result.ServicesErr)
 				valid = false
 			}
 
+			if opts.format == formatGitHub {
+				for _, p := range result.Problems() {
+					fmt.Println(githubAnnotation(registryPath, p))
+				}
+			}
 			if !valid {
 				return &fatal.Error{
 					Msg: color.Red("❌ registry is invalid"),
@@ -75,6 +122,47 @@ Validate the config files in the current directory:
 	}
 
 	flags := validateCmd.Flags()
-	flags.BoolVar(&opts.strict, "strict", false, "Strict mode, treat more cases as errors")
+	flags.BoolVar(&opts.strict, "strict", false, "Strict mode, treat more cases as errors, including unknown fields")
+	flags.StringVar(&opts.format, "format", formatText, "Output format, valid values: text, github")
+	flags.BoolVar(&opts.resolve, "resolve", false, "Resolve references to other registries and cloned repos using your tbrc")
 	return validateCmd
 }
+
+// githubAnnotation formats p as a GitHub Actions workflow command that creates an error annotation.
+// See https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#setting-an-error-message.
+func githubAnnotation(registryPath string, p registry.Problem) string {
+	var props []string
+	if p.Pos.IsValid() {
+		// GitHub expects paths relative to the root of the repo, which is usually the working directory.
+		path := filepath.ToSlash(filepath.Join(registryPath, p.Pos.File))
+		props = append(props, "file="+escapeAnnotationProperty(path))
+		if p.Pos.Line > 0 {
+			props = append(props, fmt.Sprintf("line=%d", p.Pos.Line))
+		}
+		if p.Pos.Column > 0 {
+			props = append(props, fmt.Sprintf("col=%d", p.Pos.Column))
+		}
+	}
+	var sb strings.Builder
+	sb.WriteString("::error")
+	if len(props) > 0 {
+		sb.WriteByte(' ')
+		sb.WriteString(strings.Join(props, ","))
+	}
+	sb.WriteString("::")
+	sb.WriteString(escapeAnnotationData(p.Message))
+	return sb.String()
+}
+
+var (
+	annotationDataEscaper     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	annotationPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+func escapeAnnotationData(s string) string {
+	return annotationDataEscaper.Replace(s)
+}
+
+func escapeAnnotationProperty(s string) string {
+	return annotationPropertyEscaper.Replace(s)
+}
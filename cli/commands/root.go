@@ -2,6 +2,7 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -11,6 +12,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/AlecAivazis/survey/v2"
 	"github.com/TouchBistro/goutils/color"
 	"github.com/TouchBistro/goutils/fatal"
 	"github.com/TouchBistro/goutils/logutil"
@@ -18,13 +20,18 @@ import (
 	"github.com/TouchBistro/goutils/spinner"
 	"github.com/TouchBistro/tb/cli"
 	appCommands "github.com/TouchBistro/tb/cli/commands/app"
+	configCommands "github.com/TouchBistro/tb/cli/commands/config"
+	playlistCommands "github.com/TouchBistro/tb/cli/commands/playlist"
 	registryCommands "github.com/TouchBistro/tb/cli/commands/registry"
 	"github.com/TouchBistro/tb/config"
 	"github.com/TouchBistro/tb/integrations/github"
 	"github.com/TouchBistro/tb/internal/fortune"
 	"github.com/TouchBistro/tb/internal/util"
+	"github.com/TouchBistro/tb/registry"
+	"github.com/TouchBistro/tb/resource"
 	"github.com/blang/semver/v4"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"golang.org/x/term"
 )
 
@@ -32,6 +39,35 @@ type rootOptions struct {
 	noRegistryPull bool
 	verbose        bool
 	offlineMode    bool
+	experimental   bool
+	gitConcurrency int
+	timeoutSeconds int
+	registries     []string
+	timeouts       map[string]int
+}
+
+// settings returns the config settings from the flags that were set.
+func (opts *rootOptions) settings(flags *pflag.FlagSet) (config.Settings, error) {
+	s := config.Settings{FromFlags: true}
+	if flags.Changed("experimental") {
+		s.Experimental = &opts.experimental
+	}
+	if flags.Changed("git-concurrency") {
+		s.GitConcurrency = &opts.gitConcurrency
+	}
+	if flags.Changed("timeout-seconds") {
+		s.TimeoutSeconds = &opts.timeoutSeconds
+	}
+	if flags.Changed("registries") {
+		s.Registries = opts.registries
+	}
+	if flags.Changed("timeouts") {
+		var err error
+		if s.Timeouts, err = config.ParseTimeouts(opts.timeouts); err != nil {
+			return s, err
+		}
+	}
+	return s, nil
 }
 
 func NewRootCommand(c *cli.Container, version string) *cobra.Command {
@@ -62,13 +98,25 @@ func NewRootCommand(c *cli.Container, version string) *cobra.Command {
 			fmt.Fprintln(os.Stderr, color.Magenta(fortune.Random().Pretty(termWidth)))
 
 			// Get the user config, pass empty string to have it find the config file
-			cfg, err := config.Read("")
+			// Flags take precedence over both config files and environment variables.
+			cfg, err := config.Load("", "")
 			if err != nil {
-				return &fatal.Error{
-					Msg: "Failed to load tbrc",
-					Err: err,
+				// Config commands are used to diagnose and fix config files, so they must be able
+				// to run even if the files are invalid. They handle the error themselves.
+				if cmd.Parent().Name() != "config" {
+					return &fatal.Error{
+						Msg: "Failed to load tbrc",
+						Err: err,
+					}
 				}
+				c.ConfigErr = err
 			}
+			settings, err := opts.settings(cmd.Flags())
+			if err != nil {
+				return &fatal.Error{Msg: "Invalid value for --timeouts", Err: err}
+			}
+			cfg = config.Apply(cfg, settings)
+			c.Config = cfg
 			c.Verbose = opts.verbose || cfg.DebugEnabled()
 			c.OfflineMode = opts.offlineMode
 
@@ -98,7 +146,23 @@ func NewRootCommand(c *cli.Container, version string) *cobra.Command {
 			// Any special messages based on user config
 			if cfg.Debug != nil {
 				// This prints a warning sign
-				c.Tracker.Warn("\u26A0\uFE0F  Using the 'debug' field in tbrc.yml is deprecated. Use the '--verbose' or '-v' flag instead.")
+				c.Tracker.Warn("\u26A0\uFE0F  Using the 'debug' field in tbrc.yml is deprecated. Use the '--verbose' or '-v' flag instead, 'tb config migrate' will remove it.")
+			}
+			if len(cfg.Sources) > 1 {
+				c.Tracker.Debugf("Using config files: %s", strings.Join(cfg.Sources, ", "))
+			}
+			// Config commands report problems themselves.
+			// Only the files that were loaded are checked, there may be none if tb
+			// is configured entirely with environment variables.
+			if cmd.Parent().Name() != "config" {
+				for _, path := range cfg.Sources {
+					if err := config.ValidateFile(path); err != nil {
+						c.Tracker.Warnf("\u26A0\uFE0F  Found problems in %s, they will be ignored:\n%v", path, err)
+					}
+				}
+				for _, path := range cfg.Outdated() {
+					c.Tracker.Warnf("\u26A0\uFE0F  %s uses an old config format, run 'tb config migrate %s' to update it.", path, path)
+				}
 			}
 			if cfg.ExperimentalMode {
 				c.Tracker.Info(color.Yellow("🚧 Experimental mode enabled 🚧"))
@@ -107,11 +171,24 @@ func NewRootCommand(c *cli.Container, version string) *cobra.Command {
 			checkVersion(cmd.Context(), version, c.Tracker)
 			checkDepVersion(cmd.Context(), c.Tracker)
 
+			// Create the context that commands can use.
+			// Generally it is recommended not to store contexts in structs, however this case is special
+			// since only one command runs on the each invocation of tb and the container can be seen
+			// as special parameters to the command. Also cobra does with cmd.Context().
+			c.Ctx = progress.ContextWithTracker(cmd.Context(), c.Tracker)
+
 			// Determine how to proceed based on the type of command
-			initOpts := config.InitOptions{UpdateRegistries: !opts.noRegistryPull && !opts.offlineMode}
+			initOpts := config.InitOptions{
+				UpdateRegistries: !opts.noRegistryPull && !opts.offlineMode,
+				TbVersion:        version,
+			}
+			// Only prompt if a person is there to answer, otherwise ambiguous names are errors.
+			if term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stderr.Fd())) {
+				initOpts.ChooseResource = chooseResource
+			}
 			switch cmd.Parent().Name() {
-			case "registry":
-				// No further action required for registry commands
+			case "config", "registry":
+				// No further action required for config and registry commands
 				return nil
 			case "ios":
 				if !util.IsMacOS {
@@ -124,13 +201,13 @@ func NewRootCommand(c *cli.Container, version string) *cobra.Command {
 				initOpts.LoadServices = true
 			}
 
-			// Create the context that commands can use.
-			// Generally it is recommended not to store contexts in structs, however this case is special
-			// since only one command runs on the each invocation of tb and the container can be seen
-			// as special parameters to the command. Also cobra does with cmd.Context().
-			c.Ctx = progress.ContextWithTracker(cmd.Context(), c.Tracker)
 			c.Engine, err = config.Init(c.Ctx, cfg, initOpts)
-			if err != nil {
+			if errors.Is(err, registry.ErrIncompatible) {
+				return &fatal.Error{
+					Msg: "Please update tb by running 'brew update && brew upgrade tb'",
+					Err: err,
+				}
+			} else if err != nil {
 				return &fatal.Error{
 					Msg: "Failed to load registries",
 					Err: err,
@@ -144,22 +221,45 @@ func NewRootCommand(c *cli.Container, version string) *cobra.Command {
 	persistentFlags.BoolVar(&opts.noRegistryPull, "no-registry-pull", false, "Don't pull latest version of registries when tb is run")
 	persistentFlags.BoolVarP(&opts.offlineMode, "offline", "o", false, "Skip operations requiring internet connectivity")
 	persistentFlags.BoolVarP(&opts.verbose, "verbose", "v", false, "Enable verbose logging")
+	persistentFlags.BoolVar(&opts.experimental, "experimental", false, "Enable experimental mode, overrides "+config.EnvExperimental+" and tbrc")
+	persistentFlags.IntVar(&opts.gitConcurrency, "git-concurrency", 0, "Max number of concurrent git operations, overrides "+config.EnvGitConcurrency+" and tbrc")
+	persistentFlags.IntVar(&opts.timeoutSeconds, "timeout-seconds", 0, "Timeout for operations in seconds, overrides "+config.EnvTimeoutSeconds+" and tbrc")
+	persistentFlags.StringToIntVar(&opts.timeouts, "timeouts", nil, "Timeouts in seconds for each phase, ex: build=3600,pull=600, overrides "+config.EnvTimeouts+" and tbrc")
+	persistentFlags.StringSliceVar(&opts.registries, "registries", nil, "Comma separated list of registries to use, overrides "+config.EnvRegistries+" and tbrc")
 	rootCmd.AddCommand(
 		appCommands.NewAppCommand(c),
+		configCommands.NewConfigCommand(c),
+		playlistCommands.NewPlaylistCommand(c),
 		registryCommands.NewRegistryCommand(c),
 		newCloneCommand(c),
 		newDBCommand(c),
 		newDownCommand(c),
 		newExecCommand(c),
+		newGraphCommand(c),
 		newImagesCommand(c),
+		newInfoCommand(c),
 		newListCommand(c),
 		newLogsCommand(c),
 		newNukeCommand(c),
+		newOpenCommand(c),
 		newUpCommand(c),
 	)
 	return rootCmd
 }
 
+// chooseResource prompts the user to pick one of the resources matching an ambiguous name.
+func chooseResource(t resource.Type, name string, candidates []string) (string, error) {
+	prompt := &survey.Select{
+		Message: fmt.Sprintf("Multiple %ss are named %s, which one did you mean?", t, name),
+		Options: candidates,
+	}
+	var selected string
+	if err := survey.AskOne(prompt, &selected, survey.WithStdio(os.Stdin, os.Stderr, os.Stderr)); err != nil {
+		return "", err
+	}
+	return selected, nil
+}
+
 func checkVersion(ctx context.Context, version string, logger progress.Logger) {
 	currentVersion, err := semver.Parse(version)
 	if err != nil {
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
@@ -10,7 +11,9 @@ import (
 	"github.com/TouchBistro/goutils/fatal"
 	"github.com/TouchBistro/tb/cli"
 	"github.com/TouchBistro/tb/engine"
+	"github.com/TouchBistro/tb/resource/service"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 type upOptions struct {
@@ -21,6 +24,8 @@ type upOptions struct {
 	playlistName      string
 	serviceNames      []string
 	serviceTags       []string
+	overrides         []string
+	overridesFile     string
 }
 
 func parseTag(tag string, serviceNames []string) ([]string, error) {
@@ -44,6 +49,48 @@ func parseTag(tag string, serviceNames []string) ([]string, error) {
 	return parts, nil
 }
 
+// parseOverride parses an override of the form '<service>.<field>=<value>' and returns its parts.
+// Service names and patterns cannot contain dots, so the service name ends at the first dot.
+// The field is checked when it is set with service.SetOverrideField.
+func parseOverride(override string) (serviceName, path, value string, err error) {
+	key, value, ok := strings.Cut(override, "=")
+	serviceName, path, hasPath := strings.Cut(key, ".")
+	if !ok || !hasPath || serviceName == "" || path == "" {
+		return "", "", "", fmt.Errorf("invalid override '%s'; expected format 'service.field=value'", override)
+	}
+	return serviceName, path, value, nil
+}
+
+// readOverrides reads the overrides from overridesFile, if provided, and then applies the
+// overrides from --set on top of them.
+func readOverrides(overridesFile string, sets []string) (map[string]service.ServiceOverride, error) {
+	overrides := make(map[string]service.ServiceOverride)
+	if overridesFile != "" {
+		f, err := os.Open(overridesFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		dec := yaml.NewDecoder(f)
+		dec.KnownFields(true)
+		if err := dec.Decode(&overrides); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("invalid overrides file %s: %w", overridesFile, err)
+		}
+	}
+	for _, set := range sets {
+		serviceName, path, value, err := parseOverride(set)
+		if err != nil {
+			return nil, err
+		}
+		o, err := service.SetOverrideField(overrides[serviceName], path, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid override '%s'; %w", set, err)
+		}
+		overrides[serviceName] = o
+	}
+	return overrides, nil
+}
+
 func newUpCommand(c *cli.Container) *cobra.Command {
 	var opts upOptions
 	upCmd := &cobra.Command{
@@ -76,6 +123,10 @@ Services can be specified in one of two ways. First, the names of the services c
 Second, the --playlist,-p flag can be used to provide a playlist name in order to start all the services in the playlist.
 If a playlist is provided no args can be provided, that is, mixing a playlist and service names is not allowed.
 
+Service names can also be patterns, like 'payments-*' or 'TouchBistro/tb-registry/*', to start all matching services.
+Patterns without a registry are matched against the short names of services. Make sure to quote patterns so that
+they are not expanded by the shell.
+
 Examples:
 
 Run the services defined in the 'core' playlist in a registry:
@@ -84,7 +135,22 @@ Run the services defined in the 'core' playlist in a registry:
 
 Run the postgres and localstack services directly:
 
-	tb up postgres localstack`,
+	tb up postgres localstack
+
+Run all services whose names start with payments-:
+
+	tb up 'payments-*'
+
+Overrides can be applied for a single run without editing .tbrc.yml. They use the same schema as
+overrides in .tbrc.yml, but the service can be a short name or a pattern that is matched against the
+services being started. Fields that are lists are set as comma separated values.
+
+	tb up venue-core-service --set venue-core-service.mode=build --set venue-core-service.envVars.LOG_LEVEL=debug
+
+Overrides can also be read from a YAML file that maps service names to overrides. Overrides from --set
+are applied on top of the file, and image tags from --image-tag take precedence over both:
+
+	tb up --playlist core --overrides-file overrides.yml`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Hack to support either args or --services flag for backwards compatibility.
 			// The flag will eventually be removed so we won't have to do this
@@ -105,7 +171,14 @@ Run the postgres and localstack services directly:
 				}
 				serviceTags[parts[0]] = parts[1]
 			}
-			err := c.Engine.Up(c.Ctx, engine.UpOptions{
+			overrides, err := readOverrides(opts.overridesFile, opts.overrides)
+			if err != nil {
+				return &fatal.Error{
+					Msg: "Failed to read overrides",
+					Err: err,
+				}
+			}
+			err = c.Engine.Up(c.Ctx, engine.UpOptions{
 				ServiceNames:   serviceNames,
 				PlaylistName:   opts.playlistName,
 				SkipPreRun:     opts.skipServicePreRun,
@@ -113,6 +186,7 @@ Run the postgres and localstack services directly:
 				SkipGitPull:    opts.skipGitPull,
 				OfflineMode:    c.OfflineMode,
 				ServiceTags:    serviceTags,
+				Overrides:      overrides,
 			})
 			if err != nil {
 				return &fatal.Error{
@@ -151,6 +225,8 @@ Run the postgres and localstack services directly:
 	flags.BoolVar(&opts.skipLazydocker, "no-lazydocker", false, "Don't start lazydocker")
 	flags.StringVarP(&opts.playlistName, "playlist", "p", "", "The name of a playlist")
 	flags.StringSliceVarP(&opts.serviceTags, "image-tag", "t", []string{}, "Comma separated list of service:image-tag to run")
+	flags.StringArrayVar(&opts.overrides, "set", nil, "Override a service field for this run, eg --set postgres.remote.tag=14. Can be repeated")
+	flags.StringVar(&opts.overridesFile, "overrides-file", "", "Path to a YAML file of service overrides to apply for this run")
 	flags.StringSliceVarP(&opts.serviceNames, "services", "s", []string{}, "Comma separated list of services to start. eg --services postgres,localstack.")
 	err := flags.MarkDeprecated("services", "and will be removed, pass service names as arguments instead")
 	if err != nil {
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/TouchBistro/goutils/errors"
+	"github.com/TouchBistro/tb/errkind"
+	"github.com/TouchBistro/tb/resource"
+	"github.com/TouchBistro/tb/resource/service"
+)
+
+// Same as the registry part of a full resource name, see resource.ParseName.
+var registryNameRegex = regexp.MustCompile(`^[\w-]+\/[\w-]+$`)
+
+// CheckOptions customizes the behaviour of Check.
+type CheckOptions struct {
+	// Services is used to check that every overridden service exists.
+	// If nil, overridden services are not checked.
+	Services *resource.Collection[service.Service]
+}
+
+// Check checks the values in config for problems that would cause tb to fail, such as
+// invalid registry names, timeouts being out of bounds, or overrides that do not use
+// the full service name. Unlike Validate, Check works on a config that has already been read,
+// so it can be used with the result of Load.
+//
+// If any problems are found, the returned error will be an errors.List with an error for each.
+// The problems that Init refuses to run with are listed first.
+func Check(config Config, opts CheckOptions) error {
+	const op = errors.Op("config.Check")
+	required, warnings := checkConfig(op, config, opts)
+	errs := append(required, warnings...)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// checkConfig performs the checks for Check. The problems that prevent tb from running are returned
+// in required: no registries, timeouts out of bounds, and overrides that do not use the full service name.
+// All other problems are returned in warnings, since tb has always accepted them.
+func checkConfig(op errors.Op, config Config, opts CheckOptions) (required, warnings errors.List) {
+	if len(config.Registries) == 0 {
+		required = append(required, errors.New(errkind.Invalid, "no registries defined", op))
+	}
+	registryNames := make(map[string]bool, len(config.Registries))
+	for _, r := range config.Registries {
+		if !registryNameRegex.MatchString(r.Name) {
+			msg := fmt.Sprintf("invalid registry name %q, must be of the form <org>/<repo>", r.Name)
+			warnings = append(warnings, errors.New(errkind.Invalid, msg, op))
+		} else if registryNames[r.Name] {
+			warnings = append(warnings, errors.New(errkind.Invalid, fmt.Sprintf("registry %s is defined more than once", r.Name), op))
+		}
+		registryNames[r.Name] = true
+	}
+	if _, err := phaseTimeouts(config); err != nil {
+		var timeoutErrs errors.List
+		if errors.As(err, &timeoutErrs) {
+			required = append(required, timeoutErrs...)
+		}
+	}
+	if config.GitConcurrency < 0 {
+		msg := fmt.Sprintf("invalid gitConcurrency value '%d', must not be negative", config.GitConcurrency)
+		warnings = append(warnings, errors.New(errkind.Invalid, msg, op))
+	}
+
+	// Make sure all overrides use the full name of the service. This is necessary so
+	// we can determine which service to override in which registry without ambiguity.
+	for _, name := range sortedKeys(config.Overrides) {
+		registryName, _, err := resource.ParseName(name)
+		if err != nil {
+			required = append(required, errors.Wrap(err, errors.Meta{
+				Reason: fmt.Sprintf("invalid service name to override %s", name),
+				Op:     op,
+			}))
+			continue
+		}
+		if registryName == "" {
+			msg := fmt.Sprintf("invalid service override %s, overrides must use the full name <registry>/<service>", name)
+			required = append(required, errors.New(errkind.Invalid, msg, op))
+			continue
+		}
+		if opts.Services != nil {
+			if _, err := opts.Services.Lookup(name); err != nil {
+				// The error already contains the name.
+				warnings = append(warnings, errors.Wrap(err, errors.Meta{Reason: "invalid service override", Op: op}))
+			}
+		}
+	}
+	for _, name := range sortedKeys(config.Playlists) {
+		if err := validatePlaylistName(op, name); err != nil {
+			warnings = append(warnings, err)
+		}
+	}
+	return required, warnings
+}
+
+// sortedKeys returns the keys of m in sorted order so that problems are reported consistently.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	_ "embed"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
 	"strings"
 	"time"
 
@@ -17,6 +20,7 @@ import (
 	"github.com/TouchBistro/tb/errkind"
 	"github.com/TouchBistro/tb/integrations/git"
 	"github.com/TouchBistro/tb/integrations/simulator"
+	"github.com/TouchBistro/tb/internal/schema"
 	"github.com/TouchBistro/tb/internal/util"
 	"github.com/TouchBistro/tb/registry"
 	"github.com/TouchBistro/tb/resource"
@@ -28,10 +32,14 @@ import (
 // ErrRegistryExists indicates that the registry being added already exists.
 var ErrRegistryExists errors.String = "registry already exists"
 
+// ErrRegistryNotFound indicates that the registry does not exist in the config.
+var ErrRegistryNotFound errors.String = "registry not found"
+
 const (
 	tbrcName      = ".tbrc.yml"
 	rootDir       = ".tb"
 	registriesDir = "registries"
+	reposDir      = "repos"
 )
 
 //go:embed template.yml
@@ -39,15 +47,39 @@ var tbrcTemplate []byte
 
 // Config represents a tbrc config file used to provide custom configuration for a user.
 type Config struct {
+	// Version is the version of the tbrc format used by the config file, see SchemaVersion.
+	// Load sets it to SchemaVersion since config files are always read into the latest format.
+	Version int `yaml:"version,omitempty"`
 	// Triple state bools suck but we need this so we can tell if the user set it explicitly.
-	// TODO(@cszatmary): Remove this when we do a breaking change.
-	Debug            *bool                              `yaml:"debug"`
-	ExperimentalMode bool                               `yaml:"experimental"`
-	GitConcurrency   int                                `yaml:"gitConcurrency"`
-	Playlists        map[string]playlist.Playlist       `yaml:"playlists"`
-	Overrides        map[string]service.ServiceOverride `yaml:"overrides"`
-	Registries       []registry.Registry                `yaml:"registries"`
-	TimeoutSeconds   int                                `yaml:"timeoutSeconds"`
+	// Only used by version 0 config files, it is removed by Migrate.
+	Debug             *bool                              `yaml:"debug"`
+	ExperimentalMode  bool                               `yaml:"experimental"`
+	GitConcurrency    int                                `yaml:"gitConcurrency"`
+	Include           []string                           `yaml:"include,omitempty"`
+	Playlists         map[string]playlist.Playlist       `yaml:"playlists"`
+	Overrides         map[string]service.ServiceOverride `yaml:"overrides"`
+	Registries        []registry.Registry                `yaml:"registries"`
+	ReplaceDeprecated bool                               `yaml:"replaceDeprecated"`
+	TimeoutSeconds    int                                `yaml:"timeoutSeconds"`
+	Timeouts          Timeouts                           `yaml:"timeouts,omitempty"`
+
+	// Sources contains the paths of all the config files that were merged to produce
+	// the config by Load, in the order they were merged. Not part of yaml.
+	Sources []string `yaml:"-"`
+	// fields contains the top level fields that were set in the config files.
+	// It is used when merging to tell if a field was set explicitly.
+	fields map[string]bool
+	// outdated contains the paths of the config files that use an older version than SchemaVersion.
+	outdated []string
+	// timeoutSources maps each timeout field that was set, like 'timeouts.build', to where it was set,
+	// i.e. the path of a config file, an environment variable, or a flag. It is used in errors.
+	timeoutSources map[string]string
+}
+
+// Outdated returns the paths of the config files merged by Load that use an older version
+// of the tbrc format. They can be upgraded with Migrate.
+func (c Config) Outdated() []string {
+	return c.outdated
 }
 
 // NOTE: This is deprecated and is only here for backwards compatibility.
@@ -75,16 +107,8 @@ func Read(homedir string) (Config, error) {
 		}
 	}
 	configPath := filepath.Join(homedir, tbrcName)
-
-	// Create default tbrc if it doesn't exist
-	if !file.Exists(configPath) {
-		if err := os.WriteFile(configPath, tbrcTemplate, 0o644); err != nil {
-			return Config{}, errors.Wrap(err, errors.Meta{
-				Kind:   errkind.IO,
-				Reason: fmt.Sprintf("couldn't create default tbrc at %s", configPath),
-				Op:     op,
-			})
-		}
+	if err := createDefault(op, configPath); err != nil {
+		return Config{}, err
 	}
 
 	f, err := os.Open(configPath)
@@ -108,6 +132,80 @@ func Read(homedir string) (Config, error) {
 	return config, nil
 }
 
+// createDefault creates the default tbrc at configPath if it doesn't exist.
+func createDefault(op errors.Op, configPath string) error {
+	if file.Exists(configPath) {
+		return nil
+	}
+	if err := os.WriteFile(configPath, tbrcTemplate, 0o644); err != nil {
+		return errors.Wrap(err, errors.Meta{
+			Kind:   errkind.IO,
+			Reason: fmt.Sprintf("couldn't create default tbrc at %s", configPath),
+			Op:     op,
+		})
+	}
+	return nil
+}
+
+// Validate checks the config file in the given home directory for problems that
+// do not prevent it from being read, such as unknown fields which are likely typos.
+// If homedir is empty, it will be resolved from the environment.
+// If any problems are found, the returned error will be an errors.List with an error for each.
+func Validate(homedir string) error {
+	const op = errors.Op("config.Validate")
+	if homedir == "" {
+		var err error
+		homedir, err = os.UserHomeDir()
+		if err != nil {
+			return errors.Wrap(err, errors.Meta{
+				Kind:   errkind.Internal,
+				Reason: "unable to find user home directory",
+				Op:     op,
+			})
+		}
+	}
+	return ValidateFile(filepath.Join(homedir, tbrcName))
+}
+
+// ValidateFile is like Validate but checks the config file at configPath.
+// It can be used to check any config file, like project configs and included files.
+func ValidateFile(configPath string) error {
+	const op = errors.Op("config.ValidateFile")
+	f, err := os.Open(configPath)
+	if err != nil {
+		return errors.Wrap(err, errors.Meta{
+			Kind:   errkind.IO,
+			Reason: fmt.Sprintf("failed to open file %s", configPath),
+			Op:     op,
+		})
+	}
+	defer f.Close()
+
+	var doc yaml.Node
+	if err := yaml.NewDecoder(f).Decode(&doc); err != nil && !errors.Is(err, io.EOF) {
+		return errors.Wrap(err, errors.Meta{
+			Kind:   errkind.IO,
+			Reason: fmt.Sprintf("couldn't read yaml file at %s", configPath),
+			Op:     op,
+		})
+	}
+	var errs errors.List
+	for _, uf := range schema.UnknownFields(&doc, reflect.TypeOf(Config{})) {
+		pos := resource.Position{File: configPath, Line: uf.Node.Line, Column: uf.Node.Column}
+		errs = append(errs, errors.New(errkind.Invalid, fmt.Sprintf("%s: %s", pos, uf), op))
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// JSONSchema returns a JSON Schema for the tbrc config file.
+// The schema can be used by editors to provide validation and autocompletion.
+func JSONSchema() []byte {
+	return schema.JSONSchema(reflect.TypeOf(Config{}), schema.URL("tbrc"), "tbrc")
+}
+
 type InitOptions struct {
 	// If true, Init will load services and playlists from registries.
 	// If false, no services or playlists will be available in the returned Engine instance.
@@ -118,6 +216,12 @@ type InitOptions struct {
 	// If true, registries will be updated before being read, otherwise the existing version
 	// will be read. Missing registries will always be cloned regardless of the value of this field.
 	UpdateRegistries bool
+	// TbVersion is the version of tb being run. It is used to make sure that registries
+	// are compatible with it. If empty, compatibility is not checked.
+	TbVersion string
+	// ChooseResource is used to pick a resource when a short name matches resources in
+	// multiple registries. If it is nil, ambiguous names are treated as errors.
+	ChooseResource resource.ChooseFunc
 }
 
 // Init takes a config and initializes an engine.Engine for performing tb operations.
@@ -137,8 +241,8 @@ func Init(ctx context.Context, config Config, opts InitOptions) (*engine.Engine,
 			Op:     op,
 		})
 	}
-	tbRoot := filepath.Join(homedir, rootDir)
-	// Create ~/.tb directory if it doesn't exist
+	tbRoot := rootPath(homedir)
+	// Create ~/.tb, or TB_HOME, directory if it doesn't exist
 	if err := os.MkdirAll(tbRoot, 0o755); err != nil {
 		return nil, errors.Wrap(err, errors.Meta{
 			Kind:   errkind.IO,
@@ -147,59 +251,209 @@ func Init(ctx context.Context, config Config, opts InitOptions) (*engine.Engine,
 		})
 	}
 
-	// Handle registries
-
-	// We need at least one registry otherwise tb is pretty useless so let the user know.
-	if len(config.Registries) == 0 {
-		return nil, errors.New(errkind.Invalid, "no registries defined", op)
+	// Check the config up front so all problems are reported at once.
+	// This includes making sure there is at least one registry, since otherwise tb is pretty useless.
+	// Other problems are only warned about so that existing configs keep working.
+	tracker := progress.TrackerFromContext(ctx)
+	required, warnings := checkConfig(op, config, CheckOptions{})
+	if len(required) > 0 {
+		return nil, errors.Wrap(required, errors.Meta{Reason: "invalid tbrc", Op: op})
 	}
-
-	if config.TimeoutSeconds != 0 && (config.TimeoutSeconds < 5 || config.TimeoutSeconds > 3600) {
-		return nil, errors.New(errkind.Invalid, fmt.Sprintf("Invalid timeoutSeconds value '%d' in .tbrc.yaml. Values must be between 5 and 3600 inclusive", config.TimeoutSeconds), op)
+	for _, w := range warnings {
+		tracker.Warnf("⚠️  %v", w)
+	}
+	if len(warnings) > 0 {
+		tracker.Warn("⚠️  Run 'tb config validate' for more details")
 	}
 
-	// default to 60 min timeout when not provided in .tbrc.yml
-	timeout := 60 * time.Minute
-	if config.TimeoutSeconds != 0 {
-		timeout = time.Duration(config.TimeoutSeconds) * time.Second
+	// Handle registries
+	timeout, err := operationTimeout(config)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.Meta{Op: op})
+	}
+	timeouts, err := phaseTimeouts(config)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.Meta{Op: op})
 	}
 
 	// Validate and normalize all registries.
-	tracker := progress.TrackerFromContext(ctx)
-	for i, r := range config.Registries {
-		// Resolve true registry path
+	config.Registries, err = ResolveRegistries(config, homedir)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.Meta{Op: op})
+	}
+	for _, r := range config.Registries {
 		if r.LocalPath != "" {
 			// Remind people they are using a local version in case they forgot
 			tracker.Infof("❗ Using a local version of the %s registry ❗", color.Cyan(r.Name))
-
-			// Local paths can be prefixed with ~ for convenience
-			if strings.HasPrefix(r.LocalPath, "~") {
-				r.Path = filepath.Join(homedir, strings.TrimPrefix(r.LocalPath, "~"))
-			} else {
-				path, err := filepath.Abs(r.LocalPath)
-				if err != nil {
-					return nil, errors.Wrap(err, errors.Meta{
-						Kind:   errkind.IO,
-						Reason: fmt.Sprintf("failed to resolve absolute path to local registry %s", r.Name),
-						Op:     op,
-					})
-				}
-				r.Path = path
-			}
-		} else {
-			// If not local, the path will be where the registry is/will be cloned.
-			r.Path = filepath.Join(tbRoot, registriesDir, r.Name)
 		}
-		config.Registries[i] = r
 	}
 
 	// Go through each registry and make sure it is ready for use.
-	err = progress.RunParallel(ctx, progress.RunParallelOptions{
+	if err := syncRegistries(ctx, config.Registries, opts.UpdateRegistries, timeouts.Git); err != nil {
+		return nil, errors.Wrap(err, errors.Meta{Op: op})
+	}
+
+	registryResult, err := registry.ReadAll(config.Registries, registry.ReadAllOptions{
+		ReadServices: opts.LoadServices,
+		ReadApps:     opts.LoadApps,
+		HomeDir:      homedir,
+		RootPath:     tbRoot,
+		ReposPath:    filepath.Join(tbRoot, reposDir),
+		Overrides:    config.Overrides,
+		TbVersion:    opts.TbVersion,
+		Logger:       tracker,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, errors.Meta{
+			Reason: "failed to read registries",
+			Op:     op,
+		})
+	}
+
+	// Warn whenever a deprecated resource is used so people can move off of it before it is removed.
+	deprecationOpts := resource.DeprecationOptions{Logger: tracker, UseReplacement: config.ReplaceDeprecated}
+	if opts.LoadApps {
+		registryResult.IOSApps.SetDeprecationOptions(deprecationOpts)
+		registryResult.DesktopApps.SetDeprecationOptions(deprecationOpts)
+		registryResult.IOSApps.SetChooseFunc(opts.ChooseResource)
+		registryResult.DesktopApps.SetChooseFunc(opts.ChooseResource)
+	}
+	if opts.LoadServices {
+		registryResult.Services.SetDeprecationOptions(deprecationOpts)
+		registryResult.Playlists.SetDeprecationOptions(deprecationOpts)
+		registryResult.Services.SetChooseFunc(opts.ChooseResource)
+		registryResult.Playlists.SetChooseFunc(opts.ChooseResource)
+
+		// Add custom playlists
+		for n, p := range config.Playlists {
+			p.Name = n
+			registryResult.Playlists.SetCustom(p)
+		}
+	}
+
+	// Only try loading devices if we are on macOS.
+	// This way other commands like 'tb app list' can still function on linux.
+	var deviceList simulator.DeviceList
+	if opts.LoadApps && util.IsMacOS {
+		deviceData, err := simulator.ListDevices(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.Meta{Reason: "failed to get list of simulators", Op: op})
+		}
+		deviceList, err = simulator.ParseDevices(deviceData)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.Meta{Reason: "failed to parse available simulators", Op: op})
+		}
+	}
+
+	e, err := engine.New(engine.Options{
+		Workdir:         tbRoot,
+		Services:        registryResult.Services,
+		Playlists:       registryResult.Playlists,
+		IOSApps:         registryResult.IOSApps,
+		DesktopApps:     registryResult.DesktopApps,
+		BaseImages:      registryResult.BaseImages,
+		LoginStrategies: registryResult.LoginStrategies,
+		DeviceList:      deviceList,
+		GitConcurrency:  config.GitConcurrency,
+		Timeout:         timeout,
+		Timeouts:        timeouts,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, errors.Meta{Reason: "failed to initialize engine", Op: op})
+	}
+	return e, nil
+}
+
+// operationTimeout returns the timeout for operations based on config.
+func operationTimeout(config Config) (time.Duration, error) {
+	const op = errors.Op("config.operationTimeout")
+	// default to 60 min timeout when not provided in .tbrc.yml
+	return timeoutDuration(op, config, "timeoutSeconds", config.TimeoutSeconds, maxTimeoutSeconds, defaultTimeoutSeconds*time.Second)
+}
+
+// Bounds for timeout values in seconds. Individual phases may allow larger values, see Timeouts.
+const (
+	minTimeoutSeconds = 5
+	maxTimeoutSeconds = 3600
+)
+
+// timeoutDuration returns the timeout set by the given config field.
+// If seconds is 0, i.e. the field is not set, fallback is returned.
+// An error is returned if seconds is not between minTimeoutSeconds and max inclusive.
+func timeoutDuration(op errors.Op, config Config, field string, seconds, max int, fallback time.Duration) (time.Duration, error) {
+	if seconds == 0 {
+		return fallback, nil
+	}
+	if seconds < minTimeoutSeconds || seconds > max {
+		source, ok := config.timeoutSources[field]
+		if !ok {
+			source = tbrcName
+		}
+		msg := fmt.Sprintf(
+			"invalid %s value %d in %s, must be between %d and %d inclusive",
+			field, seconds, source, minTimeoutSeconds, max,
+		)
+		return 0, errors.New(errkind.Invalid, msg, op)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// setTimeoutSources records source as where each timeout field that is set in config came from.
+// timeoutSeconds is only recorded if setTimeoutSeconds is true, since 0 is a valid value for it.
+func setTimeoutSources(config *Config, source string, setTimeoutSeconds bool) {
+	if setTimeoutSeconds {
+		config.setTimeoutSource("timeoutSeconds", source)
+	}
+	for _, p := range config.Timeouts.phases() {
+		if *p.seconds != 0 {
+			config.setTimeoutSource("timeouts."+p.name, source)
+		}
+	}
+}
+
+func (c *Config) setTimeoutSource(field, source string) {
+	// Copy so configs that were merged from c are not modified.
+	sources := make(map[string]string, len(c.timeoutSources)+1)
+	for k, v := range c.timeoutSources {
+		sources[k] = v
+	}
+	sources[field] = source
+	c.timeoutSources = sources
+}
+
+// defaultTimeoutSeconds is the timeout used when timeoutSeconds is not set.
+const defaultTimeoutSeconds = 3600
+
+// WithDefaults returns config with the default value set for any field that was not set.
+// The result shows the values that will actually be used by tb.
+func WithDefaults(config Config) Config {
+	if config.TimeoutSeconds == 0 {
+		config.TimeoutSeconds = defaultTimeoutSeconds
+	}
+	for _, p := range config.Timeouts.phases() {
+		if *p.seconds == 0 {
+			*p.seconds = config.TimeoutSeconds
+		}
+	}
+	if config.GitConcurrency == 0 {
+		// Same default as engine.Options.
+		config.GitConcurrency = runtime.NumCPU()
+	}
+	return config
+}
+
+// syncRegistries makes sure each registry is ready for use by cloning any registries that are missing.
+// If update is true, existing registries will also be updated. Local registries are always skipped.
+// The Path of each registry must be set.
+func syncRegistries(ctx context.Context, registries []registry.Registry, update bool, timeout time.Duration) error {
+	const op = errors.Op("config.syncRegistries")
+	tracker := progress.TrackerFromContext(ctx)
+	err := progress.RunParallel(ctx, progress.RunParallelOptions{
 		Message: "Cloning/updating registries",
-		Count:   len(config.Registries),
+		Count:   len(registries),
 		Timeout: timeout,
 	}, func(ctx context.Context, i int) error {
-		r := config.Registries[i]
+		r := registries[i]
 		if r.LocalPath != "" {
 			// User's are responsible for local registries so we just assume they are good to go.
 			tracker.Debugf("Skipping local registry %s", r.Name)
@@ -219,7 +473,7 @@ func Init(ctx context.Context, config Config, opts InitOptions) (*engine.Engine,
 			tracker.Debugf("Finished cloning registry %s", r.Name)
 			return nil
 		}
-		if !opts.UpdateRegistries {
+		if !update {
 			return nil
 		}
 
@@ -234,86 +488,229 @@ func Init(ctx context.Context, config Config, opts InitOptions) (*engine.Engine,
 		return nil
 	})
 	if err != nil {
-		return nil, errors.Wrap(err, errors.Meta{
+		return errors.Wrap(err, errors.Meta{
 			Reason: "failed to clone/update registries",
 			Op:     op,
 		})
 	}
+	return nil
+}
 
-	// Validate service overrides.
-	// Make sure all overrides use the full name of the service. This is necessary so
-	// we can determine which service to override in which registry without ambiguity.
-	for name := range config.Overrides {
-		registryName, _, err := resource.ParseName(name)
+// ResolveRegistries returns the registries in config with the Path of each registry set
+// to the location of the registry on the local filesystem. If the registry has a LocalPath
+// it will be used, otherwise Path will be where the registry is cloned by tb.
+// The registries in config are not modified.
+//
+// If homedir is empty, it will be resolved from the environment.
+func ResolveRegistries(config Config, homedir string) ([]registry.Registry, error) {
+	const op = errors.Op("config.ResolveRegistries")
+	if homedir == "" {
+		var err error
+		homedir, err = os.UserHomeDir()
 		if err != nil {
 			return nil, errors.Wrap(err, errors.Meta{
-				Reason: fmt.Sprintf("invalid service name to override %s", name),
+				Kind:   errkind.Internal,
+				Reason: "unable to find user home directory",
 				Op:     op,
 			})
 		}
-		if registryName == "" {
-			return nil, errors.New(
-				errkind.Invalid,
-				fmt.Sprintf("invalid service override %s, overrides must use the full name <registry>/<service>", name),
-				op,
-			)
+	}
+	registries := make([]registry.Registry, len(config.Registries))
+	for i, r := range config.Registries {
+		// Resolve true registry path
+		if r.LocalPath != "" {
+			// Local paths can be prefixed with ~ for convenience
+			if strings.HasPrefix(r.LocalPath, "~") {
+				r.Path = filepath.Join(homedir, strings.TrimPrefix(r.LocalPath, "~"))
+			} else {
+				path, err := filepath.Abs(r.LocalPath)
+				if err != nil {
+					return nil, errors.Wrap(err, errors.Meta{
+						Kind:   errkind.IO,
+						Reason: fmt.Sprintf("failed to resolve absolute path to local registry %s", r.Name),
+						Op:     op,
+					})
+				}
+				r.Path = path
+			}
+		} else {
+			// If not local, the path will be where the registry is/will be cloned.
+			r.Path = filepath.Join(rootPath(homedir), registriesDir, r.Name)
 		}
+		registries[i] = r
 	}
+	return registries, nil
+}
 
-	registryResult, err := registry.ReadAll(config.Registries, registry.ReadAllOptions{
-		ReadServices: opts.LoadServices,
-		ReadApps:     opts.LoadApps,
+// UpdateRegistries clones or updates the registries in config with the given names.
+// If no names are provided, all registries are updated. Local registries are skipped
+// since users are responsible for keeping them up to date.
+//
+// If a name does not match a registry in config, ErrRegistryNotFound will be returned.
+func UpdateRegistries(ctx context.Context, config Config, names []string) error {
+	const op = errors.Op("config.UpdateRegistries")
+	timeouts, err := phaseTimeouts(config)
+	if err != nil {
+		return errors.Wrap(err, errors.Meta{Op: op})
+	}
+	registries, err := ResolveRegistries(config, "")
+	if err != nil {
+		return errors.Wrap(err, errors.Meta{Op: op})
+	}
+	registries, err = selectRegistries(op, registries, names)
+	if err != nil {
+		return err
+	}
+	if err := syncRegistries(ctx, registries, true, timeouts.Git); err != nil {
+		return errors.Wrap(err, errors.Meta{Op: op})
+	}
+	return nil
+}
+
+// RegistryStatus describes the state of a registry on the local filesystem.
+type RegistryStatus struct {
+	// Registry is the registry with Path resolved.
+	Registry registry.Registry
+	// Exists reports whether the registry exists on the local filesystem.
+	// Remote registries will not exist until they have been cloned.
+	Exists bool
+	// Commit is the SHA of the commit currently checked out in the registry.
+	// It is empty if it could not be determined.
+	Commit string
+	// LastUpdated is when the registry was last cloned or updated.
+	// It is the zero value if it could not be determined.
+	LastUpdated time.Time
+}
+
+// RegistryStatuses returns the status of each registry in config.
+// Failing to determine the commit or last update time of a registry is not
+// considered an error, instead those fields will be empty.
+func RegistryStatuses(ctx context.Context, config Config) ([]RegistryStatus, error) {
+	const op = errors.Op("config.RegistryStatuses")
+	registries, err := ResolveRegistries(config, "")
+	if err != nil {
+		return nil, errors.Wrap(err, errors.Meta{Op: op})
+	}
+	gitClient := git.New()
+	statuses := make([]RegistryStatus, len(registries))
+	for i, r := range registries {
+		status := RegistryStatus{Registry: r, Exists: file.Exists(r.Path)}
+		if status.Exists {
+			status.Commit, _ = gitClient.GetHeadSha(ctx, r.Path)
+			// FETCH_HEAD is written every time the registry is pulled,
+			// if it doesn't exist the registry hasn't been pulled since it was cloned.
+			for _, name := range []string{"FETCH_HEAD", "HEAD"} {
+				if fi, err := os.Stat(filepath.Join(r.Path, ".git", name)); err == nil {
+					status.LastUpdated = fi.ModTime()
+					break
+				}
+			}
+		}
+		statuses[i] = status
+	}
+	return statuses, nil
+}
+
+// ReadRegistry reads all the services, playlists, and apps from the registry with the given name in config.
+// The registry must exist on the local filesystem, i.e. remote registries must have been cloned.
+//
+// If the registry does not exist in config, ErrRegistryNotFound will be returned.
+func ReadRegistry(config Config, name string) (registry.ReadAllResult, error) {
+	result, err := ReadRegistries(config, []string{name})
+	if err != nil {
+		return result, errors.Wrap(err, errors.Meta{Op: "config.ReadRegistry"})
+	}
+	return result, nil
+}
+
+// ReadRegistries reads all the services, playlists, and apps from the registries with the given names
+// in config and returns the combined result. If no names are provided, all registries are read.
+// The registries must exist on the local filesystem, i.e. remote registries must have been cloned.
+//
+// If a name does not match a registry in config, ErrRegistryNotFound will be returned.
+func ReadRegistries(config Config, names []string) (registry.ReadAllResult, error) {
+	const op = errors.Op("config.ReadRegistries")
+	homedir, err := os.UserHomeDir()
+	if err != nil {
+		return registry.ReadAllResult{}, errors.Wrap(err, errors.Meta{
+			Kind:   errkind.Internal,
+			Reason: "unable to find user home directory",
+			Op:     op,
+		})
+	}
+	registries, err := ResolveRegistries(config, homedir)
+	if err != nil {
+		return registry.ReadAllResult{}, errors.Wrap(err, errors.Meta{Op: op})
+	}
+	registries, err = selectRegistries(op, registries, names)
+	if err != nil {
+		return registry.ReadAllResult{}, err
+	}
+	for _, r := range registries {
+		if !file.Exists(r.Path) {
+			return registry.ReadAllResult{}, errors.New(errkind.Invalid, fmt.Sprintf("registry %s has not been cloned", r.Name), op)
+		}
+	}
+	tbRoot := rootPath(homedir)
+	result, err := registry.ReadAll(registries, registry.ReadAllOptions{
+		ReadServices: true,
+		ReadApps:     true,
 		HomeDir:      homedir,
 		RootPath:     tbRoot,
-		ReposPath:    filepath.Join(tbRoot, "repos"),
-		Overrides:    config.Overrides,
-		Logger:       tracker,
+		ReposPath:    filepath.Join(tbRoot, reposDir),
 	})
 	if err != nil {
-		return nil, errors.Wrap(err, errors.Meta{
-			Reason: "failed to read registries",
-			Op:     op,
-		})
+		return result, errors.Wrap(err, errors.Meta{Op: op})
 	}
+	return result, nil
+}
 
-	if opts.LoadServices {
-		// Add custom playlists
-		for n, p := range config.Playlists {
-			p.Name = n
-			registryResult.Playlists.SetCustom(p)
+// selectRegistries returns the registries with the given names. If names is empty, all registries are returned.
+// If a name does not match any registry, ErrRegistryNotFound is returned.
+func selectRegistries(op errors.Op, registries []registry.Registry, names []string) ([]registry.Registry, error) {
+	if len(names) == 0 {
+		return registries, nil
+	}
+	selected := make([]registry.Registry, 0, len(names))
+	for _, name := range names {
+		r, ok := findRegistry(registries, name)
+		if !ok {
+			return nil, errors.Wrap(ErrRegistryNotFound, errors.Meta{
+				Kind:   errkind.Invalid,
+				Reason: name,
+				Op:     op,
+			})
 		}
+		selected = append(selected, r)
 	}
+	return selected, nil
+}
 
-	// Only try loading devices if we are on macOS.
-	// This way other commands like 'tb app list' can still function on linux.
-	var deviceList simulator.DeviceList
-	if opts.LoadApps && util.IsMacOS {
-		deviceData, err := simulator.ListDevices(ctx)
-		if err != nil {
-			return nil, errors.Wrap(err, errors.Meta{Reason: "failed to get list of simulators", Op: op})
-		}
-		deviceList, err = simulator.ParseDevices(deviceData)
-		if err != nil {
-			return nil, errors.Wrap(err, errors.Meta{Reason: "failed to parse available simulators", Op: op})
+// findRegistry returns the registry with the given name from registries.
+func findRegistry(registries []registry.Registry, name string) (registry.Registry, bool) {
+	for _, r := range registries {
+		if r.Name == name {
+			return r, true
 		}
 	}
+	return registry.Registry{}, false
+}
 
-	e, err := engine.New(engine.Options{
-		Workdir:         tbRoot,
-		Services:        registryResult.Services,
-		Playlists:       registryResult.Playlists,
-		IOSApps:         registryResult.IOSApps,
-		DesktopApps:     registryResult.DesktopApps,
-		BaseImages:      registryResult.BaseImages,
-		LoginStrategies: registryResult.LoginStrategies,
-		DeviceList:      deviceList,
-		GitConcurrency:  config.GitConcurrency,
-		Timeout:         timeout,
-	})
-	if err != nil {
-		return nil, errors.Wrap(err, errors.Meta{Reason: "failed to initialize engine", Op: op})
+// ReposPath returns the path to the directory where tb clones the repos of services.
+// If homedir is empty, it will be resolved from the environment.
+func ReposPath(homedir string) (string, error) {
+	if homedir == "" {
+		var err error
+		homedir, err = os.UserHomeDir()
+		if err != nil {
+			return "", errors.Wrap(err, errors.Meta{
+				Kind:   errkind.Internal,
+				Reason: "unable to find user home directory",
+				Op:     "config.ReposPath",
+			})
+		}
 	}
-	return e, nil
+	return filepath.Join(rootPath(homedir), reposDir), nil
 }
 
 // AddRegistry adds the registry to the config file located in the given home directory.
@@ -437,12 +834,99 @@ func AddRegistry(registryName, homedir string) error {
 	// Add new registries at the end of the list
 	registriesNode.Content = append(registriesNode.Content, registryNode)
 
+	if err := writeYamlFile(op, f, tbrcDocumentNode); err != nil {
+		return err
+	}
+	return nil
+}
+
+// RemoveRegistry removes the registry from the config file located in the given home directory.
+// If homedir is empty, it will be resolved from the environment. Like AddRegistry, comments in
+// the config file are preserved.
+//
+// If the registry was cloned by tb, the clone will also be removed. Local registries are never
+// removed from the filesystem since they are managed by the user.
+//
+// If the registry does not exist in the config file, ErrRegistryNotFound will be returned.
+func RemoveRegistry(registryName, homedir string) error {
+	const op = errors.Op("config.RemoveRegistry")
+	if homedir == "" {
+		var err error
+		homedir, err = os.UserHomeDir()
+		if err != nil {
+			return errors.Wrap(err, errors.Meta{
+				Kind:   errkind.Internal,
+				Reason: "unable to find user home directory",
+				Op:     op,
+			})
+		}
+	}
+
+	tbrcPath := filepath.Join(homedir, tbrcName)
+	f, err := os.OpenFile(tbrcPath, os.O_RDWR, 0644)
+	if err != nil {
+		return errors.Wrap(err, errors.Meta{
+			Kind:   errkind.IO,
+			Reason: fmt.Sprintf("failed to open file %s", tbrcPath),
+			Op:     op,
+		})
+	}
+	defer f.Close()
+
+	// Decode into a Node so we can manipulate the contents while
+	// preserving comments and ordering
+	tbrcDocumentNode := &yaml.Node{}
+	if err := yaml.NewDecoder(f).Decode(tbrcDocumentNode); err != nil {
+		return errors.Wrap(err, errors.Meta{
+			Kind:   errkind.IO,
+			Reason: fmt.Sprintf("couldn't read yaml file at %s", tbrcPath),
+			Op:     op,
+		})
+	}
+
+	registriesNode := findYamlNode(tbrcDocumentNode, "registries")
+	if registriesNode == nil || registriesNode.Kind != yaml.SequenceNode {
+		return ErrRegistryNotFound
+	}
+	var removed *registry.Registry
+	for i, n := range registriesNode.Content {
+		var r registry.Registry
+		if err := n.Decode(&r); err != nil || r.Name != registryName {
+			continue
+		}
+		removed = &r
+		registriesNode.Content = append(registriesNode.Content[:i], registriesNode.Content[i+1:]...)
+		break
+	}
+	if removed == nil {
+		return ErrRegistryNotFound
+	}
+	if err := writeYamlFile(op, f, tbrcDocumentNode); err != nil {
+		return err
+	}
+
+	if removed.LocalPath != "" {
+		return nil
+	}
+	registryPath := filepath.Join(rootPath(homedir), registriesDir, registryName)
+	if err := os.RemoveAll(registryPath); err != nil {
+		return errors.Wrap(err, errors.Meta{
+			Kind:   errkind.IO,
+			Reason: fmt.Sprintf("failed to remove registry clone at %s", registryPath),
+			Op:     op,
+		})
+	}
+	return nil
+}
+
+// writeYamlFile overwrites the contents of f with the YAML document doc.
+func writeYamlFile(op errors.Op, f *os.File, doc *yaml.Node) error {
 	// Make sure we overwrite the file instead of appending to it
 	// Need to go back to the start and truncate it
 	if _, err := f.Seek(0, 0); err != nil {
 		return errors.Wrap(err, errors.Meta{
 			Kind:   errkind.IO,
-			Reason: fmt.Sprintf("failed to seek start of file %s", tbrcPath),
+			Reason: fmt.Sprintf("failed to seek start of file %s", f.Name()),
 			Op:     op,
 		})
 	}
@@ -450,21 +934,20 @@ func AddRegistry(registryName, homedir string) error {
 	if err := f.Truncate(0); err != nil {
 		return errors.Wrap(err, errors.Meta{
 			Kind:   errkind.IO,
-			Reason: fmt.Sprintf("failed to truncate file %s", tbrcPath),
+			Reason: fmt.Sprintf("failed to truncate file %s", f.Name()),
 			Op:     op,
 		})
 	}
 
 	encoder := yaml.NewEncoder(f)
 	encoder.SetIndent(2)
-	if err := encoder.Encode(tbrcDocumentNode); err != nil {
+	if err := encoder.Encode(doc); err != nil {
 		return errors.Wrap(err, errors.Meta{
 			Kind:   errkind.IO,
-			Reason: fmt.Sprintf("failed to write %s", tbrcPath),
+			Reason: fmt.Sprintf("failed to write %s", f.Name()),
 			Op:     op,
 		})
 	}
-
 	return nil
 }
 
@@ -3,10 +3,14 @@ package config_test
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/TouchBistro/goutils/errors"
 	"github.com/TouchBistro/tb/config"
 	"github.com/TouchBistro/tb/registry"
+	"github.com/TouchBistro/tb/resource"
+	"github.com/TouchBistro/tb/resource/service"
 	"github.com/matryer/is"
 )
 
@@ -44,7 +48,8 @@ registries:
 		{
 			name: "no tbrc",
 			want: func(homedir string) config.Config {
-				return config.Config{}
+				// The default tbrc always uses the latest version
+				return config.Config{Version: config.SchemaVersion}
 			},
 		},
 	}
@@ -238,3 +243,252 @@ func TestAddRegistry(t *testing.T) {
 		})
 	}
 }
+
+func TestValidate(t *testing.T) {
+	is := is.New(t)
+	tmpdir := t.TempDir()
+	configPath := filepath.Join(tmpdir, ".tbrc.yml")
+	data := `experimental: true
+gitConcurency: 1
+registries:
+  - name: TouchBistro/tb-registry
+    localpath: ~/tools/tb-registry
+`
+	err := os.WriteFile(configPath, []byte(data), 0o644)
+	is.NoErr(err)
+
+	err = config.Validate(tmpdir)
+	var errs errors.List
+	is.True(errors.As(err, &errs))
+	is.Equal(len(errs), 2)
+	is.True(strings.Contains(errs[0].Error(), configPath+`:2:1: unknown field "gitConcurency", did you mean "gitConcurrency"?`))
+	is.True(strings.Contains(errs[1].Error(), configPath+`:5:5: unknown field "localpath" in registries[0], did you mean "localPath"?`))
+}
+
+func TestJSONSchema(t *testing.T) {
+	is := is.New(t)
+	// Make sure the published schema is up to date. Run 'make schemas' if this fails.
+	want, err := os.ReadFile("../schemas/tbrc.schema.json")
+	is.NoErr(err)
+	is.Equal(string(config.JSONSchema()), string(want))
+}
+
+func TestResolveRegistries(t *testing.T) {
+	is := is.New(t)
+	cfg := config.Config{
+		Registries: []registry.Registry{
+			{Name: "TouchBistro/tb-registry"},
+			{Name: "ExampleZone/tb-registry", LocalPath: "~/tools/tb-registry"},
+		},
+	}
+	registries, err := config.ResolveRegistries(cfg, "/home/foo")
+	is.NoErr(err)
+	is.Equal(registries, []registry.Registry{
+		{Name: "TouchBistro/tb-registry", Path: "/home/foo/.tb/registries/TouchBistro/tb-registry"},
+		{Name: "ExampleZone/tb-registry", LocalPath: "~/tools/tb-registry", Path: "/home/foo/tools/tb-registry"},
+	})
+	// The original config must not be modified
+	is.Equal(cfg.Registries[0].Path, "")
+}
+
+func TestRemoveRegistry(t *testing.T) {
+	const existingTBRC = `# Toggle experimental mode to test new features
+experimental: false
+# Add registries to access their services and playlists
+# A registry corresponds to a GitHub repo and is of the form <org>/<repo>
+registries:
+  - name: TouchBistro/tb-registry
+  - name: ExampleZone/tb-registry
+    localPath: ~/tools/tb-registry
+`
+	tests := []struct {
+		name         string
+		registryName string
+		expectedTBRC string
+		err          error
+	}{
+		{
+			name:         "remote registry",
+			registryName: "TouchBistro/tb-registry",
+			expectedTBRC: `# Toggle experimental mode to test new features
+experimental: false
+# Add registries to access their services and playlists
+# A registry corresponds to a GitHub repo and is of the form <org>/<repo>
+registries:
+  - name: ExampleZone/tb-registry
+    localPath: ~/tools/tb-registry
+`,
+		},
+		{
+			name:         "local registry",
+			registryName: "ExampleZone/tb-registry",
+			expectedTBRC: `# Toggle experimental mode to test new features
+experimental: false
+# Add registries to access their services and playlists
+# A registry corresponds to a GitHub repo and is of the form <org>/<repo>
+registries:
+  - name: TouchBistro/tb-registry
+`,
+		},
+		{
+			name:         "registry does not exist",
+			registryName: "TouchBistro/tb-registry-example",
+			expectedTBRC: existingTBRC,
+			err:          config.ErrRegistryNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			tmpdir := t.TempDir()
+			tbrcPath := filepath.Join(tmpdir, ".tbrc.yml")
+			err := os.WriteFile(tbrcPath, []byte(existingTBRC), 0o644)
+			is.NoErr(err)
+			// Create clones of both registries to make sure only remote ones are removed
+			remotePath := filepath.Join(tmpdir, ".tb/registries/TouchBistro/tb-registry")
+			localPath := filepath.Join(tmpdir, "tools/tb-registry")
+			is.NoErr(os.MkdirAll(remotePath, 0o755))
+			is.NoErr(os.MkdirAll(localPath, 0o755))
+
+			err = config.RemoveRegistry(tt.registryName, tmpdir)
+			is.Equal(err, tt.err)
+
+			data, err := os.ReadFile(tbrcPath)
+			is.NoErr(err)
+			is.Equal(string(data), tt.expectedTBRC)
+			_, err = os.Stat(remotePath)
+			is.Equal(os.IsNotExist(err), tt.registryName == "TouchBistro/tb-registry")
+			_, err = os.Stat(localPath)
+			is.NoErr(err)
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	is := is.New(t)
+	var services resource.Collection[service.Service]
+	is.NoErr(services.Set(service.Service{Name: "postgres", RegistryName: "TouchBistro/tb-registry"}))
+	cfg := config.Config{
+		GitConcurrency: -1,
+		Registries: []registry.Registry{
+			{Name: "TouchBistro/tb-registry"},
+			{Name: "TouchBistro/tb-registry"},
+			{Name: "tb-registry"},
+		},
+		Overrides: map[string]service.ServiceOverride{
+			"TouchBistro/tb-registry/postgres": {},
+			"TouchBistro/tb-registry/redis":    {},
+			"postgres":                         {},
+		},
+		TimeoutSeconds: 1,
+		Timeouts:       config.Timeouts{Build: 20000, Start: 60},
+	}
+
+	err := config.Check(cfg, config.CheckOptions{Services: &services})
+	var errs errors.List
+	is.True(errors.As(err, &errs))
+	// Problems that prevent tb from running come first
+	want := []string{
+		"invalid timeoutSeconds value 1 in .tbrc.yml",
+		"invalid timeouts.build value 20000 in .tbrc.yml",
+		"invalid service override postgres, overrides must use the full name",
+		"registry TouchBistro/tb-registry is defined more than once",
+		`invalid registry name "tb-registry"`,
+		"invalid gitConcurrency value '-1'",
+		"invalid service override: TouchBistro/tb-registry/redis",
+	}
+	is.Equal(len(errs), len(want))
+	for i, msg := range want {
+		is.True(strings.Contains(errs[i].Error(), msg)) // error message
+	}
+
+	// Services are only checked if provided
+	cfg = config.Config{
+		Registries: []registry.Registry{{Name: "TouchBistro/tb-registry"}},
+		Overrides:  map[string]service.ServiceOverride{"TouchBistro/tb-registry/redis": {}},
+	}
+	is.NoErr(config.Check(cfg, config.CheckOptions{}))
+}
+
+func TestSet(t *testing.T) {
+	const existingTBRC = `# Toggle experimental mode to test new features
+experimental: false # Enable to try new things
+registries:
+  - name: TouchBistro/tb-registry
+playlists:
+`
+	tests := []struct {
+		name         string
+		path         string
+		value        string
+		expectedTBRC string
+		wantErr      bool
+	}{
+		{
+			name:  "existing field",
+			path:  "experimental",
+			value: "true",
+			expectedTBRC: `# Toggle experimental mode to test new features
+experimental: true # Enable to try new things
+registries:
+  - name: TouchBistro/tb-registry
+playlists:
+`,
+		},
+		{
+			name:  "new field",
+			path:  "timeoutSeconds",
+			value: "600",
+			expectedTBRC: existingTBRC[:len(existingTBRC)-1] + `
+timeoutSeconds: 600
+`,
+		},
+		{
+			name:  "nested field",
+			path:  "playlists.db.services",
+			value: "postgres,redis",
+			expectedTBRC: `# Toggle experimental mode to test new features
+experimental: false # Enable to try new things
+registries:
+  - name: TouchBistro/tb-registry
+playlists:
+  db:
+    services:
+      - postgres
+      - redis
+`,
+		},
+		{
+			name:  "string that looks like a bool",
+			path:  "overrides.TouchBistro/tb-registry/postgres.envVars.DEBUG",
+			value: "true",
+			expectedTBRC: existingTBRC + `overrides:
+  TouchBistro/tb-registry/postgres:
+    envVars:
+      DEBUG: "true"
+`,
+		},
+		{name: "unknown field", path: "experimentl", value: "true", wantErr: true},
+		{name: "invalid value", path: "gitConcurrency", value: "lots", wantErr: true},
+		{name: "field with fields", path: "registries", value: "TouchBistro/tb-registry", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			tmpdir := t.TempDir()
+			tbrcPath := filepath.Join(tmpdir, ".tbrc.yml")
+			is.NoErr(os.WriteFile(tbrcPath, []byte(existingTBRC), 0o644))
+
+			err := config.Set(tt.path, tt.value, tmpdir)
+			data, rerr := os.ReadFile(tbrcPath)
+			is.NoErr(rerr)
+			if tt.wantErr {
+				is.True(err != nil)
+				is.Equal(string(data), existingTBRC) // file must not change
+				return
+			}
+			is.NoErr(err)
+			is.Equal(string(data), tt.expectedTBRC)
+		})
+	}
+}
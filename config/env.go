@@ -0,0 +1,155 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/TouchBistro/goutils/errors"
+	"github.com/TouchBistro/tb/errkind"
+	"github.com/TouchBistro/tb/registry"
+)
+
+// Names of the environment variables that can be used to configure tb.
+const (
+	// EnvExperimental sets the experimental field.
+	EnvExperimental = "TB_EXPERIMENTAL"
+	// EnvGitConcurrency sets the gitConcurrency field.
+	EnvGitConcurrency = "TB_GIT_CONCURRENCY"
+	// EnvTimeoutSeconds sets the timeoutSeconds field.
+	EnvTimeoutSeconds = "TB_TIMEOUT_SECONDS"
+	// EnvTimeouts sets the timeouts field to a comma separated list of phase=seconds pairs,
+	// like 'build=3600,pull=600'.
+	EnvTimeouts = "TB_TIMEOUTS"
+	// EnvRegistries sets the registries field to a comma separated list of registry names.
+	EnvRegistries = "TB_REGISTRIES"
+	// EnvHome sets the directory where tb stores data, like cloned registries and repos.
+	// It defaults to ~/.tb.
+	EnvHome = "TB_HOME"
+)
+
+// Settings contains values for config fields that take precedence over the config files.
+// They are set from environment variables or command line flags. A nil value means the field
+// was not set and the value from the config files is used.
+type Settings struct {
+	Experimental   *bool
+	GitConcurrency *int
+	TimeoutSeconds *int
+	// Registries replaces the registries from the config files if it is not empty.
+	Registries []string
+	// Timeouts replaces the timeouts of the phases that are set.
+	Timeouts Timeouts
+	// FromFlags reports whether the settings were set with command line flags instead of
+	// environment variables. It is used to say where invalid values came from in errors.
+	FromFlags bool
+}
+
+// SettingsFromEnv returns the settings from the TB_* environment variables.
+// An error is returned if an environment variable has a value of the wrong type.
+func SettingsFromEnv() (Settings, error) {
+	const op = errors.Op("config.SettingsFromEnv")
+	var s Settings
+	if v := os.Getenv(EnvExperimental); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			msg := fmt.Sprintf("invalid value %q for %s, must be true or false", v, EnvExperimental)
+			return s, errors.New(errkind.Invalid, msg, op)
+		}
+		s.Experimental = &b
+	}
+	for _, env := range []struct {
+		name string
+		dst  **int
+	}{
+		{EnvGitConcurrency, &s.GitConcurrency},
+		{EnvTimeoutSeconds, &s.TimeoutSeconds},
+	} {
+		v := os.Getenv(env.name)
+		if v == "" {
+			continue
+		}
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			msg := fmt.Sprintf("invalid value %q for %s, must be an integer", v, env.name)
+			return s, errors.New(errkind.Invalid, msg, op)
+		}
+		*env.dst = &i
+	}
+	if v := os.Getenv(EnvTimeouts); v != "" {
+		m := make(map[string]int)
+		for _, pair := range strings.Split(v, ",") {
+			name, seconds, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			i, err := strconv.Atoi(seconds)
+			if !ok || err != nil {
+				msg := fmt.Sprintf("invalid value %q for %s, must be a list of phase=seconds pairs", v, EnvTimeouts)
+				return s, errors.New(errkind.Invalid, msg, op)
+			}
+			m[name] = i
+		}
+		t, err := ParseTimeouts(m)
+		if err != nil {
+			return s, errors.Wrap(err, errors.Meta{Reason: fmt.Sprintf("invalid value for %s", EnvTimeouts), Op: op})
+		}
+		s.Timeouts = t
+	}
+	if v := os.Getenv(EnvRegistries); v != "" {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				s.Registries = append(s.Registries, name)
+			}
+		}
+	}
+	return s, nil
+}
+
+// Apply returns config with the fields set in s applied. The fields in s take precedence
+// over the values in config.
+func Apply(config Config, s Settings) Config {
+	if s.Experimental != nil {
+		config.ExperimentalMode = *s.Experimental
+	}
+	if s.GitConcurrency != nil {
+		config.GitConcurrency = *s.GitConcurrency
+	}
+	if s.TimeoutSeconds != nil {
+		config.TimeoutSeconds = *s.TimeoutSeconds
+	}
+	config.Timeouts = config.Timeouts.merge(s.Timeouts)
+	timeoutSecondsSource, timeoutsSource := EnvTimeoutSeconds, EnvTimeouts
+	if s.FromFlags {
+		timeoutSecondsSource, timeoutsSource = "--timeout-seconds", "--timeouts"
+	}
+	if s.TimeoutSeconds != nil {
+		config.setTimeoutSource("timeoutSeconds", timeoutSecondsSource)
+	}
+	for _, p := range s.Timeouts.phases() {
+		if *p.seconds != 0 {
+			config.setTimeoutSource("timeouts."+p.name, timeoutsSource)
+		}
+	}
+	if len(s.Registries) > 0 {
+		config.Registries = make([]registry.Registry, len(s.Registries))
+		for i, name := range s.Registries {
+			config.Registries[i] = registry.Registry{Name: name}
+		}
+	}
+	return config
+}
+
+// rootPath returns the path to the directory where tb stores data.
+// This is the value of TB_HOME if it is set, otherwise ~/.tb.
+func rootPath(homedir string) string {
+	p := os.Getenv(EnvHome)
+	if p == "" {
+		return filepath.Join(homedir, rootDir)
+	}
+	if strings.HasPrefix(p, "~") {
+		return filepath.Join(homedir, strings.TrimPrefix(p, "~"))
+	}
+	if abs, err := filepath.Abs(p); err == nil {
+		return abs
+	}
+	return p
+}
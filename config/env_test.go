@@ -0,0 +1,95 @@
+package config_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TouchBistro/goutils/file"
+	"github.com/TouchBistro/tb/config"
+	"github.com/TouchBistro/tb/registry"
+	"github.com/matryer/is"
+)
+
+func TestLoadEnv(t *testing.T) {
+	is := is.New(t)
+	tmpdir := t.TempDir()
+	homedir := filepath.Join(tmpdir, "home")
+	writeFiles(t, map[string]string{
+		filepath.Join(homedir, ".tbrc.yml"): `experimental: false
+gitConcurrency: 2
+timeoutSeconds: 60
+registries:
+  - name: TouchBistro/tb-registry
+`,
+	})
+	t.Setenv(config.EnvExperimental, "true")
+	t.Setenv(config.EnvGitConcurrency, "8")
+	t.Setenv(config.EnvTimeoutSeconds, "300")
+	t.Setenv(config.EnvRegistries, "ExampleZone/tb-registry, TouchBistro/tb-registry-ci")
+
+	cfg, err := config.Load(homedir, homedir)
+	is.NoErr(err)
+	is.Equal(cfg.ExperimentalMode, true)
+	is.Equal(cfg.GitConcurrency, 8)
+	is.Equal(cfg.TimeoutSeconds, 300)
+	is.Equal(cfg.Registries, []registry.Registry{
+		{Name: "ExampleZone/tb-registry"},
+		{Name: "TouchBistro/tb-registry-ci"},
+	})
+}
+
+func TestLoadEnvNoUserConfig(t *testing.T) {
+	is := is.New(t)
+	homedir := t.TempDir()
+	t.Setenv(config.EnvRegistries, "TouchBistro/tb-registry")
+
+	// The user config must not be created when registries are set through the environment.
+	cfg, err := config.Load(homedir, homedir)
+	is.NoErr(err)
+	is.Equal(cfg.Registries, []registry.Registry{{Name: "TouchBistro/tb-registry"}})
+	is.Equal(len(cfg.Sources), 0)
+	is.True(!file.Exists(filepath.Join(homedir, ".tbrc.yml")))
+}
+
+func TestLoadEnvInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		value   string
+		wantErr string
+	}{
+		{"experimental", config.EnvExperimental, "yes please", "must be true or false"},
+		{"git concurrency", config.EnvGitConcurrency, "four", "must be an integer"},
+		{"timeout seconds", config.EnvTimeoutSeconds, "1m", "must be an integer"},
+		{"timeouts", config.EnvTimeouts, "build:3600", "must be a list of phase=seconds pairs"},
+		{"timeouts phase", config.EnvTimeouts, "builds=3600", `unknown timeout phase "builds"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			homedir := t.TempDir()
+			t.Setenv(tt.env, tt.value)
+			_, err := config.Load(homedir, homedir)
+			is.True(err != nil)
+			is.True(strings.Contains(err.Error(), tt.env))
+			is.True(strings.Contains(err.Error(), tt.wantErr))
+		})
+	}
+}
+
+func TestTBHome(t *testing.T) {
+	is := is.New(t)
+	t.Setenv(config.EnvHome, "~/cache/tb")
+	cfg := config.Config{
+		Registries: []registry.Registry{{Name: "TouchBistro/tb-registry"}},
+	}
+	registries, err := config.ResolveRegistries(cfg, "/home/foo")
+	is.NoErr(err)
+	is.Equal(registries[0].Path, "/home/foo/cache/tb/registries/TouchBistro/tb-registry")
+
+	t.Setenv(config.EnvHome, "/var/cache/tb")
+	reposPath, err := config.ReposPath("/home/foo")
+	is.NoErr(err)
+	is.Equal(reposPath, "/var/cache/tb/repos")
+}
@@ -0,0 +1,258 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/TouchBistro/goutils/errors"
+	"github.com/TouchBistro/goutils/file"
+	"github.com/TouchBistro/tb/errkind"
+	"github.com/TouchBistro/tb/registry"
+	"github.com/TouchBistro/tb/resource/playlist"
+	"github.com/TouchBistro/tb/resource/service"
+	"gopkg.in/yaml.v3"
+)
+
+// projectConfigNames are the names of project config files in order of precedence.
+var projectConfigNames = []string{tbrcName, "tb.yml"}
+
+// Load reads the user config file in homedir and merges the project config over it.
+// The project config is the first .tbrc.yml or tb.yml file found by walking up from dir.
+// Files listed in the include field of a config file are merged first, so that the
+// config file including them takes precedence.
+//
+// The config files are merged in the following order, with later ones taking precedence:
+// the files included by the user config, the user config, the files included by the
+// project config, and the project config.
+//
+// Finally, the settings from the TB_* environment variables are applied, which take precedence
+// over all config files. See SettingsFromEnv.
+//
+// If homedir is empty, it will be resolved from the environment. If dir is empty, the
+// current working directory is used. Like Read, if a config file does not exist in homedir,
+// one will be created, unless registries are set with TB_REGISTRIES. This allows tb to be
+// configured entirely through environment variables, for example in CI.
+//
+// Only the user config is edited by functions like AddRegistry, project config files are never modified.
+func Load(homedir, dir string) (Config, error) {
+	const op = errors.Op("config.Load")
+	if homedir == "" {
+		var err error
+		homedir, err = os.UserHomeDir()
+		if err != nil {
+			return Config{}, errors.Wrap(err, errors.Meta{
+				Kind:   errkind.Internal,
+				Reason: "unable to find user home directory",
+				Op:     op,
+			})
+		}
+	}
+	if dir == "" {
+		var err error
+		dir, err = os.Getwd()
+		if err != nil {
+			return Config{}, errors.Wrap(err, errors.Meta{
+				Kind:   errkind.Internal,
+				Reason: "unable to find current directory",
+				Op:     op,
+			})
+		}
+	}
+
+	settings, err := SettingsFromEnv()
+	if err != nil {
+		return Config{}, errors.Wrap(err, errors.Meta{Op: op})
+	}
+
+	var config Config
+	userPath := filepath.Join(homedir, tbrcName)
+	if len(settings.Registries) == 0 {
+		if err := createDefault(op, userPath); err != nil {
+			return Config{}, err
+		}
+	}
+	if file.Exists(userPath) {
+		config, err = readConfigFile(op, userPath, homedir, make(map[string]bool))
+		if err != nil {
+			return Config{}, err
+		}
+	}
+	if projectPath := FindProjectConfig(dir, homedir); projectPath != "" {
+		project, err := readConfigFile(op, projectPath, homedir, make(map[string]bool))
+		if err != nil {
+			return Config{}, err
+		}
+		config = mergeConfig(config, project)
+	}
+	config.Version = SchemaVersion
+	return Apply(config, settings), nil
+}
+
+// FindProjectConfig returns the path to the project config file found by walking up
+// from dir, or an empty string if there is none. The user config in homedir is never
+// considered a project config.
+func FindProjectConfig(dir, homedir string) string {
+	userPath := filepath.Join(homedir, tbrcName)
+	for {
+		for _, name := range projectConfigNames {
+			path := filepath.Join(dir, name)
+			if path != userPath && file.Exists(path) {
+				return path
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// readConfigFile reads the config file at path and merges it over any files it includes.
+// Relative paths in the file are resolved against the directory containing it.
+// seen contains the files currently being read and is used to detect include cycles.
+func readConfigFile(op errors.Op, path, homedir string, seen map[string]bool) (Config, error) {
+	if seen[path] {
+		return Config{}, errors.New(errkind.Invalid, fmt.Sprintf("config file %s includes itself", path), op)
+	}
+	seen[path] = true
+	defer delete(seen, path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, errors.Wrap(err, errors.Meta{
+			Kind:   errkind.IO,
+			Reason: fmt.Sprintf("failed to read file %s", path),
+			Op:     op,
+		})
+	}
+	var cfg Config
+	var fields map[string]interface{}
+	if err = yaml.Unmarshal(data, &cfg); err == nil {
+		err = yaml.Unmarshal(data, &fields)
+	}
+	if err != nil {
+		return Config{}, errors.Wrap(err, errors.Meta{
+			Kind:   errkind.IO,
+			Reason: fmt.Sprintf("couldn't read yaml file at %s", path),
+			Op:     op,
+		})
+	}
+	cfg.fields = make(map[string]bool, len(fields))
+	for k := range fields {
+		cfg.fields[k] = true
+	}
+	cfg.Sources = []string{path}
+	setTimeoutSources(&cfg, path, cfg.fields["timeoutSeconds"])
+	if err := checkVersion(op, path, cfg.Version); err != nil {
+		return Config{}, err
+	}
+	if cfg.Version < SchemaVersion {
+		cfg.outdated = []string{path}
+	}
+
+	dir := filepath.Dir(path)
+	for i, r := range cfg.Registries {
+		if r.LocalPath != "" && !strings.HasPrefix(r.LocalPath, "~") && !filepath.IsAbs(r.LocalPath) {
+			cfg.Registries[i].LocalPath = filepath.Join(dir, r.LocalPath)
+		}
+	}
+
+	var merged Config
+	for _, inc := range cfg.Include {
+		incPath := inc
+		if strings.HasPrefix(incPath, "~") {
+			incPath = filepath.Join(homedir, strings.TrimPrefix(incPath, "~"))
+		} else if !filepath.IsAbs(incPath) {
+			incPath = filepath.Join(dir, incPath)
+		}
+		incCfg, err := readConfigFile(op, incPath, homedir, seen)
+		if err != nil {
+			return Config{}, errors.Wrap(err, errors.Meta{Reason: fmt.Sprintf("failed to include %s in %s", inc, path), Op: op})
+		}
+		merged = mergeConfig(merged, incCfg)
+	}
+	return mergeConfig(merged, cfg), nil
+}
+
+// mergeConfig merges overlay over base and returns the result. The rules are:
+//
+//   - Fields with a single value are replaced if they are set in overlay.
+//   - Registries are merged by name. A registry in overlay replaces the registry with the same name
+//     in base, otherwise it is added after the registries in base.
+//   - Playlists are merged by name. A playlist in overlay replaces the playlist with the same name in base.
+//   - Overrides are merged by service name. An override in overlay replaces the override for the same
+//     service in base, the fields of the overrides are not merged.
+func mergeConfig(base, overlay Config) Config {
+	merged := base
+	merged.Include = nil
+	merged.Sources = append(append([]string(nil), base.Sources...), overlay.Sources...)
+	merged.outdated = append(append([]string(nil), base.outdated...), overlay.outdated...)
+	merged.fields = make(map[string]bool, len(base.fields)+len(overlay.fields))
+	for k := range base.fields {
+		merged.fields[k] = true
+	}
+	for k := range overlay.fields {
+		merged.fields[k] = true
+	}
+
+	if overlay.fields["debug"] {
+		merged.Debug = overlay.Debug
+	}
+	if overlay.fields["experimental"] {
+		merged.ExperimentalMode = overlay.ExperimentalMode
+	}
+	if overlay.fields["gitConcurrency"] {
+		merged.GitConcurrency = overlay.GitConcurrency
+	}
+	if overlay.fields["replaceDeprecated"] {
+		merged.ReplaceDeprecated = overlay.ReplaceDeprecated
+	}
+	if overlay.fields["timeoutSeconds"] {
+		merged.TimeoutSeconds = overlay.TimeoutSeconds
+	}
+	merged.Timeouts = base.Timeouts.merge(overlay.Timeouts)
+	merged.timeoutSources = make(map[string]string, len(base.timeoutSources)+len(overlay.timeoutSources))
+	for k, v := range base.timeoutSources {
+		merged.timeoutSources[k] = v
+	}
+	for k, v := range overlay.timeoutSources {
+		merged.timeoutSources[k] = v
+	}
+
+	merged.Registries = append([]registry.Registry(nil), base.Registries...)
+	for _, r := range overlay.Registries {
+		replaced := false
+		for i, br := range merged.Registries {
+			if br.Name == r.Name {
+				merged.Registries[i] = r
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged.Registries = append(merged.Registries, r)
+		}
+	}
+	if len(base.Playlists) > 0 || len(overlay.Playlists) > 0 {
+		merged.Playlists = make(map[string]playlist.Playlist, len(base.Playlists)+len(overlay.Playlists))
+		for n, p := range base.Playlists {
+			merged.Playlists[n] = p
+		}
+		for n, p := range overlay.Playlists {
+			merged.Playlists[n] = p
+		}
+	}
+	if len(base.Overrides) > 0 || len(overlay.Overrides) > 0 {
+		merged.Overrides = make(map[string]service.ServiceOverride, len(base.Overrides)+len(overlay.Overrides))
+		for n, o := range base.Overrides {
+			merged.Overrides[n] = o
+		}
+		for n, o := range overlay.Overrides {
+			merged.Overrides[n] = o
+		}
+	}
+	return merged
+}
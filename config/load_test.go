@@ -0,0 +1,176 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TouchBistro/tb/config"
+	"github.com/TouchBistro/tb/registry"
+	"github.com/TouchBistro/tb/resource/playlist"
+	"github.com/TouchBistro/tb/resource/service"
+	"github.com/matryer/is"
+)
+
+func writeFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	for path, data := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create dir for %s: %v", path, err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatalf("failed to write file %s: %v", path, err)
+		}
+	}
+}
+
+func TestLoadProjectConfig(t *testing.T) {
+	is := is.New(t)
+	tmpdir := t.TempDir()
+	homedir := filepath.Join(tmpdir, "home")
+	projectdir := filepath.Join(tmpdir, "projects", "venue")
+	writeFiles(t, map[string]string{
+		filepath.Join(homedir, ".tbrc.yml"): `experimental: true
+gitConcurrency: 4
+include:
+  - ~/shared.yml
+registries:
+  - name: TouchBistro/tb-registry
+playlists:
+  db:
+    services:
+      - postgres
+overrides:
+  TouchBistro/tb-registry/postgres:
+    mode: build
+`,
+		filepath.Join(homedir, "shared.yml"): `timeoutSeconds: 60
+gitConcurrency: 2
+`,
+		filepath.Join(projectdir, "tb.yml"): `include:
+  - ../team.yml
+gitConcurrency: 1
+registries:
+  - name: TouchBistro/tb-registry
+    localPath: ./registry
+playlists:
+  db:
+    services:
+      - redis
+`,
+		filepath.Join(tmpdir, "projects", "team.yml"): `experimental: false
+registries:
+  - name: ExampleZone/tb-registry
+overrides:
+  TouchBistro/tb-registry/postgres:
+    envVars:
+      DEBUG: "true"
+`,
+	})
+
+	cfg, err := config.Load(homedir, filepath.Join(projectdir, "src", "api"))
+	is.NoErr(err)
+	is.Equal(cfg.Sources, []string{
+		filepath.Join(homedir, "shared.yml"),
+		filepath.Join(homedir, ".tbrc.yml"),
+		filepath.Join(tmpdir, "projects", "team.yml"),
+		filepath.Join(projectdir, "tb.yml"),
+	})
+	is.Equal(cfg.ExperimentalMode, false)
+	is.Equal(cfg.GitConcurrency, 1)
+	is.Equal(cfg.TimeoutSeconds, 60)
+	is.Equal(cfg.Registries, []registry.Registry{
+		{Name: "TouchBistro/tb-registry", LocalPath: filepath.Join(projectdir, "registry")},
+		{Name: "ExampleZone/tb-registry"},
+	})
+	is.Equal(cfg.Playlists, map[string]playlist.Playlist{
+		"db": {Services: []string{"redis"}},
+	})
+	is.Equal(cfg.Overrides, map[string]service.ServiceOverride{
+		"TouchBistro/tb-registry/postgres": {EnvVars: map[string]string{"DEBUG": "true"}},
+	})
+}
+
+func TestLoadNoProjectConfig(t *testing.T) {
+	is := is.New(t)
+	tmpdir := t.TempDir()
+	homedir := filepath.Join(tmpdir, "home")
+	writeFiles(t, map[string]string{
+		filepath.Join(homedir, ".tbrc.yml"): "gitConcurrency: 2\n",
+	})
+
+	// The user config must not be treated as a project config when running from the home directory.
+	cfg, err := config.Load(homedir, homedir)
+	is.NoErr(err)
+	is.Equal(cfg.Sources, []string{filepath.Join(homedir, ".tbrc.yml")})
+	is.Equal(cfg.GitConcurrency, 2)
+}
+
+func TestLoadIncludeCycle(t *testing.T) {
+	is := is.New(t)
+	tmpdir := t.TempDir()
+	homedir := filepath.Join(tmpdir, "home")
+	writeFiles(t, map[string]string{
+		filepath.Join(homedir, ".tbrc.yml"): "include:\n  - a.yml\n",
+		filepath.Join(homedir, "a.yml"):     "include:\n  - b.yml\n",
+		filepath.Join(homedir, "b.yml"):     "include:\n  - a.yml\n",
+	})
+
+	_, err := config.Load(homedir, tmpdir)
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "includes itself"))
+}
+
+func TestLoadInvalidYaml(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{
+			name:  "malformed yaml",
+			files: map[string]string{".tbrc.yml": "registries:\n  - name: [TouchBistro/tb-registry\n"},
+		},
+		{
+			name:  "wrong type",
+			files: map[string]string{".tbrc.yml": "timeoutSeconds: abc\n"},
+		},
+		{
+			name: "malformed include",
+			files: map[string]string{
+				".tbrc.yml":  "include:\n  - shared.yml\n",
+				"shared.yml": "gitConcurrency: [\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			homedir := t.TempDir()
+			files := make(map[string]string, len(tt.files))
+			for name, data := range tt.files {
+				files[filepath.Join(homedir, name)] = data
+			}
+			writeFiles(t, files)
+
+			_, err := config.Load(homedir, homedir)
+			is.True(err != nil)
+			is.True(strings.Contains(err.Error(), "couldn't read yaml file"))
+		})
+	}
+}
+
+func TestFindProjectConfig(t *testing.T) {
+	is := is.New(t)
+	tmpdir := t.TempDir()
+	homedir := filepath.Join(tmpdir, "home")
+	projectdir := filepath.Join(tmpdir, "project")
+	writeFiles(t, map[string]string{
+		filepath.Join(projectdir, ".tbrc.yml"):        "",
+		filepath.Join(projectdir, "tb.yml"):           "",
+		filepath.Join(projectdir, "nested", "tb.yml"): "",
+	})
+	is.Equal(config.FindProjectConfig(filepath.Join(projectdir, "src"), homedir), filepath.Join(projectdir, ".tbrc.yml"))
+	is.Equal(config.FindProjectConfig(filepath.Join(projectdir, "nested"), homedir), filepath.Join(projectdir, "nested", "tb.yml"))
+	is.Equal(config.FindProjectConfig(homedir, homedir), "")
+}
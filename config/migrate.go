@@ -0,0 +1,208 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/TouchBistro/goutils/errors"
+	"github.com/TouchBistro/tb/errkind"
+	"github.com/TouchBistro/tb/resource"
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaVersion is the latest version of the tbrc format supported by this version of tb.
+// Config files declare the version they use with the version field, files without one are version 0.
+// Config files using an older version can still be read, and can be upgraded with Migrate.
+//
+// When making a change to the format that requires existing config files to be updated,
+// increment SchemaVersion and add a migration to migrations.
+const SchemaVersion = 1
+
+// migrations contains the functions used to upgrade config files.
+// migrations[i] upgrades a config file from version i to version i+1,
+// so there must be exactly SchemaVersion migrations.
+//
+// Each migration is given the top level mapping node of the config file and edits it in place.
+// It returns a description of each change it made, which is shown to the user.
+var migrations = []func(tbrcContentNode *yaml.Node) []string{
+	migrateV1,
+}
+
+// migrateV1 removes the deprecated debug field and qualifies overrides that use
+// the short name of a service with the registry name.
+func migrateV1(tbrcContentNode *yaml.Node) []string {
+	var changes []string
+	if removeMappingKey(tbrcContentNode, "debug") {
+		changes = append(changes, "removed the deprecated debug field, use the --verbose flag instead")
+	}
+
+	// Overrides must use the full service name. Older config files may use the short name,
+	// which can only be updated if there is a single registry it could refer to.
+	overridesNode := mappingValue(tbrcContentNode, "overrides")
+	registriesNode := mappingValue(tbrcContentNode, "registries")
+	if overridesNode == nil || overridesNode.Kind != yaml.MappingNode || registriesNode == nil || len(registriesNode.Content) != 1 {
+		return changes
+	}
+	registryNameNode := mappingValue(registriesNode.Content[0], "name")
+	if registryNameNode == nil || registryNameNode.Value == "" {
+		return changes
+	}
+	for i := 0; i+1 < len(overridesNode.Content); i += 2 {
+		keyNode := overridesNode.Content[i]
+		registryName, serviceName, err := resource.ParseName(keyNode.Value)
+		if err != nil || registryName != "" {
+			continue
+		}
+		fullName := resource.FullName(registryNameNode.Value, serviceName)
+		if mappingValue(overridesNode, fullName) != nil {
+			// Renaming would create a duplicate key, the user needs to decide how to merge them.
+			changes = append(changes, fmt.Sprintf("did not rename override %s since an override for %s already exists, merge them manually", keyNode.Value, fullName))
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("renamed override %s to %s", keyNode.Value, fullName))
+		keyNode.Value = fullName
+	}
+	return changes
+}
+
+// MigrateResult describes the changes made to a config file by Migrate.
+type MigrateResult struct {
+	// Path is the path of the config file that was migrated.
+	Path string
+	// FromVersion is the version the config file used before it was migrated.
+	FromVersion int
+	// Changes contains a description of each change made to the config file.
+	Changes []string
+	// BackupPath is the path of the copy of the original config file.
+	// It is empty if the config file was already using SchemaVersion.
+	BackupPath string
+}
+
+// Migrate upgrades the config file at path to SchemaVersion. If path is empty, the tbrc in the given
+// home directory is used. If homedir is empty, it will be resolved from the environment.
+//
+// Before the config file is changed, the original is copied to a backup file next to it named
+// <file>.v<version>.bak. If that file already exists, a number is added to the name, like
+// <file>.v<version>.1.bak, so existing backups are never overwritten.
+// Like AddRegistry, comments in the config file are preserved.
+// If the config file is already using SchemaVersion it is not changed.
+func Migrate(path, homedir string) (MigrateResult, error) {
+	const op = errors.Op("config.Migrate")
+	if path == "" {
+		if homedir == "" {
+			var err error
+			homedir, err = os.UserHomeDir()
+			if err != nil {
+				return MigrateResult{}, errors.Wrap(err, errors.Meta{
+					Kind:   errkind.Internal,
+					Reason: "unable to find user home directory",
+					Op:     op,
+				})
+			}
+		}
+		path = filepath.Join(homedir, tbrcName)
+	}
+	result := MigrateResult{Path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return result, errors.Wrap(err, errors.Meta{
+			Kind:   errkind.IO,
+			Reason: fmt.Sprintf("failed to read file %s", path),
+			Op:     op,
+		})
+	}
+	var versioned struct {
+		Version int `yaml:"version"`
+	}
+	if err := yaml.Unmarshal(data, &versioned); err != nil {
+		return result, errors.Wrap(err, errors.Meta{
+			Kind:   errkind.IO,
+			Reason: fmt.Sprintf("couldn't read yaml file at %s", path),
+			Op:     op,
+		})
+	}
+	result.FromVersion = versioned.Version
+	if err := checkVersion(op, path, versioned.Version); err != nil {
+		return result, err
+	}
+	if versioned.Version == SchemaVersion {
+		return result, nil
+	}
+
+	backupPath, err := writeBackup(path, versioned.Version, data)
+	if err != nil {
+		return result, errors.Wrap(err, errors.Meta{
+			Kind:   errkind.IO,
+			Reason: fmt.Sprintf("failed to back up %s", path),
+			Op:     op,
+		})
+	}
+	result.BackupPath = backupPath
+	err = editFile(op, path, func(tbrcContentNode *yaml.Node) error {
+		for _, migrate := range migrations[versioned.Version:] {
+			result.Changes = append(result.Changes, migrate(tbrcContentNode)...)
+		}
+		versionNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(SchemaVersion)}
+		if n := mappingValue(tbrcContentNode, "version"); n != nil {
+			*n = *versionNode
+		} else {
+			// Put the version first so it is easy to find.
+			keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"}
+			tbrcContentNode.Content = append([]*yaml.Node{keyNode, versionNode}, tbrcContentNode.Content...)
+		}
+		result.Changes = append(result.Changes, fmt.Sprintf("set version to %d", SchemaVersion))
+		return nil
+	})
+	return result, err
+}
+
+// writeBackup writes data to a new backup file for the config file at path using the given version.
+// It returns the path of the backup file.
+func writeBackup(path string, version int, data []byte) (string, error) {
+	for i := 0; ; i++ {
+		backupPath := fmt.Sprintf("%s.v%d.bak", path, version)
+		if i > 0 {
+			backupPath = fmt.Sprintf("%s.v%d.%d.bak", path, version, i)
+		}
+		// O_EXCL makes sure an existing file is never overwritten.
+		f, err := os.OpenFile(backupPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		} else if err != nil {
+			return "", err
+		}
+		if _, err := f.Write(data); err != nil {
+			f.Close()
+			return "", err
+		}
+		return backupPath, f.Close()
+	}
+}
+
+// checkVersion returns an error if version is not a version of the tbrc format
+// supported by this version of tb.
+func checkVersion(op errors.Op, path string, version int) error {
+	if version < 0 || version > SchemaVersion {
+		msg := fmt.Sprintf(
+			"config file %s uses unsupported version %d, the latest version supported by this version of tb is %d",
+			path, version, SchemaVersion,
+		)
+		return errors.New(errkind.Invalid, msg, op)
+	}
+	return nil
+}
+
+// removeMappingKey removes key and its value from the mapping node n.
+// It returns true if key existed.
+func removeMappingKey(n *yaml.Node, key string) bool {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			n.Content = append(n.Content[:i], n.Content[i+2:]...)
+			return true
+		}
+	}
+	return false
+}
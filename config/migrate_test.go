@@ -0,0 +1,169 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TouchBistro/tb/config"
+	"github.com/matryer/is"
+)
+
+func TestMigrate(t *testing.T) {
+	is := is.New(t)
+	homedir := t.TempDir()
+	tbrcPath := filepath.Join(homedir, ".tbrc.yml")
+	const original = `# Enable debug logs
+debug: true
+# Toggle experimental mode
+experimental: true
+registries:
+  - name: TouchBistro/tb-registry
+overrides:
+  postgres: # use the local repo
+    mode: build
+  TouchBistro/tb-registry/redis:
+    mode: remote
+`
+	writeFiles(t, map[string]string{tbrcPath: original})
+
+	result, err := config.Migrate("", homedir)
+	is.NoErr(err)
+	is.Equal(result.Path, tbrcPath)
+	is.Equal(result.FromVersion, 0)
+	is.Equal(result.BackupPath, tbrcPath+".v0.bak")
+	is.Equal(result.Changes, []string{
+		"removed the deprecated debug field, use the --verbose flag instead",
+		"renamed override postgres to TouchBistro/tb-registry/postgres",
+		"set version to 1",
+	})
+
+	data, err := os.ReadFile(tbrcPath)
+	is.NoErr(err)
+	is.Equal(string(data), `version: 1
+# Toggle experimental mode
+experimental: true
+registries:
+  - name: TouchBistro/tb-registry
+overrides:
+  TouchBistro/tb-registry/postgres: # use the local repo
+    mode: build
+  TouchBistro/tb-registry/redis:
+    mode: remote
+`)
+	backup, err := os.ReadFile(result.BackupPath)
+	is.NoErr(err)
+	is.Equal(string(backup), original)
+
+	// The migrated file must not be reported as outdated
+	cfg, err := config.Load(homedir, homedir)
+	is.NoErr(err)
+	is.Equal(len(cfg.Outdated()), 0)
+
+	// Migrating again does nothing
+	result, err = config.Migrate(tbrcPath, homedir)
+	is.NoErr(err)
+	is.Equal(result.FromVersion, config.SchemaVersion)
+	is.Equal(result.BackupPath, "")
+	is.Equal(len(result.Changes), 0)
+}
+
+func TestMigrateExistingBackup(t *testing.T) {
+	is := is.New(t)
+	homedir := t.TempDir()
+	tbrcPath := filepath.Join(homedir, ".tbrc.yml")
+	writeFiles(t, map[string]string{
+		tbrcPath:             "debug: true\n",
+		tbrcPath + ".v0.bak": "old backup\n",
+	})
+
+	// The existing backup must not be overwritten
+	result, err := config.Migrate("", homedir)
+	is.NoErr(err)
+	is.Equal(result.BackupPath, tbrcPath+".v0.1.bak")
+	backup, err := os.ReadFile(result.BackupPath)
+	is.NoErr(err)
+	is.Equal(string(backup), "debug: true\n")
+	backup, err = os.ReadFile(tbrcPath + ".v0.bak")
+	is.NoErr(err)
+	is.Equal(string(backup), "old backup\n")
+}
+
+func TestMigrateAmbiguousOverride(t *testing.T) {
+	is := is.New(t)
+	homedir := t.TempDir()
+	tbrcPath := filepath.Join(homedir, ".tbrc.yml")
+	writeFiles(t, map[string]string{tbrcPath: `registries:
+  - name: TouchBistro/tb-registry
+  - name: ExampleZone/tb-registry
+overrides:
+  postgres:
+    mode: build
+`})
+
+	// The override can't be renamed since it could refer to either registry
+	result, err := config.Migrate("", homedir)
+	is.NoErr(err)
+	is.Equal(result.Changes, []string{"set version to 1"})
+	data, err := os.ReadFile(tbrcPath)
+	is.NoErr(err)
+	is.True(strings.Contains(string(data), "\n  postgres:\n"))
+}
+
+func TestLoadOutdated(t *testing.T) {
+	is := is.New(t)
+	tmpdir := t.TempDir()
+	homedir := filepath.Join(tmpdir, "home")
+	writeFiles(t, map[string]string{
+		filepath.Join(homedir, ".tbrc.yml"):  "version: 1\ninclude:\n  - shared.yml\n",
+		filepath.Join(homedir, "shared.yml"): "debug: true\n",
+	})
+
+	cfg, err := config.Load(homedir, homedir)
+	is.NoErr(err)
+	is.Equal(cfg.Version, config.SchemaVersion)
+	is.Equal(cfg.Outdated(), []string{filepath.Join(homedir, "shared.yml")})
+}
+
+func TestLoadUnsupportedVersion(t *testing.T) {
+	is := is.New(t)
+	homedir := t.TempDir()
+	writeFiles(t, map[string]string{
+		filepath.Join(homedir, ".tbrc.yml"): "version: 100\n",
+	})
+
+	_, err := config.Load(homedir, homedir)
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "unsupported version 100"))
+
+	_, err = config.Migrate("", homedir)
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "unsupported version 100"))
+}
+
+func TestMigrateConflictingOverride(t *testing.T) {
+	is := is.New(t)
+	homedir := t.TempDir()
+	tbrcPath := filepath.Join(homedir, ".tbrc.yml")
+	writeFiles(t, map[string]string{tbrcPath: `registries:
+  - name: TouchBistro/tb-registry
+overrides:
+  postgres:
+    mode: build
+  TouchBistro/tb-registry/postgres:
+    mode: remote
+`})
+
+	// Renaming the override would create a duplicate key
+	result, err := config.Migrate("", homedir)
+	is.NoErr(err)
+	is.Equal(result.Changes, []string{
+		"did not rename override postgres since an override for TouchBistro/tb-registry/postgres already exists, merge them manually",
+		"set version to 1",
+	})
+	data, err := os.ReadFile(tbrcPath)
+	is.NoErr(err)
+	is.True(strings.Contains(string(data), "\n  postgres:\n"))
+	is.True(strings.Contains(string(data), "\n  TouchBistro/tb-registry/postgres:\n"))
+}
@@ -0,0 +1,265 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/TouchBistro/goutils/errors"
+	"github.com/TouchBistro/tb/errkind"
+	"github.com/TouchBistro/tb/resource"
+	"github.com/TouchBistro/tb/resource/playlist"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrPlaylistExists indicates that the custom playlist being created already exists.
+var ErrPlaylistExists errors.String = "playlist already exists"
+
+// ErrPlaylistNotFound indicates that the custom playlist does not exist in the config.
+var ErrPlaylistNotFound errors.String = "playlist not found"
+
+// CreatePlaylist adds the custom playlist p to the config file located in the given home directory.
+// If homedir is empty, it will be resolved from the environment. Like AddRegistry, comments in
+// the config file are preserved.
+//
+// If a custom playlist with the same name already exists, ErrPlaylistExists is returned
+// unless replace is true, in which case the existing playlist is replaced.
+func CreatePlaylist(p playlist.Playlist, replace bool, homedir string) error {
+	const op = errors.Op("config.CreatePlaylist")
+	if err := validatePlaylistName(op, p.Name); err != nil {
+		return err
+	}
+	if p.Services == nil {
+		// Make sure services is written as an empty list instead of null.
+		p.Services = []string{}
+	}
+	var playlistNode yaml.Node
+	if err := playlistNode.Encode(p); err != nil {
+		return errors.Wrap(err, errors.Meta{
+			Kind:   errkind.Internal,
+			Reason: fmt.Sprintf("failed to encode playlist %s", p.Name),
+			Op:     op,
+		})
+	}
+	return editPlaylists(op, homedir, func(playlistsNode *yaml.Node) error {
+		for i := 0; i+1 < len(playlistsNode.Content); i += 2 {
+			if playlistsNode.Content[i].Value != p.Name {
+				continue
+			}
+			if !replace {
+				return ErrPlaylistExists
+			}
+			playlistsNode.Content[i+1] = &playlistNode
+			return nil
+		}
+		keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: p.Name}
+		playlistsNode.Content = append(playlistsNode.Content, keyNode, &playlistNode)
+		return nil
+	})
+}
+
+// DeletePlaylist removes the custom playlist with name from the config file located in the given
+// home directory. If homedir is empty, it will be resolved from the environment.
+//
+// If the playlist does not exist in the config file, ErrPlaylistNotFound will be returned.
+func DeletePlaylist(name, homedir string) error {
+	const op = errors.Op("config.DeletePlaylist")
+	return editPlaylists(op, homedir, func(playlistsNode *yaml.Node) error {
+		for i := 0; i+1 < len(playlistsNode.Content); i += 2 {
+			if playlistsNode.Content[i].Value == name {
+				playlistsNode.Content = append(playlistsNode.Content[:i], playlistsNode.Content[i+2:]...)
+				return nil
+			}
+		}
+		return ErrPlaylistNotFound
+	})
+}
+
+// AddPlaylistServices adds the services to the custom playlist with name in the config file
+// located in the given home directory. If homedir is empty, it will be resolved from the environment.
+// Services that are already in the playlist are skipped.
+//
+// If the playlist does not exist in the config file, ErrPlaylistNotFound will be returned.
+func AddPlaylistServices(name string, serviceNames []string, homedir string) error {
+	const op = errors.Op("config.AddPlaylistServices")
+	return editPlaylistServices(op, name, homedir, func(servicesNode *yaml.Node) error {
+		existing := make(map[string]bool)
+		for _, n := range servicesNode.Content {
+			existing[n.Value] = true
+		}
+		for _, sn := range serviceNames {
+			if existing[sn] {
+				continue
+			}
+			existing[sn] = true
+			servicesNode.Content = append(servicesNode.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: sn})
+		}
+		return nil
+	})
+}
+
+// RemovePlaylistServices removes the services from the custom playlist with name in the config file
+// located in the given home directory. If homedir is empty, it will be resolved from the environment.
+//
+// If the playlist does not exist in the config file, ErrPlaylistNotFound will be returned.
+// If any of the services are not in the playlist, an error is returned and the file is not changed.
+func RemovePlaylistServices(name string, serviceNames []string, homedir string) error {
+	const op = errors.Op("config.RemovePlaylistServices")
+	return editPlaylistServices(op, name, homedir, func(servicesNode *yaml.Node) error {
+		for _, sn := range serviceNames {
+			found := false
+			for i, n := range servicesNode.Content {
+				if n.Value == sn {
+					servicesNode.Content = append(servicesNode.Content[:i], servicesNode.Content[i+1:]...)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return errors.New(errkind.Invalid, fmt.Sprintf("service %s is not in playlist %s", sn, name), op)
+			}
+		}
+		return nil
+	})
+}
+
+// editPlaylistServices calls edit with the services node of the custom playlist with name
+// and writes the changes to the config file.
+func editPlaylistServices(op errors.Op, name, homedir string, edit func(servicesNode *yaml.Node) error) error {
+	return editPlaylists(op, homedir, func(playlistsNode *yaml.Node) error {
+		var playlistNode *yaml.Node
+		for i := 0; i+1 < len(playlistsNode.Content); i += 2 {
+			if playlistsNode.Content[i].Value == name {
+				playlistNode = playlistsNode.Content[i+1]
+				break
+			}
+		}
+		if playlistNode == nil {
+			return ErrPlaylistNotFound
+		}
+		if playlistNode.Kind != yaml.MappingNode {
+			// Playlist has no fields, i.e. empty key
+			playlistNode.Kind = yaml.MappingNode
+			playlistNode.Tag = "!!map"
+			playlistNode.Value = ""
+		}
+		servicesNode := mappingValue(playlistNode, "services")
+		if servicesNode == nil {
+			servicesNode = &yaml.Node{}
+			keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "services"}
+			playlistNode.Content = append(playlistNode.Content, keyNode, servicesNode)
+		}
+		if servicesNode.Kind != yaml.SequenceNode {
+			// No services defined, i.e. empty key or null
+			servicesNode.Kind = yaml.SequenceNode
+			servicesNode.Tag = "!!seq"
+			servicesNode.Value = ""
+			servicesNode.Style = 0
+		}
+		return edit(servicesNode)
+	})
+}
+
+// editPlaylists reads the config file in homedir and calls edit with the playlists node.
+// If edit succeeds, the config file is updated with the changes.
+// Comments and ordering in the config file are preserved.
+func editPlaylists(op errors.Op, homedir string, edit func(playlistsNode *yaml.Node) error) error {
+	return editConfigFile(op, homedir, func(tbrcContentNode *yaml.Node) error {
+		// Only look at the top level since other sections can have a playlists key.
+		playlistsNode := mappingValue(tbrcContentNode, "playlists")
+		if playlistsNode == nil {
+			keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "playlists"}
+			playlistsNode = &yaml.Node{}
+			tbrcContentNode.Content = append(tbrcContentNode.Content, keyNode, playlistsNode)
+		}
+		if playlistsNode.Kind != yaml.MappingNode {
+			// No playlists defined, i.e. empty key
+			// Update the playlists node to be a mapping node so it can be treated
+			// the same as if there were already playlists
+			playlistsNode.Kind = yaml.MappingNode
+			playlistsNode.Tag = "!!map"
+			playlistsNode.Value = ""
+		}
+		return edit(playlistsNode)
+	})
+}
+
+// editConfigFile reads the config file in homedir and calls edit with the top level mapping node.
+// If edit succeeds, the config file is updated with the changes.
+// Comments and ordering in the config file are preserved.
+func editConfigFile(op errors.Op, homedir string, edit func(tbrcContentNode *yaml.Node) error) error {
+	if homedir == "" {
+		var err error
+		homedir, err = os.UserHomeDir()
+		if err != nil {
+			return errors.Wrap(err, errors.Meta{
+				Kind:   errkind.Internal,
+				Reason: "unable to find user home directory",
+				Op:     op,
+			})
+		}
+	}
+
+	return editFile(op, filepath.Join(homedir, tbrcName), edit)
+}
+
+// editFile is like editConfigFile but edits the config file at tbrcPath.
+func editFile(op errors.Op, tbrcPath string, edit func(tbrcContentNode *yaml.Node) error) error {
+	f, err := os.OpenFile(tbrcPath, os.O_RDWR, 0644)
+	if err != nil {
+		return errors.Wrap(err, errors.Meta{
+			Kind:   errkind.IO,
+			Reason: fmt.Sprintf("failed to open file %s", tbrcPath),
+			Op:     op,
+		})
+	}
+	defer f.Close()
+
+	// Decode into a Node so we can manipulate the contents while
+	// preserving comments and ordering
+	tbrcDocumentNode := &yaml.Node{}
+	if err := yaml.NewDecoder(f).Decode(tbrcDocumentNode); errors.Is(err, io.EOF) {
+		// Empty file, start with an empty mapping
+		tbrcDocumentNode.Kind = yaml.DocumentNode
+		tbrcDocumentNode.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	} else if err != nil {
+		return errors.Wrap(err, errors.Meta{
+			Kind:   errkind.IO,
+			Reason: fmt.Sprintf("couldn't read yaml file at %s", tbrcPath),
+			Op:     op,
+		})
+	}
+	if len(tbrcDocumentNode.Content) != 1 || tbrcDocumentNode.Content[0].Kind != yaml.MappingNode {
+		return errors.New(errkind.Invalid, fmt.Sprintf("tbrc at %s is not a yaml mapping", tbrcPath), op)
+	}
+
+	if err := edit(tbrcDocumentNode.Content[0]); err != nil {
+		return errors.Wrap(err, errors.Meta{Op: op})
+	}
+	return writeYamlFile(op, f, tbrcDocumentNode)
+}
+
+// mappingValue returns the value node for key in the mapping node n, or nil if key does not exist.
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// validatePlaylistName checks that name can be used as the name of a custom playlist.
+func validatePlaylistName(op errors.Op, name string) error {
+	registryName, _, err := resource.ParseName(name)
+	if err != nil {
+		msg := fmt.Sprintf("invalid playlist name %q, names can only contain letters, numbers, '_', and '-'", name)
+		return errors.New(errkind.Invalid, msg, op)
+	}
+	if registryName != "" {
+		msg := fmt.Sprintf("invalid playlist name %q, custom playlists cannot be part of a registry", name)
+		return errors.New(errkind.Invalid, msg, op)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TouchBistro/goutils/errors"
+	"github.com/TouchBistro/tb/config"
+	"github.com/TouchBistro/tb/resource/playlist"
+	"github.com/matryer/is"
+)
+
+const playlistsTBRC = `# Custom playlists
+playlists:
+  # The services I work on
+  my-core:
+    services:
+      - postgres
+      - venue-core-service
+# Override service configuration
+overrides:
+`
+
+func TestEditPlaylists(t *testing.T) {
+	tests := []struct {
+		name         string
+		edit         func(homedir string) error
+		expectedTBRC string
+		err          error
+		errMsg       string
+	}{
+		{
+			name: "create playlist",
+			edit: func(homedir string) error {
+				return config.CreatePlaylist(playlist.Playlist{
+					Name:     "payments",
+					Extends:  playlist.NameList{"core"},
+					Exclude:  []string{"analytics-worker"},
+					Services: []string{"payments-service"},
+				}, false, homedir)
+			},
+			expectedTBRC: `# Custom playlists
+playlists:
+  # The services I work on
+  my-core:
+    services:
+      - postgres
+      - venue-core-service
+  payments:
+    extends: core
+    exclude:
+      - analytics-worker
+    services:
+      - payments-service
+# Override service configuration
+overrides:
+`,
+		},
+		{
+			name: "create existing playlist",
+			edit: func(homedir string) error {
+				return config.CreatePlaylist(playlist.Playlist{Name: "my-core"}, false, homedir)
+			},
+			expectedTBRC: playlistsTBRC,
+			err:          config.ErrPlaylistExists,
+		},
+		{
+			name: "replace existing playlist",
+			edit: func(homedir string) error {
+				return config.CreatePlaylist(playlist.Playlist{Name: "my-core", Services: []string{"redis"}}, true, homedir)
+			},
+			expectedTBRC: `# Custom playlists
+playlists:
+  # The services I work on
+  my-core:
+    services:
+      - redis
+# Override service configuration
+overrides:
+`,
+		},
+		{
+			name: "add services",
+			edit: func(homedir string) error {
+				return config.AddPlaylistServices("my-core", []string{"postgres", "redis"}, homedir)
+			},
+			expectedTBRC: `# Custom playlists
+playlists:
+  # The services I work on
+  my-core:
+    services:
+      - postgres
+      - venue-core-service
+      - redis
+# Override service configuration
+overrides:
+`,
+		},
+		{
+			name: "remove services",
+			edit: func(homedir string) error {
+				return config.RemovePlaylistServices("my-core", []string{"postgres"}, homedir)
+			},
+			expectedTBRC: `# Custom playlists
+playlists:
+  # The services I work on
+  my-core:
+    services:
+      - venue-core-service
+# Override service configuration
+overrides:
+`,
+		},
+		{
+			name: "remove service not in playlist",
+			edit: func(homedir string) error {
+				return config.RemovePlaylistServices("my-core", []string{"redis"}, homedir)
+			},
+			expectedTBRC: playlistsTBRC,
+			errMsg:       "service redis is not in playlist my-core",
+		},
+		{
+			name: "delete playlist",
+			edit: func(homedir string) error {
+				return config.DeletePlaylist("my-core", homedir)
+			},
+			expectedTBRC: `# Custom playlists
+playlists: {}
+# Override service configuration
+overrides:
+`,
+		},
+		{
+			name: "delete missing playlist",
+			edit: func(homedir string) error {
+				return config.DeletePlaylist("db", homedir)
+			},
+			expectedTBRC: playlistsTBRC,
+			err:          config.ErrPlaylistNotFound,
+		},
+		{
+			name: "invalid playlist name",
+			edit: func(homedir string) error {
+				return config.CreatePlaylist(playlist.Playlist{Name: "TouchBistro/tb-registry/core"}, false, homedir)
+			},
+			expectedTBRC: playlistsTBRC,
+			errMsg:       `invalid playlist name "TouchBistro/tb-registry/core", custom playlists cannot be part of a registry`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			tmpdir := t.TempDir()
+			tbrcPath := filepath.Join(tmpdir, ".tbrc.yml")
+			is.NoErr(os.WriteFile(tbrcPath, []byte(playlistsTBRC), 0o644))
+
+			err := tt.edit(tmpdir)
+			switch {
+			case tt.err != nil:
+				is.True(errors.Is(err, tt.err))
+			case tt.errMsg != "":
+				is.True(err != nil)
+				is.True(strings.HasSuffix(err.Error(), tt.errMsg))
+			default:
+				is.NoErr(err)
+			}
+
+			data, err := os.ReadFile(tbrcPath)
+			is.NoErr(err)
+			is.Equal(string(data), tt.expectedTBRC)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/TouchBistro/goutils/errors"
+	"github.com/TouchBistro/tb/errkind"
+	"github.com/TouchBistro/tb/internal/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// Set sets the field at path in the config file located in the given home directory to value.
+// path is a dot separated list of yaml field names or map keys, like 'timeoutSeconds' or
+// 'overrides.TouchBistro/tb-registry/postgres.mode'. Fields that are lists are set by splitting
+// value on commas. Fields that contain other fields, like registries, cannot be set.
+//
+// If homedir is empty, it will be resolved from the environment. Like AddRegistry, comments in
+// the config file are preserved.
+func Set(path, value, homedir string) error {
+	const op = errors.Op("config.Set")
+	t, err := schema.FieldType(reflect.TypeOf(Config{}), path)
+	if err != nil {
+		return errors.Wrap(err, errors.Meta{Kind: errkind.Invalid, Reason: "invalid config field", Op: op})
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var valueNode *yaml.Node
+	switch {
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.String:
+		valueNode = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if value != "" {
+			for _, item := range strings.Split(value, ",") {
+				valueNode.Content = append(valueNode.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: item})
+			}
+		}
+	case t.Kind() == reflect.String:
+		valueNode = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	case t.Kind() == reflect.Bool, t.Kind() == reflect.Int:
+		// Let the yaml package resolve the type so that invalid values are caught below.
+		valueNode = &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+	default:
+		msg := fmt.Sprintf("config field %s cannot be set to a value, set one of its fields instead", path)
+		return errors.New(errkind.Invalid, msg, op)
+	}
+	// Make sure the value can be decoded into the field so that invalid values are never written.
+	if err := valueNode.Decode(reflect.New(t).Interface()); err != nil {
+		return errors.Wrap(err, errors.Meta{
+			Kind:   errkind.Invalid,
+			Reason: fmt.Sprintf("invalid value %q for config field %s", value, path),
+			Op:     op,
+		})
+	}
+
+	return editConfigFile(op, homedir, func(n *yaml.Node) error {
+		keys := strings.Split(path, ".")
+		for _, k := range keys[:len(keys)-1] {
+			next := mappingValue(n, k)
+			if next == nil {
+				next = &yaml.Node{}
+				n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k}, next)
+			}
+			if next.Kind != yaml.MappingNode {
+				// Field is not set, i.e. empty key or null
+				// Update the node to be a mapping node so the field can be added to it
+				next.Kind = yaml.MappingNode
+				next.Tag = "!!map"
+				next.Value = ""
+				next.Style = 0
+				next.Content = nil
+			}
+			n = next
+		}
+		key := keys[len(keys)-1]
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == key {
+				// Keep any comment on the same line as the value
+				valueNode.LineComment = n.Content[i+1].LineComment
+				n.Content[i+1] = valueNode
+				return nil
+			}
+		}
+		n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, valueNode)
+		return nil
+	})
+}
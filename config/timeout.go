@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/TouchBistro/goutils/errors"
+	"github.com/TouchBistro/tb/engine"
+	"github.com/TouchBistro/tb/errkind"
+)
+
+// Timeouts sets the timeout in seconds for each phase of running services and apps.
+// Any phase that is not set uses timeoutSeconds.
+type Timeouts struct {
+	// Git is the timeout for cloning and pulling registries and service repos.
+	Git int `yaml:"git,omitempty"`
+	// Pull is the timeout for pulling docker images.
+	Pull int `yaml:"pull,omitempty"`
+	// Build is the timeout for building docker images.
+	Build int `yaml:"build,omitempty"`
+	// PreRun is the timeout for the pre-run step of each service.
+	PreRun int `yaml:"preRun,omitempty"`
+	// Start is the timeout for starting services.
+	Start int `yaml:"start,omitempty"`
+	// AppDownload is the timeout for downloading an app build.
+	AppDownload int `yaml:"appDownload,omitempty"`
+}
+
+// timeoutPhase is a phase in Timeouts.
+type timeoutPhase struct {
+	name    string // The yaml field name
+	seconds *int
+	max     int // The largest allowed value in seconds
+}
+
+// phases returns each phase in t, in the same order as the fields.
+// Phases that can legitimately take a long time, like building images, allow larger values.
+func (t *Timeouts) phases() []timeoutPhase {
+	return []timeoutPhase{
+		{"git", &t.Git, maxTimeoutSeconds},
+		{"pull", &t.Pull, 2 * maxTimeoutSeconds},
+		{"build", &t.Build, 4 * maxTimeoutSeconds},
+		{"preRun", &t.PreRun, 2 * maxTimeoutSeconds},
+		{"start", &t.Start, maxTimeoutSeconds},
+		{"appDownload", &t.AppDownload, 2 * maxTimeoutSeconds},
+	}
+}
+
+// merge returns t with the phases that are set in overlay replaced.
+func (t Timeouts) merge(overlay Timeouts) Timeouts {
+	overlayPhases := overlay.phases()
+	for i, p := range t.phases() {
+		if v := *overlayPhases[i].seconds; v != 0 {
+			*p.seconds = v
+		}
+	}
+	return t
+}
+
+// ParseTimeouts returns Timeouts with the phases in m set. The keys of m are the
+// yaml field names of the phases, like 'build', and the values are in seconds.
+// An error is returned if m contains an unknown phase.
+func ParseTimeouts(m map[string]int) (Timeouts, error) {
+	const op = errors.Op("config.ParseTimeouts")
+	var t Timeouts
+	phases := t.phases()
+	for _, name := range sortedKeys(m) {
+		found := false
+		for _, p := range phases {
+			if p.name == name {
+				*p.seconds = m[name]
+				found = true
+				break
+			}
+		}
+		if !found {
+			names := make([]string, len(phases))
+			for i, p := range phases {
+				names[i] = p.name
+			}
+			msg := fmt.Sprintf("unknown timeout phase %q, must be one of: %s", name, strings.Join(names, ", "))
+			return t, errors.New(errkind.Invalid, msg, op)
+		}
+	}
+	return t, nil
+}
+
+// phaseTimeouts returns the timeout for each phase based on config.
+// Phases that are not set use the timeout returned by operationTimeout.
+//
+// If any timeouts are invalid, the returned error will be an errors.List with an error for each.
+func phaseTimeouts(config Config) (engine.Timeouts, error) {
+	const op = errors.Op("config.phaseTimeouts")
+	var errs errors.List
+	timeout, err := operationTimeout(config)
+	if err != nil {
+		errs = append(errs, err)
+		timeout = defaultTimeoutSeconds * time.Second
+	}
+	var timeouts engine.Timeouts
+	durations := []*time.Duration{
+		&timeouts.Git,
+		&timeouts.Pull,
+		&timeouts.Build,
+		&timeouts.PreRun,
+		&timeouts.Start,
+		&timeouts.AppDownload,
+	}
+	for i, p := range config.Timeouts.phases() {
+		d, err := timeoutDuration(op, config, "timeouts."+p.name, *p.seconds, p.max, timeout)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		*durations[i] = d
+	}
+	if len(errs) > 0 {
+		return timeouts, errs
+	}
+	return timeouts, nil
+}
@@ -0,0 +1,120 @@
+package config_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TouchBistro/tb/config"
+	"github.com/matryer/is"
+)
+
+func TestParseTimeouts(t *testing.T) {
+	is := is.New(t)
+	timeouts, err := config.ParseTimeouts(map[string]int{"build": 3600, "preRun": 900, "appDownload": 600})
+	is.NoErr(err)
+	is.Equal(timeouts, config.Timeouts{Build: 3600, PreRun: 900, AppDownload: 600})
+
+	_, err = config.ParseTimeouts(map[string]int{"builds": 3600})
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), `unknown timeout phase "builds"`))
+}
+
+func TestLoadTimeouts(t *testing.T) {
+	is := is.New(t)
+	tmpdir := t.TempDir()
+	homedir := filepath.Join(tmpdir, "home")
+	projectdir := filepath.Join(tmpdir, "project")
+	writeFiles(t, map[string]string{
+		filepath.Join(homedir, ".tbrc.yml"): `timeoutSeconds: 300
+timeouts:
+  build: 3600
+  pull: 600
+`,
+		filepath.Join(projectdir, "tb.yml"): `timeouts:
+  pull: 900
+  preRun: 1200
+`,
+	})
+
+	// Phases are merged individually
+	cfg, err := config.Load(homedir, projectdir)
+	is.NoErr(err)
+	is.Equal(cfg.Timeouts, config.Timeouts{Build: 3600, Pull: 900, PreRun: 1200})
+
+	// Settings take precedence but only for the phases they set
+	cfg = config.Apply(cfg, config.Settings{Timeouts: config.Timeouts{Build: 1800}})
+	is.Equal(cfg.Timeouts, config.Timeouts{Build: 1800, Pull: 900, PreRun: 1200})
+
+	// Phases that are not set use timeoutSeconds
+	cfg = config.WithDefaults(cfg)
+	is.Equal(cfg.Timeouts, config.Timeouts{
+		Git:         300,
+		Pull:        900,
+		Build:       1800,
+		PreRun:      1200,
+		Start:       300,
+		AppDownload: 300,
+	})
+}
+
+func TestTimeoutBounds(t *testing.T) {
+	tmpdir := t.TempDir()
+	homedir := filepath.Join(tmpdir, "home")
+	tbrcPath := filepath.Join(homedir, ".tbrc.yml")
+	writeFiles(t, map[string]string{
+		tbrcPath: `registries:
+  - name: TouchBistro/tb-registry
+timeouts:
+  build: 7200
+  pull: 99999
+`,
+	})
+	t.Setenv(config.EnvTimeouts, "start=4000")
+
+	tests := []struct {
+		name     string
+		settings config.Settings
+		wantErrs []string
+	}{
+		{
+			name: "config file and env",
+			wantErrs: []string{
+				"invalid timeouts.pull value 99999 in " + tbrcPath + ", must be between 5 and 7200 inclusive",
+				"invalid timeouts.start value 4000 in TB_TIMEOUTS, must be between 5 and 3600 inclusive",
+			},
+		},
+		{
+			name: "flags",
+			settings: config.Settings{
+				TimeoutSeconds: intPtr(1),
+				Timeouts:       config.Timeouts{Build: 20000},
+				FromFlags:      true,
+			},
+			wantErrs: []string{
+				"invalid timeoutSeconds value 1 in --timeout-seconds, must be between 5 and 3600 inclusive",
+				"invalid timeouts.build value 20000 in --timeouts, must be between 5 and 14400 inclusive",
+				"invalid timeouts.pull value 99999 in " + tbrcPath,
+				"invalid timeouts.start value 4000 in TB_TIMEOUTS",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			cfg, err := config.Load(homedir, homedir)
+			is.NoErr(err)
+			err = config.Check(config.Apply(cfg, tt.settings), config.CheckOptions{})
+			is.True(err != nil)
+			for _, want := range tt.wantErrs {
+				is.True(strings.Contains(err.Error(), want)) // missing error
+			}
+			// build is allowed to exceed an hour
+			is.True(!strings.Contains(err.Error(), "timeouts.build value 7200"))
+		})
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
@@ -101,7 +101,7 @@ func (e *Engine) AppiOSRun(ctx context.Context, appName string, opts AppiOSRunOp
 	// Download the app
 	appPath, err := progress.RunT(ctx, progress.RunOptions{
 		Message: fmt.Sprintf("Downloading iOS app %s", a.FullName()),
-		Timeout: e.timeout,
+		Timeout: e.timeouts.AppDownload,
 	}, func(ctx context.Context) (string, error) {
 		return e.downloadApp(ctx, a, app.TypeiOS, op)
 	})
@@ -250,7 +250,7 @@ func (e *Engine) AppDesktopRun(ctx context.Context, appName string, opts AppDesk
 	// Download the app
 	appPath, err := progress.RunT(ctx, progress.RunOptions{
 		Message: fmt.Sprintf("Downloading Desktop app %s", a.FullName()),
-		Timeout: e.timeout,
+		Timeout: e.timeouts.AppDownload,
 	}, func(ctx context.Context) (string, error) {
 		return e.downloadApp(ctx, a, app.TypeDesktop, op)
 	})
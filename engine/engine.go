@@ -31,6 +31,7 @@ type Engine struct {
 	concurrency      int
 	gitConcurrency   int
 	timeout          time.Duration
+	timeouts         Timeouts
 
 	gitClient        git.Git
 	dockerClient     *docker.Docker
@@ -80,6 +81,37 @@ type Options struct {
 	// Timeout is a limit to how long an operation will last
 	// If no value is provided, it defaults to 3600
 	Timeout time.Duration
+	// Timeouts sets the limit for each phase of an operation.
+	// Any phase that is not set uses Timeout.
+	Timeouts Timeouts
+}
+
+// Timeouts contains the limits for how long each phase of an operation can last.
+type Timeouts struct {
+	// Git is the timeout for cloning and pulling git repos.
+	Git time.Duration
+	// Pull is the timeout for pulling docker images.
+	Pull time.Duration
+	// Build is the timeout for building docker images. Services can set their own build
+	// timeout, in which case they are built separately.
+	Build time.Duration
+	// PreRun is the timeout for the pre-run step of each service, unless the service
+	// sets its own pre-run timeout.
+	PreRun time.Duration
+	// Start is the timeout for starting services.
+	Start time.Duration
+	// AppDownload is the timeout for downloading an app build.
+	AppDownload time.Duration
+}
+
+// withDefault returns t with any timeout that is not set replaced with timeout.
+func (t Timeouts) withDefault(timeout time.Duration) Timeouts {
+	for _, d := range []*time.Duration{&t.Git, &t.Pull, &t.Build, &t.PreRun, &t.Start, &t.AppDownload} {
+		if *d == 0 {
+			*d = timeout
+		}
+	}
+	return t
 }
 
 // New creates a new Engine instance.
@@ -123,6 +155,7 @@ func New(opts Options) (*Engine, error) {
 		loginStrategies:  opts.LoginStrategies,
 		deviceList:       opts.DeviceList,
 		timeout:          opts.Timeout,
+		timeouts:         opts.Timeouts.withDefault(opts.Timeout),
 		concurrency:      opts.Concurrency,
 		gitConcurrency:   opts.GitConcurrency,
 		gitClient:        opts.GitClient,
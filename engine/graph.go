@@ -0,0 +1,427 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/TouchBistro/goutils/errors"
+	"github.com/TouchBistro/tb/errkind"
+	"github.com/TouchBistro/tb/resource"
+)
+
+// GraphNodeKind is the kind of resource a GraphNode represents.
+type GraphNodeKind string
+
+const (
+	GraphNodeService  GraphNodeKind = "service"
+	GraphNodePlaylist GraphNodeKind = "playlist"
+)
+
+// GraphEdgeKind is the kind of relationship a GraphEdge represents.
+type GraphEdgeKind string
+
+const (
+	GraphEdgeDependency GraphEdgeKind = "dependency" // A service depends on another service.
+	GraphEdgeExtends    GraphEdgeKind = "extends"    // A playlist extends another playlist.
+	GraphEdgeContains   GraphEdgeKind = "contains"   // A playlist contains a service.
+)
+
+// GraphNode is a service or playlist in a Graph.
+type GraphNode struct {
+	// ID uniquely identifies the node in the graph.
+	ID   string
+	Kind GraphNodeKind
+	// Name is the full name of the resource. If the resource is missing,
+	// it is the name that was used to reference it.
+	Name string
+	// Missing is true if the resource does not exist.
+	Missing bool
+	// InCycle is true if the node is part of a cycle.
+	InCycle bool
+}
+
+// GraphEdge is a relationship between two nodes in a Graph.
+type GraphEdge struct {
+	From, To string // IDs of the nodes.
+	Kind     GraphEdgeKind
+	// InCycle is true if the edge is part of a cycle.
+	InCycle bool
+}
+
+// Graph is a dependency graph of services and playlists produced by Graph.
+// Nodes and Edges are sorted so that the graph is stable.
+type Graph struct {
+	// Roots are the IDs of the nodes the graph was built from.
+	Roots []string
+	Nodes []GraphNode
+	Edges []GraphEdge
+}
+
+// GraphOptions customizes the behaviour of Graph.
+type GraphOptions struct {
+	// Name is the name of a service or playlist to build the graph from.
+	// If it is empty, the graph contains all services.
+	Name string
+}
+
+// Graph builds the dependency graph of a service or playlist. The graph follows the dependencies
+// of services and the extends chains of playlists. Cycles and references to services or playlists
+// that do not exist are recorded in the graph instead of being treated as errors.
+func (e *Engine) Graph(opts GraphOptions) (*Graph, error) {
+	const op = errors.Op("engine.Engine.Graph")
+	gb := graphBuilder{e: e, nodes: make(map[string]*GraphNode), containerNames: e.containerNames()}
+
+	if opts.Name == "" {
+		for it := e.services.Iter(); it.Next(); {
+			gb.roots = append(gb.roots, gb.addService(it.Value().FullName()))
+		}
+		sort.Strings(gb.roots)
+		return gb.build(), nil
+	}
+
+	s, serr := e.services.Lookup(opts.Name)
+	p, perr := e.playlists.Lookup(opts.Name)
+	switch {
+	case serr == nil && perr == nil:
+		msg := fmt.Sprintf("%s is ambiguous, it matches both service %s and playlist %s", opts.Name, s.FullName(), p.FullName())
+		return nil, errors.New(errkind.Invalid, msg, op)
+	case serr == nil:
+		gb.roots = append(gb.roots, gb.addService(s.FullName()))
+	case perr == nil:
+		gb.roots = append(gb.roots, gb.addPlaylist(p.FullName()))
+	case errors.Is(serr, resource.ErrMultipleResources):
+		return nil, errors.Wrap(serr, errors.Meta{Reason: "unable to resolve service", Op: op})
+	case errors.Is(perr, resource.ErrMultipleResources):
+		return nil, errors.Wrap(perr, errors.Meta{Reason: "unable to resolve playlist", Op: op})
+	default:
+		suggestions := append(e.services.Suggestions(opts.Name), e.playlists.Suggestions(opts.Name)...)
+		return nil, errors.Wrap(&resource.LookupError{Err: resource.ErrNotFound, Suggestions: suggestions}, errors.Meta{
+			Kind:   errkind.Invalid,
+			Reason: fmt.Sprintf("no service or playlist named %s", opts.Name),
+			Op:     op,
+		})
+	}
+	return gb.build(), nil
+}
+
+// graphBuilder is used to incrementally build a Graph.
+type graphBuilder struct {
+	e     *Engine
+	roots []string
+	nodes map[string]*GraphNode
+	edges []GraphEdge
+	// containerNames maps container names to service full names.
+	// Dependencies are expanded to container names when registries are read.
+	containerNames map[string]string
+}
+
+// addService adds the service with fullName and all its dependencies to the graph.
+// It returns the ID of the service's node.
+func (gb *graphBuilder) addService(fullName string) string {
+	id := "service:" + fullName
+	if _, ok := gb.nodes[id]; ok {
+		return id
+	}
+	s, err := gb.e.services.Lookup(fullName)
+	gb.nodes[id] = &GraphNode{ID: id, Kind: GraphNodeService, Name: fullName, Missing: err != nil}
+	if err != nil {
+		return id
+	}
+	for _, dep := range s.Dependencies {
+		var depID string
+		if name, ok := gb.containerNames[dep]; ok {
+			depID = gb.addService(name)
+		} else {
+			// Not the container of any known service, record it under the name it was referenced by.
+			depID = "service:" + dep
+			gb.nodes[depID] = &GraphNode{ID: depID, Kind: GraphNodeService, Name: dep, Missing: true}
+		}
+		gb.edges = append(gb.edges, GraphEdge{From: id, To: depID, Kind: GraphEdgeDependency})
+	}
+	return id
+}
+
+// addPlaylist adds the playlist with name, the playlists it extends, and all its services to the graph.
+// It returns the ID of the playlist's node.
+func (gb *graphBuilder) addPlaylist(name string) string {
+	id := "playlist:" + name
+	if _, ok := gb.nodes[id]; ok {
+		return id
+	}
+	p, err := gb.e.playlists.Lookup(name)
+	gb.nodes[id] = &GraphNode{ID: id, Kind: GraphNodePlaylist, Name: name, Missing: err != nil}
+	if err != nil {
+		return id
+	}
+	for _, parent := range p.Extends {
+		gb.edges = append(gb.edges, GraphEdge{From: id, To: gb.addPlaylist(parent), Kind: GraphEdgeExtends})
+	}
+	for _, sn := range p.Services {
+		if s, err := gb.e.services.Lookup(sn); err == nil {
+			sn = s.FullName()
+		}
+		gb.edges = append(gb.edges, GraphEdge{From: id, To: gb.addService(sn), Kind: GraphEdgeContains})
+	}
+	return id
+}
+
+// build finds all cycles and returns the sorted graph.
+func (gb *graphBuilder) build() *Graph {
+	g := &Graph{Roots: gb.roots}
+	for _, n := range gb.nodes {
+		g.Nodes = append(g.Nodes, *n)
+	}
+	g.Edges = gb.edges
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].ID < g.Nodes[j].ID })
+	sort.SliceStable(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+
+	// A node is in a cycle if it is in a strongly connected component with other nodes,
+	// or if it has an edge to itself. An edge is in a cycle if both nodes are in the same component.
+	components := stronglyConnectedComponents(g)
+	for i := range g.Edges {
+		edge := &g.Edges[i]
+		if components[edge.From] != components[edge.To] {
+			continue
+		}
+		edge.InCycle = true
+		for j := range g.Nodes {
+			if g.Nodes[j].ID == edge.From || g.Nodes[j].ID == edge.To {
+				g.Nodes[j].InCycle = true
+			}
+		}
+	}
+	return g
+}
+
+// HasCycle reports whether the graph contains any cycles.
+func (g *Graph) HasCycle() bool {
+	for _, n := range g.Nodes {
+		if n.InCycle {
+			return true
+		}
+	}
+	return false
+}
+
+// HasMissing reports whether the graph references any services or playlists that do not exist.
+func (g *Graph) HasMissing() bool {
+	for _, n := range g.Nodes {
+		if n.Missing {
+			return true
+		}
+	}
+	return false
+}
+
+// node returns the node with the given ID.
+func (g *Graph) node(id string) GraphNode {
+	i := sort.Search(len(g.Nodes), func(i int) bool { return g.Nodes[i].ID >= id })
+	return g.Nodes[i]
+}
+
+// DOT returns the graph in the Graphviz DOT language.
+// Missing nodes are drawn dashed and cycles are drawn in red.
+func (g *Graph) DOT() string {
+	var sb strings.Builder
+	sb.WriteString("digraph tb {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	for _, n := range g.Nodes {
+		attrs := []string{fmt.Sprintf("label=%q", nodeLabel(n))}
+		if n.Kind == GraphNodePlaylist {
+			attrs = append(attrs, "shape=folder")
+		} else {
+			attrs = append(attrs, "shape=box")
+		}
+		if n.Missing {
+			attrs = append(attrs, "style=dashed")
+		}
+		if n.InCycle || n.Missing {
+			attrs = append(attrs, "color=red")
+		}
+		fmt.Fprintf(&sb, "  %q [%s];\n", n.ID, strings.Join(attrs, ", "))
+	}
+	for _, edge := range g.Edges {
+		var attrs []string
+		if edge.Kind != GraphEdgeDependency {
+			attrs = append(attrs, fmt.Sprintf("label=%q", edge.Kind))
+		}
+		if edge.Kind == GraphEdgeExtends {
+			attrs = append(attrs, "style=dashed")
+		}
+		if edge.InCycle {
+			attrs = append(attrs, "color=red")
+		}
+		fmt.Fprintf(&sb, "  %q -> %q", edge.From, edge.To)
+		if len(attrs) > 0 {
+			fmt.Fprintf(&sb, " [%s]", strings.Join(attrs, ", "))
+		}
+		sb.WriteString(";\n")
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// Mermaid returns the graph as a Mermaid flowchart.
+// Missing nodes and cycles are highlighted in red.
+func (g *Graph) Mermaid() string {
+	var sb strings.Builder
+	sb.WriteString("graph LR\n")
+	// Mermaid IDs can't contain most special characters so use the index instead.
+	ids := make(map[string]string, len(g.Nodes))
+	var missing, cycle []string
+	for i, n := range g.Nodes {
+		id := fmt.Sprintf("n%d", i)
+		ids[n.ID] = id
+		label := strings.ReplaceAll(nodeLabel(n), `"`, "#quot;")
+		if n.Kind == GraphNodePlaylist {
+			fmt.Fprintf(&sb, "  %s[/\"%s\"/]\n", id, label)
+		} else {
+			fmt.Fprintf(&sb, "  %s[\"%s\"]\n", id, label)
+		}
+		if n.Missing {
+			missing = append(missing, id)
+		} else if n.InCycle {
+			cycle = append(cycle, id)
+		}
+	}
+	var cycleEdges []string
+	for i, edge := range g.Edges {
+		switch edge.Kind {
+		case GraphEdgeDependency:
+			fmt.Fprintf(&sb, "  %s --> %s\n", ids[edge.From], ids[edge.To])
+		case GraphEdgeExtends:
+			fmt.Fprintf(&sb, "  %s -. extends .-> %s\n", ids[edge.From], ids[edge.To])
+		default:
+			fmt.Fprintf(&sb, "  %s -- %s --> %s\n", ids[edge.From], edge.Kind, ids[edge.To])
+		}
+		if edge.InCycle {
+			cycleEdges = append(cycleEdges, fmt.Sprint(i))
+		}
+	}
+	if len(missing) > 0 {
+		sb.WriteString("  classDef missing stroke:#c00,stroke-dasharray:5 5\n")
+		fmt.Fprintf(&sb, "  class %s missing\n", strings.Join(missing, ","))
+	}
+	if len(cycle) > 0 {
+		sb.WriteString("  classDef cycle stroke:#c00,stroke-width:2px\n")
+		fmt.Fprintf(&sb, "  class %s cycle\n", strings.Join(cycle, ","))
+	}
+	if len(cycleEdges) > 0 {
+		fmt.Fprintf(&sb, "  linkStyle %s stroke:#c00,stroke-width:2px\n", strings.Join(cycleEdges, ","))
+	}
+	return sb.String()
+}
+
+// Tree returns the graph as an ASCII tree starting from each root.
+// Nodes that have already been expanded are not expanded again.
+func (g *Graph) Tree() string {
+	children := make(map[string][]GraphEdge)
+	for _, edge := range g.Edges {
+		children[edge.From] = append(children[edge.From], edge)
+	}
+	var sb strings.Builder
+	expanded := make(map[string]bool)
+	onPath := make(map[string]bool)
+	var walk func(id, prefix string)
+	walk = func(id, prefix string) {
+		expanded[id] = true
+		onPath[id] = true
+		edges := children[id]
+		for i, edge := range edges {
+			branch, indent := "├── ", "│   "
+			if i == len(edges)-1 {
+				branch, indent = "└── ", "    "
+			}
+			n := g.node(edge.To)
+			sb.WriteString(prefix + branch + treeLabel(n, edge))
+			switch {
+			case onPath[edge.To]:
+				sb.WriteString(" (cycle)\n")
+			case expanded[edge.To] && len(children[edge.To]) > 0:
+				sb.WriteString(" (see above)\n")
+			default:
+				sb.WriteString("\n")
+				walk(edge.To, prefix+indent)
+			}
+		}
+		onPath[id] = false
+	}
+	for _, root := range g.Roots {
+		sb.WriteString(treeLabel(g.node(root), GraphEdge{}) + "\n")
+		walk(root, "")
+	}
+	return sb.String()
+}
+
+func nodeLabel(n GraphNode) string {
+	if n.Missing {
+		return n.Name + " (missing)"
+	}
+	return n.Name
+}
+
+func treeLabel(n GraphNode, edge GraphEdge) string {
+	label := nodeLabel(n)
+	if n.Kind == GraphNodePlaylist {
+		label = "playlist " + label
+	}
+	if edge.Kind == GraphEdgeExtends {
+		label = "extends " + label
+	}
+	return label
+}
+
+// stronglyConnectedComponents returns the strongly connected component of each node in g
+// using Tarjan's algorithm. Nodes in the same component have the same number.
+func stronglyConnectedComponents(g *Graph) map[string]int {
+	adj := make(map[string][]string)
+	for _, edge := range g.Edges {
+		adj[edge.From] = append(adj[edge.From], edge.To)
+	}
+	index := make(map[string]int)
+	lowlink := make(map[string]int)
+	onStack := make(map[string]bool)
+	components := make(map[string]int)
+	var stack []string
+	var next, component int
+	var connect func(v string)
+	connect = func(v string) {
+		index[v] = next
+		lowlink[v] = next
+		next++
+		stack = append(stack, v)
+		onStack[v] = true
+		for _, w := range adj[v] {
+			if _, ok := index[w]; !ok {
+				connect(w)
+				lowlink[v] = min(lowlink[v], lowlink[w])
+			} else if onStack[w] {
+				lowlink[v] = min(lowlink[v], index[w])
+			}
+		}
+		if lowlink[v] == index[v] {
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				components[w] = component
+				if w == v {
+					break
+				}
+			}
+			component++
+		}
+	}
+	for _, n := range g.Nodes {
+		if _, ok := index[n.ID]; !ok {
+			connect(n.ID)
+		}
+	}
+	return components
+}
@@ -0,0 +1,179 @@
+package engine_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/TouchBistro/tb/engine"
+	"github.com/TouchBistro/tb/resource"
+	"github.com/TouchBistro/tb/resource/playlist"
+	"github.com/TouchBistro/tb/resource/service"
+	"github.com/matryer/is"
+)
+
+func newGraphEngine(t *testing.T) *engine.Engine {
+	t.Helper()
+	services := []service.Service{
+		{Name: "postgres", RegistryName: "TouchBistro/tb-registry", Mode: service.ModeRemote},
+		{
+			Name:         "api",
+			RegistryName: "TouchBistro/tb-registry",
+			Mode:         service.ModeRemote,
+			// Dependencies are container names after being read from a registry
+			Dependencies: []string{"touchbistro-tb-registry-postgres", "touchbistro-tb-registry-redis"},
+		},
+		{
+			Name:         "a",
+			RegistryName: "TouchBistro/tb-registry",
+			Mode:         service.ModeRemote,
+			Dependencies: []string{"touchbistro-tb-registry-b"},
+		},
+		{
+			Name:         "b",
+			RegistryName: "TouchBistro/tb-registry",
+			Mode:         service.ModeRemote,
+			Dependencies: []string{"touchbistro-tb-registry-a"},
+		},
+	}
+	playlists := []playlist.Playlist{
+		{
+			Name:         "db",
+			RegistryName: "TouchBistro/tb-registry",
+			Services:     []string{"TouchBistro/tb-registry/postgres"},
+		},
+		{
+			Name:         "backend",
+			RegistryName: "TouchBistro/tb-registry",
+			Extends:      playlist.NameList{"TouchBistro/tb-registry/db"},
+			Services:     []string{"TouchBistro/tb-registry/api", "TouchBistro/tb-registry/missing"},
+		},
+		{
+			Name:         "loop-1",
+			RegistryName: "TouchBistro/tb-registry",
+			Extends:      playlist.NameList{"TouchBistro/tb-registry/loop-2"},
+		},
+		{
+			Name:         "loop-2",
+			RegistryName: "TouchBistro/tb-registry",
+			Extends:      playlist.NameList{"TouchBistro/tb-registry/loop-1"},
+		},
+	}
+	return newEngine(t, engine.Options{
+		Services:  newServiceCollection(t, services),
+		Playlists: newPlaylistCollection(t, playlists, []playlist.Playlist{}),
+	})
+}
+
+func TestGraphService(t *testing.T) {
+	is := is.New(t)
+	e := newGraphEngine(t)
+	g, err := e.Graph(engine.GraphOptions{Name: "api"})
+	is.NoErr(err)
+	is.Equal(g.Roots, []string{"service:TouchBistro/tb-registry/api"})
+	is.Equal(g.Nodes, []engine.GraphNode{
+		{ID: "service:TouchBistro/tb-registry/api", Kind: engine.GraphNodeService, Name: "TouchBistro/tb-registry/api"},
+		{ID: "service:TouchBistro/tb-registry/postgres", Kind: engine.GraphNodeService, Name: "TouchBistro/tb-registry/postgres"},
+		{ID: "service:touchbistro-tb-registry-redis", Kind: engine.GraphNodeService, Name: "touchbistro-tb-registry-redis", Missing: true},
+	})
+	is.Equal(g.Edges, []engine.GraphEdge{
+		{From: "service:TouchBistro/tb-registry/api", To: "service:TouchBistro/tb-registry/postgres", Kind: engine.GraphEdgeDependency},
+		{From: "service:TouchBistro/tb-registry/api", To: "service:touchbistro-tb-registry-redis", Kind: engine.GraphEdgeDependency},
+	})
+	is.True(g.HasMissing())
+	is.True(!g.HasCycle())
+	is.Equal(g.Tree(), `TouchBistro/tb-registry/api
+├── TouchBistro/tb-registry/postgres
+└── touchbistro-tb-registry-redis (missing)
+`)
+}
+
+func TestGraphServiceCycle(t *testing.T) {
+	is := is.New(t)
+	e := newGraphEngine(t)
+	g, err := e.Graph(engine.GraphOptions{Name: "TouchBistro/tb-registry/a"})
+	is.NoErr(err)
+	is.True(g.HasCycle())
+	for _, n := range g.Nodes {
+		is.True(n.InCycle)
+	}
+	is.Equal(g.Tree(), `TouchBistro/tb-registry/a
+└── TouchBistro/tb-registry/b
+    └── TouchBistro/tb-registry/a (cycle)
+`)
+	is.Equal(g.DOT(), `digraph tb {
+  rankdir=LR;
+  "service:TouchBistro/tb-registry/a" [label="TouchBistro/tb-registry/a", shape=box, color=red];
+  "service:TouchBistro/tb-registry/b" [label="TouchBistro/tb-registry/b", shape=box, color=red];
+  "service:TouchBistro/tb-registry/a" -> "service:TouchBistro/tb-registry/b" [color=red];
+  "service:TouchBistro/tb-registry/b" -> "service:TouchBistro/tb-registry/a" [color=red];
+}
+`)
+	is.Equal(g.Mermaid(), `graph LR
+  n0["TouchBistro/tb-registry/a"]
+  n1["TouchBistro/tb-registry/b"]
+  n0 --> n1
+  n1 --> n0
+  classDef cycle stroke:#c00,stroke-width:2px
+  class n0,n1 cycle
+  linkStyle 0,1 stroke:#c00,stroke-width:2px
+`)
+}
+
+func TestGraphPlaylist(t *testing.T) {
+	is := is.New(t)
+	e := newGraphEngine(t)
+	g, err := e.Graph(engine.GraphOptions{Name: "backend"})
+	is.NoErr(err)
+	is.True(g.HasMissing())
+	is.True(!g.HasCycle())
+	is.Equal(g.Tree(), `playlist TouchBistro/tb-registry/backend
+├── extends playlist TouchBistro/tb-registry/db
+│   └── TouchBistro/tb-registry/postgres
+├── TouchBistro/tb-registry/api
+│   ├── TouchBistro/tb-registry/postgres
+│   └── touchbistro-tb-registry-redis (missing)
+└── TouchBistro/tb-registry/missing (missing)
+`)
+
+	dot := g.DOT()
+	is.True(strings.Contains(dot, `"playlist:TouchBistro/tb-registry/backend" -> "playlist:TouchBistro/tb-registry/db" [label="extends", style=dashed];`))
+	is.True(strings.Contains(dot, `"service:TouchBistro/tb-registry/missing" [label="TouchBistro/tb-registry/missing (missing)", shape=box, style=dashed, color=red];`))
+	mermaid := g.Mermaid()
+	is.True(strings.Contains(mermaid, "-. extends .->"))
+	is.True(strings.Contains(mermaid, "classDef missing"))
+}
+
+func TestGraphPlaylistCycle(t *testing.T) {
+	is := is.New(t)
+	e := newGraphEngine(t)
+	g, err := e.Graph(engine.GraphOptions{Name: "loop-1"})
+	is.NoErr(err)
+	is.True(g.HasCycle())
+	is.Equal(g.Tree(), `playlist TouchBistro/tb-registry/loop-1
+└── extends playlist TouchBistro/tb-registry/loop-2
+    └── extends playlist TouchBistro/tb-registry/loop-1 (cycle)
+`)
+}
+
+func TestGraphAll(t *testing.T) {
+	is := is.New(t)
+	e := newGraphEngine(t)
+	g, err := e.Graph(engine.GraphOptions{})
+	is.NoErr(err)
+	is.Equal(g.Roots, []string{
+		"service:TouchBistro/tb-registry/a",
+		"service:TouchBistro/tb-registry/api",
+		"service:TouchBistro/tb-registry/b",
+		"service:TouchBistro/tb-registry/postgres",
+	})
+}
+
+func TestGraphNotFound(t *testing.T) {
+	is := is.New(t)
+	e := newGraphEngine(t)
+	_, err := e.Graph(engine.GraphOptions{Name: "nope"})
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "no service or playlist named nope"))
+	is.True(errors.Is(err, resource.ErrNotFound))
+}
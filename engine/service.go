@@ -6,8 +6,14 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/TouchBistro/goutils/errors"
 	"github.com/TouchBistro/goutils/file"
@@ -15,6 +21,9 @@ import (
 	"github.com/TouchBistro/tb/errkind"
 	"github.com/TouchBistro/tb/integrations/docker"
 	"github.com/TouchBistro/tb/integrations/login"
+	"github.com/TouchBistro/tb/internal/util"
+	"github.com/TouchBistro/tb/resource"
+	"github.com/TouchBistro/tb/resource/playlist"
 	"github.com/TouchBistro/tb/resource/service"
 	"gopkg.in/yaml.v3"
 )
@@ -31,12 +40,199 @@ func (e *Engine) ResolveService(serviceName string) (service.Service, error) {
 	return s, nil
 }
 
+// ResolveServiceNames checks that each name in serviceNames is a known service and returns the names
+// so they can be stored, for example in a custom playlist. Patterns are expanded to the full names of
+// the services they match. Names are returned as is unless they are ambiguous or a deprecated service
+// was replaced, in which case the full name of the resolved service is returned.
+func (e *Engine) ResolveServiceNames(serviceNames []string) ([]string, error) {
+	const op = errors.Op("engine.Engine.ResolveServiceNames")
+	names, err := e.expandServiceNames(serviceNames)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.Meta{Reason: "unable to resolve services", Op: op})
+	}
+	for i, name := range names {
+		s, err := e.services.Get(name)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.Meta{Reason: "unable to resolve service", Op: op})
+		}
+		if r, err := e.services.Lookup(name); err != nil || r.FullName() != s.FullName() {
+			names[i] = s.FullName()
+		}
+	}
+	return names, nil
+}
+
+// RunningServices returns the full names of all services that currently have a running container, sorted by name.
+func (e *Engine) RunningServices(ctx context.Context) ([]string, error) {
+	const op = errors.Op("engine.Engine.RunningServices")
+	containers, err := e.dockerClient.RunningContainerNames(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.Meta{Reason: "unable to list running services", Op: op})
+	}
+	containerNames := e.containerNames()
+	var serviceNames []string
+	for _, c := range containers {
+		// Ignore containers for services that are no longer in any registry.
+		if fullName, ok := containerNames[c]; ok {
+			serviceNames = append(serviceNames, fullName)
+		}
+	}
+	sort.Strings(serviceNames)
+	return util.UniqueStrings(serviceNames), nil
+}
+
+// ServiceInfo contains details about a service produced by Info.
+type ServiceInfo struct {
+	Service service.Service
+	// RepoPath is the path where the repo of the service is cloned.
+	// It is empty if the service has no repo.
+	RepoPath string
+	// Dependencies contains the full names of the services the service depends on.
+	// Dependencies that are not known services are left as is.
+	Dependencies []string
+	// Playlists contains the names of the playlists that include the service, either directly
+	// or through extends. Custom playlists are included.
+	Playlists []string
+}
+
+// Info returns details about the service with serviceName.
+func (e *Engine) Info(serviceName string) (ServiceInfo, error) {
+	const op = errors.Op("engine.Engine.Info")
+	s, err := e.services.Get(serviceName)
+	if err != nil {
+		return ServiceInfo{}, errors.Wrap(err, errors.Meta{Reason: "unable to resolve service", Op: op})
+	}
+	info := ServiceInfo{Service: s}
+	if s.HasGitRepo() {
+		info.RepoPath = filepath.Join(e.workdir, reposDir, s.GitRepo.Name)
+	}
+	containerNames := e.containerNames()
+	for _, dep := range s.Dependencies {
+		if fullName, ok := containerNames[dep]; ok {
+			dep = fullName
+		}
+		info.Dependencies = append(info.Dependencies, dep)
+	}
+
+	playlistNames := append(e.playlists.Names(), e.playlists.CustomNames()...)
+	sort.Strings(playlistNames)
+	for _, pn := range playlistNames {
+		serviceNames, err := e.playlists.ServiceNames(pn)
+		if err != nil {
+			// Broken playlists can't include the service, don't let them prevent showing info.
+			continue
+		}
+		for _, sn := range serviceNames {
+			// Custom playlists may use short names so resolve each service to compare.
+			if ps, err := e.services.Lookup(sn); err == nil && ps.FullName() == s.FullName() {
+				info.Playlists = append(info.Playlists, pn)
+				break
+			}
+		}
+	}
+	return info, nil
+}
+
+// ServiceURL returns the URL of the endpoint named endpoint of the running service with serviceName.
+// If endpoint is empty, the service must have exactly one URL which will be used.
+//
+// The port of the URL is replaced with the port that is actually published by the service's
+// container, so the URL is correct even if the port was remapped.
+func (e *Engine) ServiceURL(ctx context.Context, serviceName, endpoint string) (string, error) {
+	const op = errors.Op("engine.Engine.ServiceURL")
+	s, err := e.services.Get(serviceName)
+	if err != nil {
+		return "", errors.Wrap(err, errors.Meta{Reason: "unable to resolve service", Op: op})
+	}
+	names := make([]string, 0, len(s.URLs))
+	for n := range s.URLs {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return "", errors.New(errkind.Invalid, fmt.Sprintf("service %s has no urls", s.FullName()), op)
+	}
+	if endpoint == "" {
+		if len(names) > 1 {
+			msg := fmt.Sprintf("service %s has multiple urls, specify one of: %s", s.FullName(), strings.Join(names, ", "))
+			return "", errors.New(errkind.Invalid, msg, op)
+		}
+		endpoint = names[0]
+	}
+	rawURL, ok := s.URLs[endpoint]
+	if !ok {
+		msg := fmt.Sprintf("service %s has no url named %s, available urls: %s", s.FullName(), endpoint, strings.Join(names, ", "))
+		return "", errors.New(errkind.Invalid, msg, op)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", errors.Wrap(err, errors.Meta{
+			Kind:   errkind.Invalid,
+			Reason: fmt.Sprintf("invalid url %s for service %s", endpoint, s.FullName()),
+			Op:     op,
+		})
+	}
+
+	// The port in the URL is the host port from the service config, find the container port
+	// it maps to so the port actually published by the container can be looked up.
+	hostPort := u.Port()
+	if hostPort == "" {
+		return rawURL, nil
+	}
+	containerPort := hostPort
+	for _, p := range s.Ports {
+		host, container := parsePortMapping(p)
+		if host == hostPort || (host == "" && container == hostPort) {
+			containerPort = container
+			break
+		}
+	}
+	port, err := strconv.ParseUint(containerPort, 10, 16)
+	if err != nil {
+		msg := fmt.Sprintf("invalid port %s in url %s for service %s", containerPort, endpoint, s.FullName())
+		return "", errors.New(errkind.Invalid, msg, op)
+	}
+	published, err := e.dockerClient.PublishedPort(ctx, s.FullName(), uint16(port))
+	if err != nil {
+		return "", errors.Wrap(err, errors.Meta{Reason: "unable to determine published port", Op: op})
+	}
+	u.Host = net.JoinHostPort(u.Hostname(), strconv.Itoa(int(published)))
+	return u.String(), nil
+}
+
+// parsePortMapping parses a port mapping in the compose format [[ip:]host:]container[/protocol]
+// and returns the host and container ports. host is empty if the port is not mapped to a specific host port.
+func parsePortMapping(mapping string) (host, container string) {
+	mapping, _, _ = strings.Cut(mapping, "/")
+	parts := strings.Split(mapping, ":")
+	container = parts[len(parts)-1]
+	if len(parts) > 1 {
+		host = parts[len(parts)-2]
+	}
+	return host, container
+}
+
+// containerNames returns a map of container names to the full names of the services they belong to.
+// Dependencies are expanded to container names when registries are read so this can be used to map them back.
+func (e *Engine) containerNames() map[string]string {
+	containerNames := make(map[string]string)
+	for it := e.services.Iter(); it.Next(); {
+		s := it.Value()
+		containerNames[docker.NormalizeName(s.FullName())] = s.FullName()
+	}
+	return containerNames
+}
+
 // UpOptions customizes the behaviour of Up.
 type UpOptions struct {
 	// ServiceNames is a list of services names to start.
 	ServiceNames []string
 	// ServiceTags is a map of service:image-tag to start.
 	ServiceTags map[string]string
+	// Overrides are applied to the services for this run only. The keys are service names
+	// or patterns and are matched against the services being started. Overrides are applied
+	// after any playlist overrides and before ServiceTags.
+	Overrides map[string]service.ServiceOverride
 	// PlaylistName is the name of a playlist to start.
 	PlaylistName string
 	// SkipPreRun skips running the pre-run step for services.
@@ -67,7 +263,7 @@ type UpOptions struct {
 // which services to start.
 func (e *Engine) Up(ctx context.Context, opts UpOptions) error {
 	const op = errors.Op("engine.Engine.Up")
-	services, err := e.resolveServices(op, opts.ServiceNames, opts.PlaylistName, opts.ServiceTags, true)
+	services, err := e.resolveServices(ctx, op, opts.ServiceNames, opts.PlaylistName, opts.Overrides, opts.ServiceTags, true)
 	if err != nil {
 		return err
 	}
@@ -131,7 +327,7 @@ func (e *Engine) Up(ctx context.Context, opts UpOptions) error {
 			Message:     "Pulling docker base images",
 			Count:       len(e.baseImages),
 			Concurrency: e.concurrency,
-			Timeout:     e.timeout,
+			Timeout:     e.timeouts.Pull,
 		}, func(ctx context.Context, i int) error {
 			img := e.baseImages[i]
 			if err := e.dockerClient.PullImage(ctx, img); err != nil {
@@ -159,7 +355,7 @@ func (e *Engine) Up(ctx context.Context, opts UpOptions) error {
 				Message:     "Pulling docker service images",
 				Count:       len(images),
 				Concurrency: e.concurrency,
-				Timeout:     e.timeout,
+				Timeout:     e.timeouts.Pull,
 			}, func(ctx context.Context, i int) error {
 				img := images[i]
 				if err := e.dockerClient.PullImage(ctx, img); err != nil {
@@ -176,22 +372,45 @@ func (e *Engine) Up(ctx context.Context, opts UpOptions) error {
 	}
 
 	// Build necessary services
-	var buildServices []string
+	// Services with their own build timeout are built separately so that one long build
+	// does not require a long timeout for all the others.
+	type buildGroup struct {
+		message      string
+		serviceNames []string
+		timeout      time.Duration
+	}
+	builds := []buildGroup{{message: "Building docker images for services", timeout: e.timeouts.Build}}
 	for _, s := range services {
-		if s.Mode == service.ModeBuild {
-			buildServices = append(buildServices, s.FullName())
+		if s.Mode != service.ModeBuild {
+			continue
+		}
+		if s.Timeouts.Build == 0 {
+			builds[0].serviceNames = append(builds[0].serviceNames, s.FullName())
+			continue
 		}
+		builds = append(builds, buildGroup{
+			message:      fmt.Sprintf("Building docker image for %s", s.FullName()),
+			serviceNames: []string{s.FullName()},
+			timeout:      time.Duration(s.Timeouts.Build) * time.Second,
+		})
 	}
-	if len(buildServices) > 0 {
+	var built bool
+	for _, b := range builds {
+		if len(b.serviceNames) == 0 {
+			continue
+		}
 		err := progress.Run(ctx, progress.RunOptions{
-			Message: "Building docker images for services",
-			Timeout: e.timeout,
+			Message: b.message,
+			Timeout: b.timeout,
 		}, func(ctx context.Context) error {
-			return e.dockerClient.BuildServices(ctx, buildServices)
+			return e.dockerClient.BuildServices(ctx, b.serviceNames)
 		})
 		if err != nil {
 			return errors.Wrap(err, errors.Meta{Reason: "failed to build docker images for services", Op: op})
 		}
+		built = true
+	}
+	if built {
 		tracker.Info("✔ Built docker service images")
 	}
 
@@ -200,40 +419,62 @@ func (e *Engine) Up(ctx context.Context, opts UpOptions) error {
 		// Do this serially since we had issues before when trying to do it in parallel.
 		// TODO(@cszatmary): Should scope what the deal was and see if we do these in parallel.
 		// We might need to rethink the whole way pre-run works.
-		err := progress.Run(ctx, progress.RunOptions{
-			Message: "Performing pre-run step for services (this may take a long time)",
-			Count:   len(services),
-			Timeout: e.timeout,
-		}, func(ctx context.Context) error {
-			for _, s := range services {
-				if s.PreRun == "" {
-					tracker.Debugf("No pre-run for %s, skipping", s.FullName())
-					tracker.Inc()
-					continue
-				}
+		// Each pre-run has its own timeout, so the step as a whole can take as long as all of them.
+		preRunTimeouts := make([]time.Duration, len(services))
+		var total time.Duration
+		hasPreRun := false
+		for i, s := range services {
+			preRunTimeouts[i] = e.timeouts.PreRun
+			if s.Timeouts.PreRun > 0 {
+				preRunTimeouts[i] = time.Duration(s.Timeouts.PreRun) * time.Second
+			}
+			if s.PreRun != "" {
+				total += preRunTimeouts[i]
+				hasPreRun = true
+			}
+		}
+		if !hasPreRun {
+			// Nothing to run, skip the step instead of giving it an empty timeout.
+			tracker.Debug("No services have a pre-run, skipping pre-run step")
+		} else {
+			err := progress.Run(ctx, progress.RunOptions{
+				Message: "Performing pre-run step for services (this may take a long time)",
+				Count:   len(services),
+				Timeout: total,
+			}, func(ctx context.Context) error {
+				for i, s := range services {
+					if s.PreRun == "" {
+						tracker.Debugf("No pre-run for %s, skipping", s.FullName())
+						tracker.Inc()
+						continue
+					}
 
-				tracker.Debugf("Running pre-run for %s", s.FullName())
-				if err := e.dockerClient.RunService(ctx, s.FullName(), s.PreRun); err != nil {
-					return errors.Wrap(err, errors.Meta{
-						Reason: fmt.Sprintf("failed to run pre-run command for %s", s.FullName()),
-						Op:     op,
-					})
+					tracker.Debugf("Running pre-run for %s", s.FullName())
+					preRunCtx, cancel := context.WithTimeout(ctx, preRunTimeouts[i])
+					err := e.dockerClient.RunService(preRunCtx, s.FullName(), s.PreRun)
+					cancel()
+					if err != nil {
+						return errors.Wrap(err, errors.Meta{
+							Reason: fmt.Sprintf("failed to run pre-run command for %s", s.FullName()),
+							Op:     op,
+						})
+					}
+					tracker.Debugf("Ran pre-run for %s", s.FullName())
+					tracker.Inc()
 				}
-				tracker.Debugf("Ran pre-run for %s", s.FullName())
-				tracker.Inc()
+				return nil
+			})
+			if err != nil {
+				return err
 			}
-			return nil
-		})
-		if err != nil {
-			return err
+			tracker.Info("✔ Performed pre-run step for services")
 		}
-		tracker.Info("✔ Performed pre-run step for services")
 	}
 
 	// Start services
 	err = progress.Run(ctx, progress.RunOptions{
 		Message: "Starting services in the background",
-		Timeout: e.timeout,
+		Timeout: e.timeouts.Start,
 	}, func(ctx context.Context) error {
 		return e.dockerClient.UpServices(ctx, getServiceNames(services))
 	})
@@ -253,7 +494,7 @@ type DownOptions struct {
 // Down stops services and removes the containers.
 func (e *Engine) Down(ctx context.Context, opts DownOptions) error {
 	const op = errors.Op("engine.Engine.Down")
-	services, err := e.resolveServices(op, opts.ServiceNames, "", make(map[string]string), false)
+	services, err := e.resolveServices(ctx, op, opts.ServiceNames, "", nil, nil, false)
 	if err != nil {
 		return err
 	}
@@ -284,7 +525,7 @@ type LogsOptions struct {
 // Logs retrieves the logs from one or more service containers and writes it to w.
 func (e *Engine) Logs(ctx context.Context, w io.Writer, opts LogsOptions) error {
 	const op = errors.Op("engine.Engine.Logs")
-	services, err := e.resolveServices(op, opts.ServiceNames, "", make(map[string]string), false)
+	services, err := e.resolveServices(ctx, op, opts.ServiceNames, "", nil, nil, false)
 	if err != nil {
 		return err
 	}
@@ -321,7 +562,7 @@ func (e *Engine) Exec(ctx context.Context, serviceName string, opts ExecOptions)
 	if len(opts.Cmd) == 0 {
 		panic("ExecOptions.Cmd must have at least one element")
 	}
-	s, err := e.services.Get(serviceName)
+	s, err := e.getService(serviceName)
 	if err != nil {
 		return -1, errors.Wrap(err, errors.Meta{Reason: "unable to resolve service", Op: op})
 	}
@@ -350,19 +591,31 @@ type ListResult struct {
 	Services        []string
 	Playlists       []PlaylistSummary
 	CustomPlaylists []PlaylistSummary
+	// DeprecatedServices maps the full name of each deprecated service in Services
+	// to its deprecation. It is nil if no services are deprecated.
+	DeprecatedServices map[string]*resource.Deprecation
 }
 
 // PlaylistSummary provides a summary of a playlist produced by List.
 type PlaylistSummary struct {
 	Name     string
 	Services []string
+	// Deprecated is set if the playlist is deprecated.
+	Deprecated *resource.Deprecation
 }
 
 func (e *Engine) List(opts ListOptions) ListResult {
 	var lr ListResult
 	if opts.ListServices {
 		for it := e.services.Iter(); it.Next(); {
-			lr.Services = append(lr.Services, it.Value().FullName())
+			s := it.Value()
+			lr.Services = append(lr.Services, s.FullName())
+			if s.Deprecated != nil {
+				if lr.DeprecatedServices == nil {
+					lr.DeprecatedServices = make(map[string]*resource.Deprecation)
+				}
+				lr.DeprecatedServices[s.FullName()] = s.Deprecated
+			}
 		}
 	}
 	if opts.ListPlaylists {
@@ -378,6 +631,9 @@ func (e *Engine) listPlaylists(names []string, tree bool) []PlaylistSummary {
 	var summaries []PlaylistSummary
 	for _, n := range names {
 		summary := PlaylistSummary{Name: n}
+		if p, err := e.playlists.Lookup(n); err == nil {
+			summary.Deprecated = p.Deprecated
+		}
 		if tree {
 			list, err := e.playlists.ServiceNames(n)
 			if err != nil {
@@ -391,6 +647,31 @@ func (e *Engine) listPlaylists(names []string, tree bool) []PlaylistSummary {
 	return summaries
 }
 
+// PlaylistInfo contains details about a playlist produced by Engine.PlaylistInfo.
+type PlaylistInfo struct {
+	Playlist playlist.Playlist
+	// Custom is true if the playlist is a custom playlist from the tbrc.
+	Custom bool
+	// Services contains all the services that are run by the playlist,
+	// including those from the playlists it extends.
+	Services []string
+}
+
+// PlaylistInfo returns details about the playlist with playlistName.
+func (e *Engine) PlaylistInfo(playlistName string) (PlaylistInfo, error) {
+	const op = errors.Op("engine.Engine.PlaylistInfo")
+	p, err := e.playlists.Get(playlistName)
+	if err != nil {
+		return PlaylistInfo{}, errors.Wrap(err, errors.Meta{Reason: "unable to resolve playlist", Op: op})
+	}
+	info := PlaylistInfo{Playlist: p, Custom: p.RegistryName == ""}
+	info.Services, err = e.playlists.ServiceNames(p.FullName())
+	if err != nil {
+		return PlaylistInfo{}, errors.Wrap(err, errors.Meta{Reason: "unable to resolve playlist services", Op: op})
+	}
+	return info, nil
+}
+
 // NukeOptions customizes the behaviour of Nuke.
 type NukeOptions struct {
 	// RemoveContainers specifies to remove all service containers.
@@ -562,6 +843,49 @@ func (e *Engine) nuke(ctx context.Context, opts NukeOptions, op errors.Op) error
 	return nil
 }
 
+// expandServiceNames expands any patterns in serviceNames to the full names of the services they match.
+// Names that are not patterns are left as is. Duplicates are removed while preserving order.
+func (e *Engine) expandServiceNames(serviceNames []string) ([]string, error) {
+	var names []string
+	for _, name := range serviceNames {
+		if !resource.IsPattern(name) {
+			names = append(names, name)
+			continue
+		}
+		matches, err := e.services.Match(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range matches {
+			names = append(names, s.FullName())
+		}
+	}
+	return util.UniqueStrings(names), nil
+}
+
+// getService is like e.services.Get but also accepts a pattern,
+// as long as it matches exactly one service.
+func (e *Engine) getService(name string) (service.Service, error) {
+	if !resource.IsPattern(name) {
+		return e.services.Get(name)
+	}
+	matches, err := e.services.Match(name)
+	if err != nil {
+		return service.Service{}, err
+	}
+	if len(matches) > 1 {
+		candidates := make([]string, len(matches))
+		for i, s := range matches {
+			candidates[i] = s.FullName()
+		}
+		return service.Service{}, errors.Wrap(&resource.LookupError{
+			Err:         resource.ErrMultipleResources,
+			Suggestions: candidates,
+		}, errors.Meta{Kind: errkind.Invalid, Reason: name})
+	}
+	return e.services.Get(matches[0].FullName())
+}
+
 // resolveServices resolves a list of services from either a list of service names or a playlist name.
 //
 // If both serviceNames and playlistName are provided, an error will be returned. Mixing service names
@@ -571,59 +895,54 @@ func (e *Engine) nuke(ctx context.Context, opts NukeOptions, op errors.Op) error
 // In this case, if requireOne is true, an error will be returned since at least one of serviceNames or playlistName
 // was required. Otherwise, both the returned slice and error will be nil, which can be treated as an empty slice
 // of services.
-func (e *Engine) resolveServices(op errors.Op, serviceNames []string, playlistName string, serviceTags map[string]string, requireOne bool) ([]service.Service, error) {
+//
+// overrides and serviceTags are applied to the resolved services, with serviceTags taking precedence.
+func (e *Engine) resolveServices(
+	ctx context.Context,
+	op errors.Op,
+	serviceNames []string,
+	playlistName string,
+	overrides map[string]service.ServiceOverride,
+	serviceTags map[string]string,
+	requireOne bool,
+) ([]service.Service, error) {
 	if len(serviceNames) > 0 && playlistName != "" {
 		return nil, errors.New(errkind.Invalid, "both service names and playlist name provided", op)
 	}
 	if len(serviceNames) > 0 {
-		for service, tag := range serviceTags {
-			_, err := e.services.Get(service)
-			if err != nil {
-				return nil, errors.Wrap(err, errors.Meta{Reason: fmt.Sprintf("unable to resolve service %s with tag %s", service, tag), Op: op})
-			}
+		serviceNames, err := e.expandServiceNames(serviceNames)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.Meta{Reason: "unable to resolve services", Op: op})
 		}
-
-		services := make([]service.Service, len(serviceNames))
-		for i, name := range serviceNames {
-			s, err := e.services.Get(name)
-			if err != nil {
-				return nil, errors.Wrap(err, errors.Meta{Reason: "unable to resolve service", Op: op})
-			}
-
-			services[i] = s
-			tag := serviceTags[s.Name]
-			if len(tag) > 0 {
-				override := service.ServiceOverride{
-					Mode: "remote",
-					Remote: service.RemoteOverride{
-						Tag: tag,
-					},
-				}
-				overridenService, err := service.Override(s, override)
-				if err != nil {
-					return nil, errors.Wrap(err, errors.Meta{Reason: fmt.Sprintf("failed to override service %s with tag %s", s.Name, tag), Op: op})
-				}
-
-				/* e.services is the global list of services parsed from the registry
-				 * we need to update its state with the given remote tag
-				 * since it's used downstream to generate the docker-compose config to tag the service
-				 */
-				if err := e.services.Set(overridenService); err != nil {
-					return nil, errors.Wrap(err, errors.Meta{Reason: fmt.Sprintf("failed to override service %s with tag %s", s.Name, tag), Op: op})
-				}
-
-				services[i] = overridenService
-			}
+		services, err := e.getServices(op, serviceNames)
+		if err != nil {
+			return nil, err
 		}
-		return services, nil
+		return e.overrideServices(ctx, op, services, overrides, serviceTags)
 	}
 	if playlistName != "" {
+		// Resolve the playlist first so that deprecated playlists are handled.
+		p, err := e.playlists.Get(playlistName)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.Meta{Reason: "unable to resolve playlist", Op: op})
+		}
+		if p.RegistryName != "" {
+			playlistName = p.FullName()
+		}
 		serviceNames, err := e.playlists.ServiceNames(playlistName)
 		if err != nil {
 			return nil, errors.Wrap(err, errors.Meta{Reason: "unable to resolve playlist", Op: op})
 		}
-		// Can just run resolveServices again with the service names to get the actual services.
-		return e.resolveServices(op, serviceNames, "", serviceTags, true)
+		services, err := e.getServices(op, serviceNames)
+		if err != nil {
+			return nil, err
+		}
+		// Apply the playlist overrides first so that the given overrides and tags take precedence.
+		services, err = e.applyOverrides(ctx, op, services, p.EnvVars, p.Overrides)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.Meta{Reason: fmt.Sprintf("unable to apply overrides of playlist %s", p.FullName()), Op: op})
+		}
+		return e.overrideServices(ctx, op, services, overrides, serviceTags)
 	}
 	if requireOne {
 		return nil, errors.New(errkind.Invalid, "neither service names nor playlist name was provided", op)
@@ -632,6 +951,154 @@ func (e *Engine) resolveServices(op errors.Op, serviceNames []string, playlistNa
 	return nil, nil
 }
 
+// getServices retrieves the services with the given names. This is the only place the names are
+// resolved, so any deprecation warnings or prompts for ambiguous names are only shown once.
+func (e *Engine) getServices(op errors.Op, serviceNames []string) ([]service.Service, error) {
+	services := make([]service.Service, len(serviceNames))
+	for i, name := range serviceNames {
+		s, err := e.services.Get(name)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.Meta{Reason: "unable to resolve service", Op: op})
+		}
+		services[i] = s
+	}
+	return services, nil
+}
+
+// overrideServices applies overrides and then serviceTags to services and returns the overridden services.
+func (e *Engine) overrideServices(
+	ctx context.Context,
+	op errors.Op,
+	services []service.Service,
+	overrides map[string]service.ServiceOverride,
+	serviceTags map[string]string,
+) ([]service.Service, error) {
+	services, err := e.applyOverrides(ctx, op, services, nil, overrides)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.Meta{Reason: "unable to apply overrides", Op: op})
+	}
+	for service, tag := range serviceTags {
+		_, err := e.services.Get(service)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.Meta{Reason: fmt.Sprintf("unable to resolve service %s with tag %s", service, tag), Op: op})
+		}
+	}
+
+	for i, s := range services {
+		tag := serviceTags[s.Name]
+		if len(tag) > 0 {
+			override := service.ServiceOverride{
+				Mode: "remote",
+				Remote: service.RemoteOverride{
+					Tag: tag,
+				},
+			}
+			overridenService, err := service.Override(s, override)
+			if err != nil {
+				return nil, errors.Wrap(err, errors.Meta{Reason: fmt.Sprintf("failed to override service %s with tag %s", s.Name, tag), Op: op})
+			}
+
+			/* e.services is the global list of services parsed from the registry
+			 * we need to update its state with the given remote tag
+			 * since it's used downstream to generate the docker-compose config to tag the service
+			 */
+			if err := e.services.Set(overridenService); err != nil {
+				return nil, errors.Wrap(err, errors.Meta{Reason: fmt.Sprintf("failed to override service %s with tag %s", s.Name, tag), Op: op})
+			}
+
+			services[i] = overridenService
+		}
+	}
+	return services, nil
+}
+
+// applyOverrides applies envVars and overrides to services, which must already be resolved,
+// and returns the overridden services in the same order.
+// The keys of overrides are service names or patterns which are only matched against services.
+// Keys that do not match any of the services are skipped with a warning, since playlists can
+// exclude services that an override refers to. A key for a deprecated service that is not in
+// services applies to its replacement, since that is what is run instead.
+// e.services is updated with the overridden services since it is used downstream to generate
+// the docker-compose config.
+func (e *Engine) applyOverrides(
+	ctx context.Context,
+	op errors.Op,
+	services []service.Service,
+	envVars map[string]string,
+	overrides map[string]service.ServiceOverride,
+) ([]service.Service, error) {
+	if len(envVars) == 0 && len(overrides) == 0 {
+		return services, nil
+	}
+	// Put the services in their own collection so that overrides can only match the given services.
+	var members resource.Collection[service.Service]
+	for _, s := range services {
+		if len(envVars) > 0 {
+			var err error
+			s, err = service.Override(s, service.ServiceOverride{EnvVars: envVars})
+			if err != nil {
+				return nil, errors.Wrap(err, errors.Meta{Op: op})
+			}
+		}
+		if err := members.Set(s); err != nil {
+			return nil, errors.Wrap(err, errors.Meta{Op: op})
+		}
+	}
+	tracker := progress.TrackerFromContext(ctx)
+	// Sort the keys so that overrides matching the same service are applied in a consistent order.
+	keys := make([]string, 0, len(overrides))
+	for k := range overrides {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		matches, err := members.Match(k)
+		if errors.Is(err, resource.ErrNotFound) && !resource.IsPattern(k) {
+			// The key may be a deprecated service that was replaced, match its replacement instead.
+			// Lookup is used since deprecations were already handled when services were resolved.
+			if s, lookupErr := e.services.Lookup(k); lookupErr == nil {
+				if d := resource.DeprecationOf(s); d != nil && d.Replacement != "" {
+					// Prefer a service in the same registry like Get does.
+					matches, err = members.Match(resource.FullName(s.RegistryName, d.Replacement))
+					if errors.Is(err, resource.ErrNotFound) {
+						matches, err = members.Match(d.Replacement)
+					}
+				}
+			}
+		}
+		if errors.Is(err, resource.ErrNotFound) {
+			tracker.Warnf("⚠️  Override %s does not match any of the services being run, skipping", k)
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, errors.Meta{Reason: fmt.Sprintf("invalid override %s", k), Op: op})
+		}
+		for _, s := range matches {
+			s, err := service.Override(s, overrides[k])
+			if err != nil {
+				return nil, errors.Wrap(err, errors.Meta{Op: op})
+			}
+			if err := members.Set(s); err != nil {
+				return nil, errors.Wrap(err, errors.Meta{Op: op})
+			}
+		}
+	}
+	overridden := make([]service.Service, len(services))
+	for i, s := range services {
+		s, err := members.Lookup(s.FullName())
+		if err != nil {
+			return nil, errors.Wrap(err, errors.Meta{Kind: errkind.Internal, Op: op})
+		}
+		overridden[i] = s
+	}
+	for it := members.Iter(); it.Next(); {
+		if err := e.services.Set(it.Value()); err != nil {
+			return nil, errors.Wrap(err, errors.Meta{Op: op})
+		}
+	}
+	return overridden, nil
+}
+
 // prepareGitRepos prepares the git repos for all services. Missing repos will always be cloned
 // to ensure that any files referenced in the docker-compose.yml file exist.
 // Repos will be pulled if skipPull is false.
@@ -703,7 +1170,7 @@ func (e *Engine) prepareGitRepos(ctx context.Context, op errors.Op, skipPull boo
 		Message:     "Cloning/pulling service git repos",
 		Count:       len(actions),
 		Concurrency: e.gitConcurrency,
-		Timeout:     e.timeout,
+		Timeout:     e.timeouts.Git,
 	}, func(ctx context.Context, i int) error {
 		a := actions[i]
 		if a.clone {
@@ -2,9 +2,13 @@ package engine_test
 
 import (
 	"context"
+	"errors"
+	"path/filepath"
 	"sort"
+	"strings"
 	"testing"
 
+	"github.com/TouchBistro/goutils/progress"
 	"github.com/TouchBistro/tb/engine"
 	"github.com/TouchBistro/tb/integrations/docker"
 	"github.com/TouchBistro/tb/integrations/git"
@@ -100,6 +104,49 @@ func TestDown(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "remove containers matching pattern",
+			existingContainers: []dockertypes.Container{
+				{
+					ID:    "32ce4d8d9c648dd5fce39cf48319da8d55b195513b6fe0cef4a425de9380590c",
+					Names: []string{"touchbistro-tb-registry-postgres"},
+					Labels: map[string]string{
+						docker.ProjectLabel: "tb",
+					},
+					State: docker.ContainerStateRunning,
+				},
+				{
+					ID:    "f4d2913f1010244b61940cf52845e6dbe5d687791ea185237efe9121adf15edd",
+					Names: []string{"touchbistro-tb-registry-touchbistro-node-boilerplate"},
+					Labels: map[string]string{
+						docker.ProjectLabel: "tb",
+					},
+					State: docker.ContainerStateRunning,
+				},
+				// Additional container not part of tb to make sure it is untouched.
+				{
+					ID:    "e8dc7c16f7dd4be23b96951a34b7ecc69cd727ed13a626a309a96b472646c5e9",
+					Names: []string{"test-ubuntu"},
+					State: docker.ContainerStateRunning,
+				},
+			},
+			serviceNames: []string{"touchbistro-*"},
+			remainingContainers: []dockertypes.Container{
+				{
+					ID:    "32ce4d8d9c648dd5fce39cf48319da8d55b195513b6fe0cef4a425de9380590c",
+					Names: []string{"touchbistro-tb-registry-postgres"},
+					Labels: map[string]string{
+						docker.ProjectLabel: "tb",
+					},
+					State: docker.ContainerStateRunning,
+				},
+				{
+					ID:    "e8dc7c16f7dd4be23b96951a34b7ecc69cd727ed13a626a309a96b472646c5e9",
+					Names: []string{"test-ubuntu"},
+					State: docker.ContainerStateRunning,
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -204,6 +251,201 @@ func TestList(t *testing.T) {
 	}
 }
 
+func TestListDeprecated(t *testing.T) {
+	is := is.New(t)
+	deprecation := &resource.Deprecation{Message: "use postgres instead", Replacement: "postgres"}
+	sc := newServiceCollection(t, []service.Service{
+		{
+			Name:         "old-postgres",
+			RegistryName: "TouchBistro/tb-registry",
+			Mode:         service.ModeRemote,
+			Remote:       service.Remote{Image: "postgres", Tag: "9"},
+			Deprecated:   deprecation,
+		},
+	})
+	pc := newPlaylistCollection(t, []playlist.Playlist{
+		{
+			Name:         "old-backend",
+			RegistryName: "TouchBistro/tb-registry",
+			Services:     []string{"TouchBistro/tb-registry/postgres"},
+			Deprecated:   deprecation,
+		},
+	}, []playlist.Playlist{})
+	e := newEngine(t, engine.Options{
+		Services:  sc,
+		Playlists: pc,
+	})
+
+	result := e.List(engine.ListOptions{ListServices: true, ListPlaylists: true})
+	is.Equal(result.DeprecatedServices, map[string]*resource.Deprecation{
+		"TouchBistro/tb-registry/old-postgres": deprecation,
+	})
+	is.Equal(result.Playlists, []engine.PlaylistSummary{
+		{Name: "TouchBistro/tb-registry/old-backend", Deprecated: deprecation},
+	})
+}
+
+func TestInfo(t *testing.T) {
+	is := is.New(t)
+	services := []service.Service{
+		{
+			Name:         "postgres",
+			RegistryName: "TouchBistro/tb-registry",
+			Mode:         service.ModeRemote,
+			Remote:       service.Remote{Image: "postgres", Tag: "12"},
+		},
+		{
+			Name:         "api",
+			RegistryName: "TouchBistro/tb-registry",
+			Mode:         service.ModeRemote,
+			Remote:       service.Remote{Image: "api"},
+			GitRepo:      service.GitRepo{Name: "TouchBistro/api"},
+			Dependencies: []string{"touchbistro-tb-registry-postgres", "external-db"},
+			Description:  "The API",
+			Owners:       []string{"backend-team"},
+			URLs:         map[string]string{"admin": "http://localhost:8081"},
+		},
+	}
+	playlists := []playlist.Playlist{
+		{
+			Name:         "db",
+			RegistryName: "TouchBistro/tb-registry",
+			Services:     []string{"TouchBistro/tb-registry/postgres"},
+		},
+		{
+			Name:         "backend",
+			RegistryName: "TouchBistro/tb-registry",
+			Extends:      playlist.NameList{"TouchBistro/tb-registry/db"},
+			Services:     []string{"TouchBistro/tb-registry/api"},
+		},
+	}
+	customPlaylists := []playlist.Playlist{
+		{Name: "my-api", Services: []string{"api"}},
+	}
+	workdir := t.TempDir()
+	e := newEngine(t, engine.Options{
+		Workdir:   workdir,
+		Services:  newServiceCollection(t, services),
+		Playlists: newPlaylistCollection(t, playlists, customPlaylists),
+	})
+
+	info, err := e.Info("api")
+	is.NoErr(err)
+	is.Equal(info.Service.Description, "The API")
+	is.Equal(info.RepoPath, filepath.Join(workdir, "repos", "TouchBistro/api"))
+	is.Equal(info.Dependencies, []string{"TouchBistro/tb-registry/postgres", "external-db"})
+	is.Equal(info.Playlists, []string{"TouchBistro/tb-registry/backend", "my-api"})
+
+	info, err = e.Info("postgres")
+	is.NoErr(err)
+	is.Equal(info.RepoPath, "")
+	is.Equal(info.Playlists, []string{"TouchBistro/tb-registry/backend", "TouchBistro/tb-registry/db"})
+
+	_, err = e.Info("nope")
+	is.True(errors.Is(err, resource.ErrNotFound))
+}
+
+func TestServiceURL(t *testing.T) {
+	services := []service.Service{
+		{
+			Name:         "api",
+			RegistryName: "TouchBistro/tb-registry",
+			Mode:         service.ModeRemote,
+			Remote:       service.Remote{Image: "api"},
+			Ports:        []string{"8081:8080", "9000"},
+			URLs: map[string]string{
+				"admin":   "http://localhost:8081/admin",
+				"metrics": "http://localhost:9000/metrics",
+				"docs":    "https://example.com/docs",
+			},
+		},
+		{
+			Name:         "web",
+			RegistryName: "TouchBistro/tb-registry",
+			Mode:         service.ModeRemote,
+			Remote:       service.Remote{Image: "web"},
+			Ports:        []string{"3000:3000"},
+			URLs:         map[string]string{"app": "http://localhost:3000"},
+		},
+	}
+	containers := []dockertypes.Container{
+		{
+			ID:     "api",
+			Names:  []string{"touchbistro-tb-registry-api"},
+			Labels: map[string]string{docker.ProjectLabel: "tb"},
+			State:  docker.ContainerStateRunning,
+			Ports: []dockertypes.Port{
+				// The host port was remapped
+				{PrivatePort: 8080, PublicPort: 18081, Type: "tcp"},
+				{PrivatePort: 9000, PublicPort: 49153, Type: "tcp"},
+			},
+		},
+	}
+	tests := []struct {
+		name     string
+		service  string
+		endpoint string
+		want     string
+		wantErr  string
+	}{
+		{
+			name:     "remapped port",
+			service:  "api",
+			endpoint: "admin",
+			want:     "http://localhost:18081/admin",
+		},
+		{
+			name:     "random host port",
+			service:  "api",
+			endpoint: "metrics",
+			want:     "http://localhost:49153/metrics",
+		},
+		{
+			name:     "no port",
+			service:  "api",
+			endpoint: "docs",
+			want:     "https://example.com/docs",
+		},
+		{
+			name:    "multiple urls",
+			service: "api",
+			wantErr: "has multiple urls, specify one of: admin, docs, metrics",
+		},
+		{
+			name:     "unknown endpoint",
+			service:  "api",
+			endpoint: "nope",
+			wantErr:  "has no url named nope",
+		},
+		{
+			name:    "service not running",
+			service: "web",
+			wantErr: "service TouchBistro/tb-registry/web is not running",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			e := newEngine(t, engine.Options{
+				Services: newServiceCollection(t, services),
+				DockerOptions: docker.Options{
+					APIClient: docker.NewMockAPIClient(docker.MockAPIClientOptions{Containers: containers}),
+				},
+			})
+			got, err := e.ServiceURL(context.Background(), tt.service, tt.endpoint)
+			if tt.wantErr != "" {
+				is.True(err != nil)
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got error %q, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			is.NoErr(err)
+			is.Equal(got, tt.want)
+		})
+	}
+}
+
 func TestNuke(t *testing.T) {
 	tests := []struct {
 		name              string
@@ -427,6 +669,235 @@ func TestNuke(t *testing.T) {
 	}
 }
 
+func TestRunningServices(t *testing.T) {
+	is := is.New(t)
+	e := newEngine(t, engine.Options{
+		Services: newServiceCollection(t, nil),
+		DockerOptions: docker.Options{
+			APIClient: docker.NewMockAPIClient(docker.MockAPIClientOptions{
+				Containers: []dockertypes.Container{
+					{
+						ID:     "f4d2913f1010244b61940cf52845e6dbe5d687791ea185237efe9121adf15edd",
+						Names:  []string{"touchbistro-tb-registry-touchbistro-node-boilerplate"},
+						Labels: map[string]string{docker.ProjectLabel: "tb"},
+						State:  docker.ContainerStateRunning,
+					},
+					{
+						ID:     "32ce4d8d9c648dd5fce39cf48319da8d55b195513b6fe0cef4a425de9380590c",
+						Names:  []string{"touchbistro-tb-registry-postgres"},
+						Labels: map[string]string{docker.ProjectLabel: "tb"},
+						State:  docker.ContainerStateRunning,
+					},
+					// Container for a service that no longer exists.
+					{
+						ID:     "a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2",
+						Names:  []string{"touchbistro-tb-registry-removed-service"},
+						Labels: map[string]string{docker.ProjectLabel: "tb"},
+						State:  docker.ContainerStateRunning,
+					},
+					// Additional container not part of tb.
+					{
+						ID:    "e8dc7c16f7dd4be23b96951a34b7ecc69cd727ed13a626a309a96b472646c5e9",
+						Names: []string{"test-ubuntu"},
+						State: docker.ContainerStateRunning,
+					},
+				},
+			}),
+		},
+	})
+	serviceNames, err := e.RunningServices(context.Background())
+	is.NoErr(err)
+	is.Equal(serviceNames, []string{
+		"TouchBistro/tb-registry/postgres",
+		"TouchBistro/tb-registry/touchbistro-node-boilerplate",
+	})
+}
+
+func TestUpOverrides(t *testing.T) {
+	newService := func(name string, deprecated *resource.Deprecation) service.Service {
+		return service.Service{
+			Build:        service.Build{DockerfilePath: "/tmp/" + name},
+			Deprecated:   deprecated,
+			Mode:         service.ModeBuild,
+			Name:         name,
+			RegistryName: "TouchBistro/tb-registry",
+		}
+	}
+	services := newServiceCollection(t, []service.Service{
+		newService("api", nil),
+		newService("worker", nil),
+		newService("old-worker", &resource.Deprecation{Replacement: "worker"}),
+	})
+	services.SetDeprecationOptions(resource.DeprecationOptions{UseReplacement: true})
+	playlists := newPlaylistCollection(t, []playlist.Playlist{
+		{
+			Services:     []string{"api", "worker"},
+			Name:         "backend",
+			RegistryName: "TouchBistro/tb-registry",
+		},
+	}, []playlist.Playlist{
+		{
+			// The override of worker does not match anything since it is excluded
+			Extends: playlist.NameList{"backend"},
+			Exclude: []string{"worker"},
+			Overrides: map[string]service.ServiceOverride{
+				"api":    {EnvVars: map[string]string{"QUEUE": "jobs"}},
+				"worker": {EnvVars: map[string]string{"QUEUE": "jobs"}},
+			},
+			Name: "api-only",
+		},
+	})
+
+	tests := []struct {
+		name        string
+		opts        engine.UpOptions
+		wantEnvVars map[string]map[string]string
+	}{
+		{
+			name: "pattern that matches nothing",
+			opts: engine.UpOptions{
+				ServiceNames: []string{"api"},
+				Overrides: map[string]service.ServiceOverride{
+					"payments-*": {EnvVars: map[string]string{"X": "1"}},
+					"api":        {EnvVars: map[string]string{"Y": "2"}},
+				},
+			},
+			wantEnvVars: map[string]map[string]string{"api": {"Y": "2"}},
+		},
+		{
+			name:        "playlist override for excluded service",
+			opts:        engine.UpOptions{PlaylistName: "api-only"},
+			wantEnvVars: map[string]map[string]string{"api": {"QUEUE": "jobs"}},
+		},
+		{
+			name: "override for deprecated service",
+			opts: engine.UpOptions{
+				ServiceNames: []string{"old-worker"},
+				Overrides: map[string]service.ServiceOverride{
+					"old-worker": {EnvVars: map[string]string{"Z": "3"}},
+				},
+			},
+			wantEnvVars: map[string]map[string]string{"worker": {"Z": "3"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			e := newEngine(t, engine.Options{Services: services, Playlists: playlists})
+			tt.opts.SkipPreRun = true
+			tt.opts.SkipDockerPull = true
+			is.NoErr(e.Up(context.Background(), tt.opts))
+			for name, envVars := range tt.wantEnvVars {
+				s, err := e.ResolveService(name)
+				is.NoErr(err)
+				for k, v := range envVars {
+					is.Equal(s.EnvVars[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestUpOverridesAmbiguousName(t *testing.T) {
+	is := is.New(t)
+	var services []service.Service
+	for _, registryName := range []string{"ExampleZone/tb-registry", "TouchBistro/tb-registry"} {
+		services = append(services, service.Service{
+			Mode:         service.ModeRemote,
+			Remote:       service.Remote{Image: "postgres"},
+			Name:         "postgres",
+			RegistryName: registryName,
+		})
+	}
+	collection := newServiceCollection(t, services)
+	calls := 0
+	collection.SetChooseFunc(func(typ resource.Type, name string, candidates []string) (string, error) {
+		calls++
+		return "TouchBistro/tb-registry/postgres", nil
+	})
+	e := newEngine(t, engine.Options{Services: collection})
+	err := e.Up(context.Background(), engine.UpOptions{
+		ServiceNames: []string{"postgres"},
+		Overrides: map[string]service.ServiceOverride{
+			"postgres": {EnvVars: map[string]string{"POSTGRES_DB": "core"}},
+		},
+		SkipPreRun:     true,
+		SkipDockerPull: true,
+	})
+	is.NoErr(err)
+	is.Equal(calls, 1) // the name is only resolved once
+	s, err := e.ResolveService("TouchBistro/tb-registry/postgres")
+	is.NoErr(err)
+	is.Equal(s.EnvVars["POSTGRES_DB"], "core")
+	s, err = e.ResolveService("ExampleZone/tb-registry/postgres")
+	is.NoErr(err)
+	is.Equal(s.EnvVars["POSTGRES_DB"], "")
+}
+
+func TestUpPreRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		preRun     string
+		wantPreRun bool
+	}{
+		{name: "service with pre-run", preRun: "yarn db:prepare", wantPreRun: true},
+		{name: "no services with pre-run", wantPreRun: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			services := newServiceCollection(t, []service.Service{
+				{
+					Mode:         service.ModeRemote,
+					PreRun:       tt.preRun,
+					Remote:       service.Remote{Image: "venue-core-service"},
+					Name:         "venue-core-service",
+					RegistryName: "TouchBistro/tb-registry",
+				},
+			})
+			e := newEngine(t, engine.Options{Services: services})
+			tracker := &startTracker{}
+			ctx := progress.ContextWithTracker(context.Background(), tracker)
+			err := e.Up(ctx, engine.UpOptions{
+				ServiceNames:   []string{"venue-core-service"},
+				SkipDockerPull: true,
+			})
+			is.NoErr(err)
+			ranPreRun := false
+			for _, msg := range tracker.messages {
+				if strings.Contains(msg, "pre-run") {
+					ranPreRun = true
+				}
+			}
+			is.Equal(ranPreRun, tt.wantPreRun)
+		})
+	}
+}
+
+func TestResolveServiceNames(t *testing.T) {
+	is := is.New(t)
+	e := newEngine(t, engine.Options{Services: newServiceCollection(t, nil)})
+	names, err := e.ResolveServiceNames([]string{"touchbistro-node-boilerplate", "ExampleZone/tb-registry/post*"})
+	is.NoErr(err)
+	is.Equal(names, []string{"touchbistro-node-boilerplate", "ExampleZone/tb-registry/postgres"})
+
+	// Ambiguous names can't be resolved without a way to choose
+	_, err = e.ResolveServiceNames([]string{"postgres"})
+	is.True(errors.Is(err, resource.ErrMultipleResources))
+	_, err = e.ResolveServiceNames([]string{"redis"})
+	is.True(errors.Is(err, resource.ErrNotFound))
+}
+
+// startTracker is a tracker that records the message of each started step.
+type startTracker struct {
+	progress.NoopTracker
+	messages []string
+}
+
+func (t *startTracker) Start(message string, count int) {
+	t.messages = append(t.messages, message)
+}
+
 func newServiceCollection(t *testing.T, services []service.Service) *resource.Collection[service.Service] {
 	t.Helper()
 
@@ -237,6 +237,57 @@ func (d *Docker) listContainers(ctx context.Context, serviceNames []string, stop
 	return containers, nil
 }
 
+// RunningContainerNames returns the names of all running containers that belong to the project.
+func (d *Docker) RunningContainerNames(ctx context.Context) ([]string, error) {
+	const op = errors.Op("docker.Docker.RunningContainerNames")
+	containers, err := d.listContainers(ctx, nil, false, op)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, c := range containers {
+		for _, n := range c.Names {
+			names = append(names, strings.TrimPrefix(n, "/"))
+		}
+	}
+	return names, nil
+}
+
+// PublishedPort returns the port on the host that containerPort of the running service container is published to.
+// The port is read from the container, so it is correct even if it differs from the port in the compose config.
+func (d *Docker) PublishedPort(ctx context.Context, serviceName string, containerPort uint16) (uint16, error) {
+	const op = errors.Op("docker.Docker.PublishedPort")
+	containers, err := d.listContainers(ctx, []string{serviceName}, false, op)
+	if err != nil {
+		return 0, err
+	}
+	// The name filter matches substrings, make sure the container is actually for this service.
+	name := NormalizeName(serviceName)
+	for _, c := range containers {
+		match := false
+		for _, n := range c.Names {
+			if strings.TrimPrefix(n, "/") == name {
+				match = true
+				break
+			}
+		}
+		if !match {
+			continue
+		}
+		for _, p := range c.Ports {
+			if p.PrivatePort == containerPort && p.PublicPort != 0 && (p.Type == "" || p.Type == "tcp") {
+				return p.PublicPort, nil
+			}
+		}
+		return 0, errors.New(
+			errkind.Invalid,
+			fmt.Sprintf("port %d of service %s is not published to the host", containerPort, serviceName),
+			op,
+		)
+	}
+	return 0, errors.New(errkind.Invalid, fmt.Sprintf("service %s is not running", serviceName), op)
+}
+
 // PullImage pulls the specified image from a remote registry.
 // imageName must be a valid image name either in normalized for or familiar form.
 //
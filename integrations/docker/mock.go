@@ -208,6 +208,21 @@ func (m *mockAPIClient) ContainerStop(ctx context.Context, container string, opt
 	return nil
 }
 
+// Compose operations are not simulated since they run the docker compose CLI,
+// they succeed without doing anything.
+
+func (m *mockAPIClient) ComposeBuild(ctx context.Context, project ComposeProject, services []string) error {
+	return nil
+}
+
+func (m *mockAPIClient) ComposeUp(ctx context.Context, project ComposeProject, services []string) error {
+	return nil
+}
+
+func (m *mockAPIClient) ComposeRun(ctx context.Context, project ComposeProject, opts ComposeRunOptions) error {
+	return nil
+}
+
 func (m *mockAPIClient) findContainerByID(id string) (types.Container, error) {
 	if id == "" {
 		return types.Container{}, fmt.Errorf("container cannot be empty")
@@ -22,6 +22,7 @@ type Git interface {
 	Clone(ctx context.Context, repo, path string) error
 	Pull(ctx context.Context, path string) error
 	GetBranchHeadSha(ctx context.Context, repo, branch string) (string, error)
+	GetHeadSha(ctx context.Context, path string) (string, error)
 }
 
 type realGit struct{}
@@ -61,6 +62,15 @@ func (realGit) GetBranchHeadSha(ctx context.Context, repo, branch string) (strin
 	return result[0:40], nil
 }
 
+// GetHeadSha returns the SHA of the commit currently checked out in the repo at path.
+func (realGit) GetHeadSha(ctx context.Context, path string) (string, error) {
+	var stdout bytes.Buffer
+	if err := execGit(ctx, "git.Git.GetHeadSha", &stdout, "-C", path, "rev-parse", "HEAD"); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
 func execGit(ctx context.Context, op errors.Op, stdout io.Writer, args ...string) error {
 	tracker := progress.TrackerFromContext(ctx)
 	w := logutil.LogWriter(tracker.WithAttrs("op", op), slog.LevelDebug)
@@ -0,0 +1,30 @@
+// Command genschema generates the JSON Schemas for tb config files.
+// It is run with 'make schemas' and writes the schemas to the directory
+// given as the first argument.
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/TouchBistro/tb/config"
+	"github.com/TouchBistro/tb/registry"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: genschema <dir>")
+		os.Exit(2)
+	}
+	dir := os.Args[1]
+	schemas := registry.JSONSchemas()
+	schemas["tbrc"] = config.JSONSchema()
+	for name, s := range schemas {
+		p := filepath.Join(dir, name+".schema.json")
+		if err := os.WriteFile(p, s, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write %s: %v\n", p, err)
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+// Package schema provides support for checking YAML documents against the Go types
+// they are decoded into, and for generating JSON Schemas from those types.
+//
+// Field names are determined from yaml struct tags the same way as the yaml package does,
+// so the types used to decode config files are the single source of truth for their schema.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/TouchBistro/tb/internal/util"
+	"gopkg.in/yaml.v3"
+)
+
+// baseURL is the URL where the generated JSON Schemas are published.
+// The schemas are stored in the schemas directory of the tb repo.
+const baseURL = "https://raw.githubusercontent.com/TouchBistro/tb/master/schemas/"
+
+// URL returns the URL where the JSON Schema with the given name is published.
+// It is used as the $id of the schema.
+func URL(name string) string {
+	return baseURL + name + ".schema.json"
+}
+
+// UnknownField describes a key in a YAML document that does not correspond
+// to any field of the type being decoded into.
+type UnknownField struct {
+	// Node is the key node of the unknown field.
+	Node *yaml.Node
+	// Path is the dot separated path to the mapping containing the field.
+	// It is empty if the field is at the top level of the document.
+	Path string
+	// Name is the name of the unknown field.
+	Name string
+	// Suggestion is the closest known field name, if one is similar enough.
+	Suggestion string
+}
+
+func (uf UnknownField) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "unknown field %q", uf.Name)
+	if uf.Path != "" {
+		fmt.Fprintf(&sb, " in %s", uf.Path)
+	}
+	if uf.Suggestion != "" {
+		fmt.Fprintf(&sb, ", did you mean %q?", uf.Suggestion)
+	}
+	return sb.String()
+}
+
+// UnknownFields returns all the keys in n that do not correspond to a field in t.
+// n is expected to be the node that would be decoded into a value of type t.
+func UnknownFields(n *yaml.Node, t reflect.Type) []UnknownField {
+	var fields []UnknownField
+	unknownFields(n, t, "", &fields)
+	return fields
+}
+
+var unmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+
+func unknownFields(n *yaml.Node, t reflect.Type, path string, result *[]UnknownField) {
+	if n == nil {
+		return
+	}
+	if n.Kind == yaml.DocumentNode && len(n.Content) > 0 {
+		n = n.Content[0]
+	}
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	// Types with custom unmarshaling define their own format so there is nothing to check.
+	if t.Implements(unmarshalerType) || reflect.PointerTo(t).Implements(unmarshalerType) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if n.Kind != yaml.MappingNode {
+			return
+		}
+		fields := structFields(t)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			// Merge keys are handled by the yaml package
+			if k.Value == "<<" {
+				continue
+			}
+			ft, ok := fields[k.Value]
+			if !ok {
+				*result = append(*result, UnknownField{
+					Node:       k,
+					Path:       path,
+					Name:       k.Value,
+					Suggestion: closest(k.Value, fields),
+				})
+				continue
+			}
+			unknownFields(v, ft, joinPath(path, k.Value), result)
+		}
+	case reflect.Map:
+		if n.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			unknownFields(n.Content[i+1], t.Elem(), joinPath(path, n.Content[i].Value), result)
+		}
+	case reflect.Slice, reflect.Array:
+		if n.Kind != yaml.SequenceNode {
+			return
+		}
+		for i, item := range n.Content {
+			unknownFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), result)
+		}
+	}
+}
+
+// FieldType returns the type of the value at path in t. path is a dot separated list of
+// yaml field names, or keys if the value is a map. An error is returned if path does not
+// refer to a value in t, which includes a suggestion if a field name looks like a typo.
+func FieldType(t reflect.Type, path string) (reflect.Type, error) {
+	var cur string
+	for _, key := range strings.Split(path, ".") {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if key == "" {
+			return nil, fmt.Errorf("invalid path %q, keys must not be empty", path)
+		}
+		switch t.Kind() {
+		case reflect.Struct:
+			if t.Implements(unmarshalerType) || reflect.PointerTo(t).Implements(unmarshalerType) {
+				return nil, fmt.Errorf("%s does not have fields", cur)
+			}
+			fields := structFields(t)
+			ft, ok := fields[key]
+			if !ok {
+				uf := UnknownField{Path: cur, Name: key, Suggestion: closest(key, fields)}
+				return nil, fmt.Errorf("%s", uf)
+			}
+			t = ft
+		case reflect.Map:
+			t = t.Elem()
+		default:
+			return nil, fmt.Errorf("%s does not have fields", cur)
+		}
+		cur = joinPath(cur, key)
+	}
+	return t, nil
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// closest returns the name in fields that is closest to name, or an empty string
+// if there are no names that are close enough to be a likely typo.
+func closest(name string, fields map[string]reflect.Type) string {
+	// Allow more mistakes in longer names, but always allow at least a couple.
+	maxDist := max(2, len(name)/4)
+	best := ""
+	bestDist := maxDist + 1
+	for f := range fields {
+		// Use lowercase so that mistakes in casing, which are very common, are preferred.
+		d := util.EditDistance(strings.ToLower(name), strings.ToLower(f))
+		if d < bestDist || (d == bestDist && f < best) {
+			best, bestDist = f, d
+		}
+	}
+	return best
+}
+
+// structFields returns a map of the yaml field names of t to their types.
+// Inline structs and maps are flattened into the result.
+func structFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		// Same as the yaml package, embedded structs can be inlined even if unexported
+		if !f.IsExported() && !f.Anonymous {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(opts, "inline") {
+			ft := f.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for n, t := range structFields(ft) {
+					fields[n] = t
+				}
+			}
+			continue
+		}
+		if name == "" {
+			// Same default as the yaml package
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// JSONSchema generates a JSON Schema describing the YAML representation of t.
+// The returned value is indented JSON and ends with a newline.
+func JSONSchema(t reflect.Type, id, title string) []byte {
+	s := jsonSchema(t)
+	s["$schema"] = "http://json-schema.org/draft-07/schema#"
+	s["$id"] = id
+	s["title"] = title
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		// The schema is built from maps, slices, and strings so this should never happen.
+		panic(fmt.Sprintf("schema: failed to marshal JSON Schema: %v", err))
+	}
+	return append(b, '\n')
+}
+
+// schemaer is implemented by types with custom unmarshaling that describe their own format.
+type schemaer interface {
+	JSONSchema() map[string]interface{}
+}
+
+var schemaerType = reflect.TypeOf((*schemaer)(nil)).Elem()
+
+func jsonSchema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Implements(schemaerType) {
+		return reflect.Zero(t).Interface().(schemaer).JSONSchema()
+	}
+	// Types with custom unmarshaling can have any format.
+	if t.Implements(unmarshalerType) || reflect.PointerTo(t).Implements(unmarshalerType) {
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		fields := structFields(t)
+		names := make([]string, 0, len(fields))
+		for n := range fields {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		props := make(map[string]interface{}, len(fields))
+		for _, n := range names {
+			props[n] = jsonSchema(fields[n])
+		}
+		return map[string]interface{}{
+			"type":                 []string{"object", "null"},
+			"properties":           props,
+			"additionalProperties": false,
+		}
+	case reflect.Map:
+		elem := jsonSchema(t.Elem())
+		if t.Elem().Kind() == reflect.String {
+			// Values in string maps are commonly written as numbers or bools in YAML,
+			// ex: 'PORT: 8080', which is perfectly valid so allow any scalar.
+			elem = map[string]interface{}{"type": []string{"string", "number", "boolean", "null"}}
+		}
+		return map[string]interface{}{
+			"type":                 []string{"object", "null"},
+			"additionalProperties": elem,
+		}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{
+			"type":  []string{"array", "null"},
+			"items": jsonSchema(t.Elem()),
+		}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	}
+	return map[string]interface{}{}
+}
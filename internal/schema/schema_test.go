@@ -0,0 +1,102 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/TouchBistro/tb/internal/schema"
+	"github.com/matryer/is"
+	"gopkg.in/yaml.v3"
+)
+
+type inner struct {
+	Name string `yaml:"name"`
+}
+
+type embedded struct {
+	Port int `yaml:"port"`
+}
+
+type config struct {
+	embedded `yaml:",inline"`
+	Items    []inner          `yaml:"items"`
+	ByName   map[string]inner `yaml:"byName"`
+	Enabled  bool
+	Ignored  string `yaml:"-"`
+}
+
+func TestUnknownFields(t *testing.T) {
+	is := is.New(t)
+	data := `port: 80
+enabled: true
+ignored: x
+items:
+  - name: a
+  - nmae: b
+byName:
+  foo:
+    name: c
+    other: d
+`
+	var doc yaml.Node
+	is.NoErr(yaml.Unmarshal([]byte(data), &doc))
+
+	var got []string
+	for _, uf := range schema.UnknownFields(&doc, reflect.TypeOf(config{})) {
+		got = append(got, uf.String())
+	}
+	is.Equal(got, []string{
+		`unknown field "ignored"`,
+		`unknown field "nmae" in items[1], did you mean "name"?`,
+		`unknown field "other" in byName.foo`,
+	})
+}
+
+func TestFieldType(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    reflect.Type
+		wantErr string
+	}{
+		{path: "port", want: reflect.TypeOf(0)},
+		{path: "items", want: reflect.TypeOf([]inner{})},
+		{path: "byName.foo.name", want: reflect.TypeOf("")},
+		{path: "enabled", want: reflect.TypeOf(true)},
+		{path: "byName.foo.nmae", wantErr: `unknown field "nmae" in byName.foo, did you mean "name"?`},
+		{path: "ignored", wantErr: `unknown field "ignored"`},
+		{path: "port.number", wantErr: "port does not have fields"},
+		{path: "byName..name", wantErr: `invalid path "byName..name", keys must not be empty`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			is := is.New(t)
+			got, err := schema.FieldType(reflect.TypeOf(config{}), tt.path)
+			if tt.wantErr != "" {
+				is.True(err != nil)
+				is.Equal(err.Error(), tt.wantErr)
+				return
+			}
+			is.NoErr(err)
+			is.Equal(got, tt.want)
+		})
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	is := is.New(t)
+	b := schema.JSONSchema(reflect.TypeOf(config{}), schema.URL("test"), "test")
+	var s struct {
+		ID         string                     `json:"$id"`
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	is.NoErr(json.Unmarshal(b, &s))
+	is.Equal(s.ID, "https://raw.githubusercontent.com/TouchBistro/tb/master/schemas/test.schema.json")
+	var names []string
+	for n := range s.Properties {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	is.Equal(names, []string{"byName", "enabled", "items", "port"})
+}
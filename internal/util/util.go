@@ -18,3 +18,28 @@ func UniqueStrings(s []string) []string {
 	}
 	return us
 }
+
+// EditDistance returns the Levenshtein distance between a and b, that is,
+// the minimum number of single character insertions, deletions, or substitutions
+// required to turn a into b.
+func EditDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	// Only need to keep track of the previous row of the matrix.
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
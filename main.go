@@ -89,7 +89,18 @@ func mapError(ctx context.Context, err error) (fatalErr *fatal.Error, removeLogf
 	// TODO(@cszatmary): We can check if errors.Error and use the Kind
 	// to add custom messages to try and help the user.
 	// We should also add specific error codes based on Kind.
+	var lookupErr *resource.LookupError
 	switch {
+	case errors.As(fatalErr.Err, &lookupErr) && len(lookupErr.Suggestions) > 0:
+		// Show the suggestions as the message so they are easy to spot.
+		msg := "Did you mean:"
+		if errors.Is(lookupErr.Err, resource.ErrMultipleResources) {
+			msg = "The name is ambiguous, use the full name of one of:"
+		}
+		for _, s := range lookupErr.Suggestions {
+			msg += "\n  - " + s
+		}
+		fatalErr.Msg = msg
 	case errors.Is(fatalErr.Err, resource.ErrNotFound):
 		// TODO(@cszatmary): Should we have a custom exit code?
 		fatalErr.Msg = "Try running `tb list` to see available services"
@@ -0,0 +1,231 @@
+package registry
+
+import (
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/TouchBistro/goutils/errors"
+	"github.com/TouchBistro/tb/errkind"
+	"github.com/TouchBistro/tb/integrations/docker"
+	"github.com/TouchBistro/tb/resource/app"
+)
+
+// Formats supported by WriteCatalog.
+const (
+	CatalogFormatMarkdown = "markdown"
+	CatalogFormatHTML     = "html"
+)
+
+//go:embed catalog
+var catalogFS embed.FS
+
+// CatalogOptions customizes the behaviour of WriteCatalog.
+type CatalogOptions struct {
+	// Format is the format of the catalog, either CatalogFormatMarkdown or CatalogFormatHTML.
+	// Defaults to CatalogFormatMarkdown.
+	Format string
+	// Title is the title of the catalog. Defaults to "Service Catalog".
+	Title string
+}
+
+// WriteCatalog renders a catalog documenting all the resources in result and writes it to w.
+// Resources are grouped by registry and sorted by name so that the output is stable and can be
+// committed or published as is.
+//
+// Only the names of env vars are included since their values may be sensitive.
+func WriteCatalog(w io.Writer, result ReadAllResult, opts CatalogOptions) error {
+	const op = errors.Op("registry.WriteCatalog")
+	if opts.Format == "" {
+		opts.Format = CatalogFormatMarkdown
+	}
+	if opts.Title == "" {
+		opts.Title = "Service Catalog"
+	}
+	c := buildCatalog(result, opts.Title)
+
+	var err error
+	funcs := map[string]interface{}{"join": strings.Join}
+	switch opts.Format {
+	case CatalogFormatMarkdown:
+		funcs["md"] = escapeMarkdown
+		var t *texttemplate.Template
+		t, err = texttemplate.New("catalog.md.tmpl").Funcs(funcs).ParseFS(catalogFS, "catalog/catalog.md.tmpl")
+		if err == nil {
+			err = t.Execute(w, c)
+		}
+	case CatalogFormatHTML:
+		var t *htmltemplate.Template
+		t, err = htmltemplate.New("catalog.html.tmpl").Funcs(funcs).ParseFS(catalogFS, "catalog/catalog.html.tmpl")
+		if err == nil {
+			err = t.Execute(w, c)
+		}
+	default:
+		msg := fmt.Sprintf("invalid catalog format %q, must be %q or %q", opts.Format, CatalogFormatMarkdown, CatalogFormatHTML)
+		return errors.New(errkind.Invalid, msg, op)
+	}
+	if err != nil {
+		return errors.Wrap(err, errors.Meta{Kind: errkind.IO, Reason: "failed to write catalog", Op: op})
+	}
+	return nil
+}
+
+// catalog is the data passed to the catalog templates.
+type catalog struct {
+	Title      string
+	Registries []*catalogRegistry
+}
+
+type catalogRegistry struct {
+	Name        string
+	Services    []catalogService
+	Playlists   []catalogPlaylist
+	IOSApps     []catalogApp
+	DesktopApps []catalogApp
+}
+
+type catalogService struct {
+	Name         string
+	FullName     string
+	Mode         string
+	Image        string
+	CanBuild     bool
+	Ports        []string
+	Dependencies []string
+	Repo         string
+	EnvVars      []string
+}
+
+type catalogPlaylist struct {
+	Name     string
+	FullName string
+	Extends  []string
+	Services []string
+	// Error is set if the services in the playlist could not be resolved.
+	Error string
+}
+
+type catalogApp struct {
+	Name     string
+	FullName string
+	Repo     string
+	Branch   string
+	BundleID string
+	RunsOn   string
+	EnvVars  []string
+}
+
+func buildCatalog(result ReadAllResult, title string) catalog {
+	registries := make(map[string]*catalogRegistry)
+	getRegistry := func(name string) *catalogRegistry {
+		r, ok := registries[name]
+		if !ok {
+			r = &catalogRegistry{Name: name}
+			registries[name] = r
+		}
+		return r
+	}
+
+	if result.Services != nil {
+		// Dependencies have been expanded to container names, map them back to
+		// the services they refer to so they are readable.
+		containerNames := make(map[string]string)
+		for it := result.Services.Iter(); it.Next(); {
+			s := it.Value()
+			containerNames[docker.NormalizeName(s.FullName())] = s.FullName()
+		}
+		for it := result.Services.Iter(); it.Next(); {
+			s := it.Value()
+			cs := catalogService{
+				Name:     s.Name,
+				FullName: s.FullName(),
+				Mode:     s.Mode,
+				Image:    s.ImageURI(),
+				CanBuild: s.CanBuild(),
+				Ports:    s.Ports,
+				Repo:     s.GitRepo.Name,
+				EnvVars:  sortedKeys(s.EnvVars),
+			}
+			for _, dep := range s.Dependencies {
+				if fullName, ok := containerNames[dep]; ok {
+					dep = fullName
+				}
+				cs.Dependencies = append(cs.Dependencies, dep)
+			}
+			r := getRegistry(s.RegistryName)
+			r.Services = append(r.Services, cs)
+		}
+	}
+	if result.Playlists != nil {
+		for _, n := range result.Playlists.Names() {
+			p, err := result.Playlists.Get(n)
+			if err != nil {
+				// Should never happen since the name came from the collection
+				continue
+			}
+			cp := catalogPlaylist{Name: p.Name, FullName: p.FullName(), Extends: p.Extends}
+			serviceNames, err := result.Playlists.ServiceNames(n)
+			if err != nil {
+				cp.Error = err.Error()
+			}
+			cp.Services = serviceNames
+			r := getRegistry(p.RegistryName)
+			r.Playlists = append(r.Playlists, cp)
+		}
+	}
+	if result.IOSApps != nil {
+		for it := result.IOSApps.Iter(); it.Next(); {
+			a := it.Value()
+			r := getRegistry(a.RegistryName)
+			r.IOSApps = append(r.IOSApps, newCatalogApp(a))
+		}
+	}
+	if result.DesktopApps != nil {
+		for it := result.DesktopApps.Iter(); it.Next(); {
+			a := it.Value()
+			r := getRegistry(a.RegistryName)
+			r.DesktopApps = append(r.DesktopApps, newCatalogApp(a))
+		}
+	}
+
+	c := catalog{Title: title}
+	for _, r := range registries {
+		sort.Slice(r.Services, func(i, j int) bool { return r.Services[i].Name < r.Services[j].Name })
+		sort.Slice(r.Playlists, func(i, j int) bool { return r.Playlists[i].Name < r.Playlists[j].Name })
+		sort.Slice(r.IOSApps, func(i, j int) bool { return r.IOSApps[i].Name < r.IOSApps[j].Name })
+		sort.Slice(r.DesktopApps, func(i, j int) bool { return r.DesktopApps[i].Name < r.DesktopApps[j].Name })
+		c.Registries = append(c.Registries, r)
+	}
+	sort.Slice(c.Registries, func(i, j int) bool { return c.Registries[i].Name < c.Registries[j].Name })
+	return c
+}
+
+func newCatalogApp(a app.App) catalogApp {
+	return catalogApp{
+		Name:     a.Name,
+		FullName: a.FullName(),
+		Repo:     a.GitRepo,
+		Branch:   a.Branch,
+		BundleID: a.BundleID,
+		RunsOn:   a.RunsOn,
+		EnvVars:  sortedKeys(a.EnvVars),
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// escapeMarkdown escapes characters in s that have special meaning in markdown tables.
+func escapeMarkdown(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
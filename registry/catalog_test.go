@@ -0,0 +1,84 @@
+package registry_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/TouchBistro/tb/registry"
+	"github.com/matryer/is"
+)
+
+func readCatalogRegistries(t *testing.T) registry.ReadAllResult {
+	t.Helper()
+	result, err := registry.ReadAll([]registry.Registry{
+		{
+			Name: "TouchBistro/tb-registry",
+			Path: "testdata/registry-1",
+		},
+		{
+			Name: "ExampleZone/tb-registry",
+			Path: "testdata/registry-2",
+		},
+	}, registry.ReadAllOptions{
+		ReadApps:     true,
+		ReadServices: true,
+		RootPath:     "/home/test/.tb",
+		ReposPath:    "/home/test/.tb/repos",
+	})
+	if err != nil {
+		t.Fatalf("failed to read registries: %v", err)
+	}
+	return result
+}
+
+func TestWriteCatalogMarkdown(t *testing.T) {
+	is := is.New(t)
+	var buf bytes.Buffer
+	err := registry.WriteCatalog(&buf, readCatalogRegistries(t), registry.CatalogOptions{})
+	is.NoErr(err)
+
+	out := buf.String()
+	for _, want := range []string{
+		"# Service Catalog\n",
+		"## ExampleZone/tb-registry\n",
+		"## TouchBistro/tb-registry\n",
+		// Dependencies are shown as service names instead of container names
+		"| venue-core-service | remote (can build) | `12345.dkr.ecr.us-east-1.amazonaws.com/venue-core-service:master` | 8081:8080 | TouchBistro/tb-registry/postgres | TouchBistro/venue-core-service | DB_HOST, HTTP_PORT |\n",
+		// Playlists include services from the playlists they extend
+		"| core | TouchBistro/tb-registry/db | TouchBistro/tb-registry/postgres, TouchBistro/tb-registry/venue-core-service |\n",
+		"| iCode | com.example.iCode | iPad | ExampleZone/iCode | develop |  |\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("catalog does not contain %q\ngot:\n%s", want, out)
+		}
+	}
+	// ExampleZone must come before TouchBistro since registries are sorted
+	is.True(strings.Index(out, "## ExampleZone") < strings.Index(out, "## TouchBistro"))
+	// Env var values must not be included
+	is.True(!strings.Contains(out, "localdev"))
+}
+
+func TestWriteCatalogHTML(t *testing.T) {
+	is := is.New(t)
+	var buf bytes.Buffer
+	err := registry.WriteCatalog(&buf, readCatalogRegistries(t), registry.CatalogOptions{
+		Format: registry.CatalogFormatHTML,
+		Title:  "Services & Apps",
+	})
+	is.NoErr(err)
+
+	out := buf.String()
+	is.True(strings.HasPrefix(out, "<!DOCTYPE html>"))
+	// Values must be escaped
+	is.True(strings.Contains(out, "<title>Services &amp; Apps</title>"))
+	is.True(strings.Contains(out, `<tr id="TouchBistro/tb-registry/db"><td>db</td><td></td><td>TouchBistro/tb-registry/postgres</td></tr>`))
+}
+
+func TestWriteCatalogInvalidFormat(t *testing.T) {
+	is := is.New(t)
+	var buf bytes.Buffer
+	err := registry.WriteCatalog(&buf, readCatalogRegistries(t), registry.CatalogOptions{Format: "pdf"})
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "invalid catalog format"))
+}
@@ -0,0 +1,349 @@
+package registry
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/TouchBistro/goutils/errors"
+	"github.com/TouchBistro/tb/errkind"
+	"github.com/TouchBistro/tb/resource/service"
+	"gopkg.in/yaml.v3"
+)
+
+// ImportComposeOptions customizes the behaviour of ImportCompose.
+type ImportComposeOptions struct {
+	// RepoName is the name of the git repo, of the form org/repo, that contains
+	// the compose file. It is set as the repo of any services that use relative paths
+	// so that the paths can be resolved using ${@REPOPATH}.
+	RepoName string
+}
+
+// ComposeImport is the result of importing a docker compose file.
+type ComposeImport struct {
+	// Services contains the converted services in the order they are defined in the compose file.
+	Services []service.Service
+	// Warnings describes any features used by the compose file that could not be
+	// represented by tb and were therefore dropped or changed.
+	Warnings []string
+}
+
+// ImportCompose converts the services in the docker compose file data to tb services.
+//
+// Relative paths are assumed to be relative to the root of the repo containing the compose file
+// and are converted to use ${@REPOPATH}. Dependencies on other services in the compose file
+// are converted to use ${@<service>} so that they refer to the services once they are added to a registry.
+//
+// Any features that cannot be represented are reported in the returned warnings instead of
+// failing the import. Services that cannot be converted at all are skipped with a warning.
+func ImportCompose(data []byte, opts ImportComposeOptions) (ComposeImport, error) {
+	const op = errors.Op("registry.ImportCompose")
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return ComposeImport{}, errors.Wrap(err, errors.Meta{
+			Kind:   errkind.Invalid,
+			Reason: "failed to decode compose file",
+			Op:     op,
+		})
+	}
+	var root *yaml.Node
+	if len(doc.Content) > 0 {
+		root = doc.Content[0]
+	}
+	if root == nil || root.Kind != yaml.MappingNode {
+		return ComposeImport{}, errors.New(errkind.Invalid, "compose file does not contain a mapping", op)
+	}
+	_, servicesNode := mappingEntry(root, "services")
+	if servicesNode == nil || servicesNode.Kind != yaml.MappingNode || len(servicesNode.Content) == 0 {
+		return ComposeImport{}, errors.New(errkind.Invalid, "compose file does not define any services", op)
+	}
+
+	ci := composeImporter{
+		opts:         opts,
+		serviceNames: make(map[string]bool),
+		namedVolumes: make(map[string]bool),
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		switch k := root.Content[i].Value; {
+		case k == "volumes":
+			for j := 0; j+1 < len(root.Content[i+1].Content); j += 2 {
+				ci.namedVolumes[root.Content[i+1].Content[j].Value] = true
+			}
+		case k == "networks", k == "secrets", k == "configs":
+			ci.warnf("top level %s are not supported and were ignored", k)
+		}
+	}
+	for i := 0; i+1 < len(servicesNode.Content); i += 2 {
+		ci.serviceNames[servicesNode.Content[i].Value] = true
+	}
+	for i := 0; i+1 < len(servicesNode.Content); i += 2 {
+		name := servicesNode.Content[i].Value
+		s, ok := ci.convertService(name, servicesNode.Content[i+1])
+		if ok {
+			ci.result.Services = append(ci.result.Services, s)
+		}
+	}
+	return ci.result, nil
+}
+
+// composeImporter holds the state used while converting the services of a compose file.
+type composeImporter struct {
+	opts         ImportComposeOptions
+	serviceNames map[string]bool
+	namedVolumes map[string]bool
+	result       ComposeImport
+	// usesRepo is set if the service currently being converted has paths relative to the repo.
+	usesRepo bool
+}
+
+func (ci *composeImporter) warnf(format string, a ...interface{}) {
+	ci.result.Warnings = append(ci.result.Warnings, fmt.Sprintf(format, a...))
+}
+
+func (ci *composeImporter) convertService(name string, n *yaml.Node) (service.Service, bool) {
+	s := service.Service{Name: name}
+	ci.usesRepo = false
+	if n.Kind != yaml.MappingNode {
+		ci.warnf("service %s: not a mapping, skipped", name)
+		return s, false
+	}
+	warnf := func(format string, a ...interface{}) {
+		ci.warnf("service %s: "+format, append([]interface{}{name}, a...)...)
+	}
+
+	var hasBuild bool
+	var command string
+	var volumes []service.Volume
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, v := n.Content[i].Value, n.Content[i+1]
+		switch key {
+		case "build":
+			hasBuild = true
+			ci.convertBuild(&s, v, warnf)
+		case "image":
+			s.Remote.Image, s.Remote.Tag = splitImage(v.Value)
+		case "command":
+			command = joinCommand(v)
+		case "entrypoint":
+			if v.Kind == yaml.SequenceNode {
+				s.Entrypoint = scalarValues(v)
+			} else {
+				s.Entrypoint = strings.Fields(v.Value)
+			}
+		case "container_name":
+			warnf("container_name is ignored, tb names containers after the service")
+		case "depends_on":
+			// depends_on is either a list of names or a mapping of names to conditions
+			var deps []string
+			if v.Kind == yaml.MappingNode {
+				for j := 0; j < len(v.Content); j += 2 {
+					deps = append(deps, v.Content[j].Value)
+				}
+				warnf("depends_on conditions are not supported, only the order of services is kept")
+			} else {
+				deps = scalarValues(v)
+			}
+			for _, d := range deps {
+				if !ci.serviceNames[d] {
+					warnf("depends_on: %s is not a service in the compose file, it was kept as is", d)
+					s.Dependencies = append(s.Dependencies, d)
+					continue
+				}
+				s.Dependencies = append(s.Dependencies, "${@"+d+"}")
+			}
+		case "environment":
+			s.EnvVars = ci.convertEnvironment(v, warnf)
+		case "env_file":
+			files := scalarValues(v)
+			if v.Kind == yaml.ScalarNode {
+				files = []string{v.Value}
+			}
+			if len(files) > 0 {
+				s.EnvFile = ci.repoPath(files[0], "env_file", warnf)
+			}
+			if len(files) > 1 {
+				warnf("env_file: only a single file is supported, %s were ignored", strings.Join(files[1:], ", "))
+			}
+		case "ports":
+			for _, p := range v.Content {
+				if p.Kind != yaml.ScalarNode {
+					warnf("ports: long syntax is not supported, use EXTERNAL:INTERNAL instead")
+					continue
+				}
+				s.Ports = append(s.Ports, p.Value)
+			}
+		case "volumes":
+			for _, vol := range v.Content {
+				if volume, ok := ci.convertVolume(vol, warnf); ok {
+					volumes = append(volumes, volume)
+				}
+			}
+		default:
+			if strings.HasPrefix(key, "x-") {
+				// Extension fields are only used by compose itself
+				continue
+			}
+			warnf("%s is not supported and was ignored", key)
+		}
+	}
+
+	if hasBuild {
+		s.Mode = service.ModeBuild
+		s.Build.Command = command
+		s.Build.Volumes = volumes
+	} else {
+		s.Mode = service.ModeRemote
+		s.Remote.Command = command
+		s.Remote.Volumes = volumes
+	}
+	if ci.usesRepo {
+		s.GitRepo.Name = ci.opts.RepoName
+	}
+	if s.Mode == service.ModeRemote && s.Remote.Image == "" {
+		warnf("neither build nor image is set, skipped")
+		return s, false
+	}
+	return s, true
+}
+
+func (ci *composeImporter) convertBuild(s *service.Service, n *yaml.Node, warnf func(string, ...interface{})) {
+	// build is either the path to the context or a mapping
+	if n.Kind == yaml.ScalarNode {
+		s.Build.DockerfilePath = ci.repoPath(n.Value, "build", warnf)
+		return
+	}
+	context := "."
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, v := n.Content[i].Value, n.Content[i+1]
+		switch key {
+		case "context":
+			context = v.Value
+		case "target":
+			s.Build.Target = v.Value
+		case "args":
+			s.Build.Args = ci.convertEnvironment(v, func(format string, a ...interface{}) {
+				warnf("build.args: "+format, a...)
+			})
+		case "dockerfile":
+			if path.Base(v.Value) != "Dockerfile" {
+				warnf("build.dockerfile is not supported, the Dockerfile must be named Dockerfile at the root of the context")
+			}
+		default:
+			warnf("build.%s is not supported and was ignored", key)
+		}
+	}
+	s.Build.DockerfilePath = ci.repoPath(context, "build.context", warnf)
+}
+
+// convertEnvironment converts a compose environment, which is either a list of KEY=VALUE
+// or a mapping, to a map of env vars.
+func (ci *composeImporter) convertEnvironment(n *yaml.Node, warnf func(string, ...interface{})) map[string]string {
+	env := make(map[string]string)
+	if n.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i].Value, n.Content[i+1]
+			if v.Tag == "!!null" {
+				warnf("%s has no value, passing through host env vars is not supported", k)
+				continue
+			}
+			env[k] = v.Value
+		}
+		return env
+	}
+	for _, item := range scalarValues(n) {
+		k, v, ok := strings.Cut(item, "=")
+		if !ok {
+			warnf("%s has no value, passing through host env vars is not supported", k)
+			continue
+		}
+		env[k] = v
+	}
+	return env
+}
+
+// convertVolume converts a compose volume to a tb volume. Only the short syntax is supported.
+func (ci *composeImporter) convertVolume(n *yaml.Node, warnf func(string, ...interface{})) (service.Volume, bool) {
+	if n.Kind != yaml.ScalarNode {
+		warnf("volumes: long syntax is not supported, use SOURCE:TARGET instead")
+		return service.Volume{}, false
+	}
+	source, rest, ok := strings.Cut(n.Value, ":")
+	if !ok {
+		// Anonymous volume, only a container path
+		return service.Volume{Value: n.Value}, true
+	}
+	if ci.namedVolumes[source] {
+		return service.Volume{Value: n.Value, IsNamed: true}, true
+	}
+	if !isComposePath(source) {
+		warnf("volumes: %s is not declared in the top level volumes, it was added as a named volume", source)
+		return service.Volume{Value: n.Value, IsNamed: true}, true
+	}
+	return service.Volume{Value: ci.repoPath(source, "volumes", warnf) + ":" + rest}, true
+}
+
+// repoPath converts a path in the compose file to a path that can be used by tb.
+// Relative paths are made relative to the root of the repo.
+func (ci *composeImporter) repoPath(p, field string, warnf func(string, ...interface{})) string {
+	if !strings.HasPrefix(p, ".") && isComposePath(p) {
+		// Absolute paths or paths relative to the home directory can't be changed
+		return p
+	}
+	ci.usesRepo = true
+	if ci.opts.RepoName == "" {
+		warnf("%s: %s is relative to the compose file but no repo was provided, ${@REPOPATH} will be empty", field, p)
+	}
+	p = path.Clean(p)
+	if p == "." {
+		return "${@REPOPATH}"
+	}
+	return "${@REPOPATH}/" + p
+}
+
+// isComposePath reports whether the volume source p is a path,
+// as opposed to the name of a volume.
+func isComposePath(p string) bool {
+	return strings.HasPrefix(p, ".") || strings.HasPrefix(p, "/") || strings.HasPrefix(p, "~")
+}
+
+// splitImage splits an image into the image name and the tag.
+func splitImage(image string) (string, string) {
+	// Digests are part of the image, not the tag
+	if strings.Contains(image, "@") {
+		return image, ""
+	}
+	// A colon before the last slash is a registry port, ex: localhost:5000/app
+	i := strings.LastIndex(image, ":")
+	if i == -1 || strings.Contains(image[i:], "/") {
+		return image, ""
+	}
+	return image[:i], image[i+1:]
+}
+
+// joinCommand converts a compose command, which is either a string or a list, to a string.
+func joinCommand(n *yaml.Node) string {
+	if n.Kind != yaml.SequenceNode {
+		return n.Value
+	}
+	args := scalarValues(n)
+	for i, a := range args {
+		if a == "" || strings.ContainsAny(a, " \t\"'") {
+			args[i] = fmt.Sprintf("%q", a)
+		}
+	}
+	return strings.Join(args, " ")
+}
+
+// scalarValues returns the values of all the scalar items in the sequence node n.
+func scalarValues(n *yaml.Node) []string {
+	var values []string
+	if n.Kind != yaml.SequenceNode {
+		return values
+	}
+	for _, item := range n.Content {
+		if item.Kind == yaml.ScalarNode {
+			values = append(values, item.Value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,109 @@
+package registry_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TouchBistro/tb/registry"
+	"github.com/TouchBistro/tb/resource/service"
+	"github.com/matryer/is"
+)
+
+func TestImportCompose(t *testing.T) {
+	is := is.New(t)
+	data, err := os.ReadFile("testdata/compose/docker-compose.yml")
+	is.NoErr(err)
+	result, err := registry.ImportCompose(data, registry.ImportComposeOptions{RepoName: "example/api"})
+	is.NoErr(err)
+
+	is.Equal(result.Services, []service.Service{
+		{
+			Name:    "postgres",
+			Mode:    service.ModeRemote,
+			EnvVars: map[string]string{"POSTGRES_USER": "core", "POSTGRES_PASSWORD": "localdev"},
+			Ports:   []string{"5432:5432"},
+			Remote: service.Remote{
+				Image: "postgres",
+				Tag:   "12-alpine",
+				Volumes: []service.Volume{
+					{Value: "postgres-data:/var/lib/postgresql/data", IsNamed: true},
+				},
+			},
+		},
+		{
+			Name: "api",
+			Mode: service.ModeBuild,
+			Build: service.Build{
+				Args:           map[string]string{"NODE_ENV": "development"},
+				Command:        "yarn start",
+				DockerfilePath: "${@REPOPATH}/api",
+				Target:         "dev",
+				Volumes: []service.Volume{
+					{Value: "${@REPOPATH}/api/src:/app/src"},
+					{Value: "cache:/app/.cache", IsNamed: true},
+				},
+			},
+			Dependencies: []string{"${@postgres}", "redis"},
+			EnvFile:      "${@REPOPATH}/.env",
+			EnvVars:      map[string]string{"PORT": "8080"},
+			GitRepo:      service.GitRepo{Name: "example/api"},
+			Ports:        []string{"8080:8080"},
+			Remote:       service.Remote{Image: "example/api", Tag: "latest"},
+		},
+	})
+	is.Equal(result.Warnings, []string{
+		"top level networks are not supported and were ignored",
+		"service postgres: container_name is ignored, tb names containers after the service",
+		"service postgres: healthcheck is not supported and was ignored",
+		"service api: depends_on: redis is not a service in the compose file, it was kept as is",
+		"service api: env_file: only a single file is supported, .env.local were ignored",
+		"service api: HOME has no value, passing through host env vars is not supported",
+		"service api: volumes: cache is not declared in the top level volumes, it was added as a named volume",
+		"service api: networks is not supported and was ignored",
+		"service worker: restart is not supported and was ignored",
+		"service worker: neither build nor image is set, skipped",
+	})
+}
+
+func TestImportComposeAddService(t *testing.T) {
+	is := is.New(t)
+	data, err := os.ReadFile("testdata/compose/docker-compose.yml")
+	is.NoErr(err)
+	result, err := registry.ImportCompose(data, registry.ImportComposeOptions{RepoName: "example/api"})
+	is.NoErr(err)
+
+	// Imported services must be able to be added to a registry and be valid
+	dir := filepath.Join(t.TempDir(), "my-registry")
+	is.NoErr(registry.Scaffold(dir))
+	is.NoErr(registry.AddServices(dir, result.Services))
+	validateResult := registry.Validate(dir, registry.ValidateOptions{Strict: true})
+	is.Equal(len(validateResult.Problems()), 0)
+
+	// Importing again must fail without changing anything
+	servicesPath := filepath.Join(dir, registry.ServicesFileName)
+	before, err := os.ReadFile(servicesPath)
+	is.NoErr(err)
+	is.True(registry.AddServices(dir, result.Services) != nil)
+	after, err := os.ReadFile(servicesPath)
+	is.NoErr(err)
+	is.Equal(string(after), string(before))
+}
+
+func TestImportComposeInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"not a mapping", "- foo\n"},
+		{"no services", "version: '3.7'\n"},
+		{"invalid yaml", "services: [\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			_, err := registry.ImportCompose([]byte(tt.data), registry.ImportComposeOptions{})
+			is.True(err != nil)
+		})
+	}
+}
@@ -0,0 +1,341 @@
+package registry
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/TouchBistro/goutils/errors"
+	"github.com/TouchBistro/tb/errkind"
+	"github.com/TouchBistro/tb/internal/schema"
+	"github.com/TouchBistro/tb/resource"
+	"gopkg.in/yaml.v3"
+)
+
+// FileError represents an error caused by the contents of a registry file.
+// It is used for errors that are not tied to a single resource, such as
+// a file that could not be decoded.
+type FileError struct {
+	// Pos is the location in the file where the error occurred.
+	// Pos.File is relative to the root of the registry.
+	Pos resource.Position
+	Err error
+}
+
+func (e *FileError) Error() string {
+	return e.Pos.String() + ": " + e.Err.Error()
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// withPos attaches pos to err. If err is a resource.ValidationError, its position is set,
+// otherwise err is wrapped in a FileError.
+func withPos(err error, pos resource.Position) error {
+	var ve *resource.ValidationError
+	if errors.As(err, &ve) {
+		// Copy so we don't modify the original
+		veCopy := *ve
+		veCopy.Pos = pos
+		return &veCopy
+	}
+	return &FileError{Pos: pos, Err: err}
+}
+
+// withFieldPos attaches the position of each field that failed validation to err.
+// If err is a resource.ValidationError with fields, it is split into an error for each message,
+// positioned at the field the message is about. Otherwise err is positioned at src.
+func withFieldPos(err error, src nodeSource) []error {
+	var ve *resource.ValidationError
+	if !errors.As(err, &ve) || len(ve.Fields) != len(ve.Messages) {
+		return []error{withPos(err, src.pos())}
+	}
+	errs := make([]error, len(ve.Messages))
+	for i, msg := range ve.Messages {
+		var path []interface{}
+		for _, p := range strings.Split(ve.Fields[i], ".") {
+			path = append(path, p)
+		}
+		errs[i] = &resource.ValidationError{
+			Resource: ve.Resource,
+			Messages: []string{msg},
+			Fields:   []string{ve.Fields[i]},
+			Pos:      src.fieldPos(path...),
+		}
+	}
+	return errs
+}
+
+// registryFile is a single config file read from a registry.
+type registryFile[T any] struct {
+	path string     // Path to the file relative to the root of the registry.
+	root *yaml.Node // Root node of the document, used to determine positions.
+	data T
+}
+
+// source returns where the mapping entry found by following path from the root of the file is defined.
+// If the entry cannot be found, the returned nodeSource will only point to the file.
+func (f registryFile[T]) source(path ...string) nodeSource {
+	src := nodeSource{file: f.path, value: f.root}
+	for _, p := range path {
+		key, value := mappingEntry(src.value, p)
+		if key == nil {
+			return nodeSource{file: f.path}
+		}
+		src.key, src.value = key, value
+	}
+	return src
+}
+
+// nodeSource records where a value is defined in a registry file.
+type nodeSource struct {
+	file  string
+	key   *yaml.Node // Key node of the value in its parent mapping, may be nil.
+	value *yaml.Node // The value node, may be nil.
+}
+
+// pos returns the position of the key that defines the value.
+func (ns nodeSource) pos() resource.Position {
+	if ns.key == nil {
+		return resource.Position{File: ns.file}
+	}
+	return resource.Position{File: ns.file, Line: ns.key.Line, Column: ns.key.Column}
+}
+
+// fieldPos returns the position of a field nested within the value.
+// Each element of path must either be a string, which is a mapping key,
+// or an int, which is a sequence index.
+// If the field does not exist, the position of the value itself is returned.
+func (ns nodeSource) fieldPos(path ...interface{}) resource.Position {
+	n, _ := ns.field(path...)
+	if n == nil {
+		return ns.pos()
+	}
+	return resource.Position{File: ns.file, Line: n.Line, Column: n.Column}
+}
+
+// itemPos returns the position of the item at index i of the field with name,
+// where the field can be written as either a single value or a list of values.
+// If the field is a single value, the position of the field is returned.
+func (ns nodeSource) itemPos(name string, i int) resource.Position {
+	if n, _ := ns.field(name, i); n != nil {
+		return resource.Position{File: ns.file, Line: n.Line, Column: n.Column}
+	}
+	return ns.fieldPos(name)
+}
+
+// fieldValue returns the raw value of a scalar field nested within the value,
+// i.e. the value as written in the file before any variables are expanded.
+// path has the same format as in fieldPos. If the field does not exist
+// an empty string is returned.
+func (ns nodeSource) fieldValue(path ...interface{}) string {
+	_, v := ns.field(path...)
+	if v == nil {
+		return ""
+	}
+	return v.Value
+}
+
+// field finds the field nested within the value by following path.
+// It returns the node that identifies the field, which is the key node for
+// mapping entries and the item node for sequence items, along with the value node.
+// If the field does not exist, both return values will be nil.
+func (ns nodeSource) field(path ...interface{}) (*yaml.Node, *yaml.Node) {
+	var id *yaml.Node
+	n := ns.value
+	for _, p := range path {
+		switch p := p.(type) {
+		case string:
+			id, n = mappingEntry(n, p)
+		case int:
+			if n == nil || n.Kind != yaml.SequenceNode || p >= len(n.Content) {
+				return nil, nil
+			}
+			n = n.Content[p]
+			id = n
+		default:
+			panic(fmt.Sprintf("registry: invalid field path element type %T", p))
+		}
+		if id == nil {
+			return nil, nil
+		}
+	}
+	return id, n
+}
+
+// mappingEntry returns the key and value nodes for key in the mapping node n.
+// If n is not a mapping or key does not exist, both return values will be nil.
+func mappingEntry(n *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i], n.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// readRegistryFiles reads all the config files of a single kind from the registry r.
+// The config can be provided in the file filename, in any number of YAML files in the
+// directory dirname, or both. Files in dirname are read in lexical order.
+//
+// Any file that fails to be read is recorded in the returned error list and the remaining
+// files are still read. If neither filename nor dirname exist, both return values will be empty.
+//
+// If strict is true, any keys in a file that do not correspond to a known field are
+// also recorded as errors.
+func readRegistryFiles[T any](op errors.Op, filename, dirname string, r Registry, strict bool) ([]registryFile[T], errors.List) {
+	paths := []string{filename}
+	entries, err := os.ReadDir(filepath.Join(r.Path, dirname))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, errors.List{errors.Wrap(err, errors.Meta{
+			Kind:   errkind.IO,
+			Reason: fmt.Sprintf("failed to read directory %s in registry %s", dirname, r.Name),
+			Op:     op,
+		})}
+	}
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != ".yml" && ext != ".yaml") {
+			continue
+		}
+		// Always use forward slashes so paths are reported consistently on every OS.
+		paths = append(paths, dirname+"/"+e.Name())
+	}
+
+	var files []registryFile[T]
+	var errs errors.List
+	for _, p := range paths {
+		var data T
+		root, err := readRegistryFile(op, p, r, &data)
+		if errors.Is(err, fs.ErrNotExist) {
+			// Only filename can be missing, it's fine if it doesn't exist
+			continue
+		} else if err != nil {
+			var el errors.List
+			if errors.As(err, &el) {
+				errs = append(errs, el...)
+			} else {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if strict {
+			for _, uf := range schema.UnknownFields(root, reflect.TypeOf(data)) {
+				errs = append(errs, &FileError{
+					Pos: resource.Position{File: p, Line: uf.Node.Line, Column: uf.Node.Column},
+					Err: errors.New(errkind.Invalid, uf.String(), op),
+				})
+			}
+		}
+		files = append(files, registryFile[T]{path: p, root: root, data: data})
+	}
+	return files, errs
+}
+
+// hasUnreadFiles reports whether any of errs is for a file that could not be read at all,
+// i.e. a file that is not in files. This means some of the resources in the registry are unknown.
+func hasUnreadFiles[T any](files []registryFile[T], errs errors.List) bool {
+	read := make(map[string]bool, len(files))
+	for _, f := range files {
+		read[f.path] = true
+	}
+	for _, err := range errs {
+		var fe *FileError
+		if !errors.As(err, &fe) || !read[fe.Pos.File] {
+			return true
+		}
+	}
+	return false
+}
+
+// notExistError returns an error signifying that neither filename nor dirname exist in the registry r.
+// The error wraps fs.ErrNotExist so it can be checked with errors.Is.
+func notExistError(op errors.Op, filename, dirname string, r Registry) error {
+	return errors.Wrap(fs.ErrNotExist, errors.Meta{
+		Kind:   errkind.IO,
+		Reason: fmt.Sprintf("no %s file or %s directory in registry %s", filename, dirname, r.Name),
+		Op:     op,
+	})
+}
+
+// readRegistryFile is a small helper to read a registry file and unmarshal it.
+// The file is first decoded into a yaml.Node so that the positions of values can be
+// determined. The root node of the document is returned.
+//
+// If the file does not exist, fs.ErrNotExist will be returned which can be checked
+// with errors.Is. If the file cannot be decoded, the returned error will contain
+// a FileError for each problem.
+func readRegistryFile(op errors.Op, filename string, r Registry, v interface{}) (*yaml.Node, error) {
+	fp := filepath.Join(r.Path, filename)
+	f, err := os.Open(fp)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.Meta{
+			Kind:   errkind.IO,
+			Reason: fmt.Sprintf("failed to open file %s in registry %s", filename, r.Name),
+			Op:     op,
+		})
+	}
+	defer f.Close()
+
+	var doc yaml.Node
+	if err := yaml.NewDecoder(f).Decode(&doc); errors.Is(err, io.EOF) {
+		// The file is empty or only contains comments, treat it as an empty config.
+		return nil, nil
+	} else if err != nil {
+		return nil, decodeError(op, filename, r, err)
+	}
+	if err := doc.Decode(v); err != nil {
+		return nil, decodeError(op, filename, r, err)
+	}
+	// The document node always has a single child, which is the actual content.
+	var root *yaml.Node
+	if len(doc.Content) > 0 {
+		root = doc.Content[0]
+	}
+	return root, nil
+}
+
+// yamlLineRegex matches the line number in errors returned by the yaml package.
+var yamlLineRegex = regexp.MustCompile(`line (\d+): `)
+
+// decodeError converts an error from decoding filename into a FileError containing
+// the line where decoding failed. If err contains multiple problems, a FileError
+// is returned for each.
+func decodeError(op errors.Op, filename string, r Registry, err error) error {
+	msgs := []string{err.Error()}
+	var te *yaml.TypeError
+	if errors.As(err, &te) {
+		msgs = te.Errors
+	}
+	var errs errors.List
+	for _, msg := range msgs {
+		pos := resource.Position{File: filename}
+		if m := yamlLineRegex.FindStringSubmatchIndex(msg); m != nil {
+			// Error is already checked by the regex, only digits can match.
+			pos.Line, _ = strconv.Atoi(msg[m[2]:m[3]])
+			msg = msg[:m[0]] + msg[m[1]:]
+		}
+		errs = append(errs, &FileError{
+			Pos: pos,
+			Err: errors.Wrap(errors.String(msg), errors.Meta{
+				Kind:   errkind.IO,
+				Reason: fmt.Sprintf("failed to decode %s in registry %s", filename, r.Name),
+				Op:     op,
+			}),
+		})
+	}
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errs
+}
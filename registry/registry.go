@@ -10,8 +10,8 @@ package registry
 import (
 	"fmt"
 	"io/fs"
-	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 
 	"github.com/TouchBistro/goutils/errors"
@@ -19,12 +19,12 @@ import (
 	"github.com/TouchBistro/goutils/text"
 	"github.com/TouchBistro/tb/errkind"
 	"github.com/TouchBistro/tb/integrations/docker"
+	"github.com/TouchBistro/tb/internal/schema"
 	"github.com/TouchBistro/tb/internal/util"
 	"github.com/TouchBistro/tb/resource"
 	"github.com/TouchBistro/tb/resource/app"
 	"github.com/TouchBistro/tb/resource/playlist"
 	"github.com/TouchBistro/tb/resource/service"
-	"gopkg.in/yaml.v3"
 )
 
 // File names in registry
@@ -35,6 +35,14 @@ const (
 	staticDirName     = "static"        // Directory where additional static assets can be stored.
 )
 
+// Directory names in registry. Each directory can contain any number of YAML files
+// which are merged together along with the corresponding config file.
+const (
+	AppsDirName      = "apps.d"      // Name of the directory containing app config files.
+	PlaylistsDirName = "playlists.d" // Name of the directory containing playlist config files.
+	ServicesDirName  = "services.d"  // Name of the directory containing service config files.
+)
+
 // Registry configures a registry. A registry is a Git repo
 // that contains configuration for services, playlists, and
 // apps that tb can run.
@@ -68,6 +76,11 @@ type ReadAllOptions struct {
 	ReposPath string
 	// Overrides are any overrides that should be applied to services.
 	Overrides map[string]service.ServiceOverride
+	// TbVersion is the version of tb reading the registries. If provided, registries that
+	// declare a minTbVersion that TbVersion does not satisfy will not be read and ErrIncompatible
+	// will be returned. If TbVersion is not a valid version, for example a development build,
+	// the registries are assumed to be compatible.
+	TbVersion string
 	// Logger can be provided to log debug details while reading registries.
 	// If it is nil, logging is off.
 	Logger progress.Logger
@@ -99,6 +112,11 @@ type ReadAllResult struct {
 // All registry files are optional. As a result, ReadAll will not treat a
 // missing registry file as an error but will instead consider it identical
 // to an empty config with no resources.
+//
+// Each type of config can either be provided as a single file, i.e. services.yml,
+// or split across multiple files in a directory, i.e. services.d/, or both.
+// All the files are merged together and it is an error for the same resource
+// to be defined in more than one file.
 func ReadAll(registries []Registry, opts ReadAllOptions) (ReadAllResult, error) {
 	const op = errors.Op("registry.ReadAll")
 	var result ReadAllResult
@@ -115,6 +133,9 @@ func ReadAll(registries []Registry, opts ReadAllOptions) (ReadAllResult, error)
 	}
 
 	for _, r := range registries {
+		if err := checkCompatibility(op, r, opts.TbVersion, opts.Logger); err != nil {
+			return result, err
+		}
 		if opts.ReadServices {
 			opts.Logger.Debugf("Reading services from registry %s", r.Name)
 			globalConf, err := readServices(op, r, readServicesOptions{
@@ -126,7 +147,7 @@ func ReadAll(registries []Registry, opts ReadAllOptions) (ReadAllResult, error)
 			})
 			if errors.Is(err, fs.ErrNotExist) {
 				// No file, do nothing
-				opts.Logger.Debugf("registry %s has no %s", r.Name, ServicesFileName)
+				opts.Logger.Debugf("registry %s has no %s or %s", r.Name, ServicesFileName, ServicesDirName)
 			} else if err != nil {
 				return result, errors.Wrap(err, errors.Meta{
 					Reason: fmt.Sprintf("failed to read services from registry %s", r.Name),
@@ -135,10 +156,10 @@ func ReadAll(registries []Registry, opts ReadAllOptions) (ReadAllResult, error)
 			}
 
 			opts.Logger.Debugf("Reading playlists from registry %s", r.Name)
-			err = readPlaylists(op, r, result.Playlists)
+			_, err = readPlaylists(op, r, result.Playlists, false)
 			if errors.Is(err, fs.ErrNotExist) {
 				// No file, do nothing
-				opts.Logger.Debugf("registry %s has no %s", r.Name, PlaylistsFileName)
+				opts.Logger.Debugf("registry %s has no %s or %s", r.Name, PlaylistsFileName, PlaylistsDirName)
 			} else if err != nil {
 				return result, errors.Wrap(err, errors.Meta{
 					Reason: fmt.Sprintf("failed to read playlists from registry %s", r.Name),
@@ -157,7 +178,7 @@ func ReadAll(registries []Registry, opts ReadAllOptions) (ReadAllResult, error)
 			})
 			if errors.Is(err, fs.ErrNotExist) {
 				// No file, do nothing
-				opts.Logger.Debugf("registry %s has no %s", r.Name, AppsFileName)
+				opts.Logger.Debugf("registry %s has no %s or %s", r.Name, AppsFileName, AppsDirName)
 			} else if err != nil {
 				return result, errors.Wrap(err, errors.Meta{
 					Reason: fmt.Sprintf("failed to read apps from registry %s", r.Name),
@@ -179,7 +200,22 @@ type ValidateOptions struct {
 	// The following additional validations are enabled:
 	//
 	// - Unknown variables will be considered errors.
+	//
+	// - Unknown fields in any config file will be considered errors.
 	Strict bool
+	// ReposPath is the path where cloned repos are stored.
+	// If provided, paths in services that are relative to a repo, i.e. that use ${@REPOPATH},
+	// will be checked to make sure they exist as long as the repo has been cloned.
+	// Paths relative to the static directory of the registry are always checked.
+	ReposPath string
+	// Registries is a list of other registries that the registry being validated can reference.
+	// If provided, references to services and playlists in these registries will be checked
+	// to make sure they exist. Otherwise, any references to other registries are assumed to be valid.
+	// The Path of each registry must be set.
+	//
+	// If one of the registries has the same path as the registry being validated, it is not
+	// considered to be another registry, instead its name is used for the registry being validated.
+	Registries []Registry
 	// Logger can be provided to log debug details while reading registries.
 	// If it is nil, logging is off.
 	Logger progress.Logger
@@ -187,24 +223,72 @@ type ValidateOptions struct {
 
 // ValidateResult is the result returned by Validate.
 // See each field for more details.
+//
+// Errors caused by the contents of a file are either a *resource.ValidationError
+// or a *FileError, both of which contain the position in the file that caused the
+// validation failure. Use Problems to get a flat list of all errors along with their positions.
 type ValidateResult struct {
 	// AppsErr is an error containing details on why the apps config
 	// in the registry failed validation.
 	// If the apps config was valid, AppsErr is nil.
-	// If no apps config file was found, AppsErr will be fs.ErrNotExist.
+	// If no apps config files were found, AppsErr will be fs.ErrNotExist.
 	AppsErr error
 	// PlaylistsErr is an error containing details on why the playlists config
 	// in the registry failed validation.
 	// If the playlists config was valid, PlaylistsErr is nil.
-	// If no apps config file was found, PlaylistsErr will be fs.ErrNotExist.
+	// If no playlists config files were found, PlaylistsErr will be fs.ErrNotExist.
 	PlaylistsErr error
 	// ServicesErr is an error containing details on why the services config
 	// in the registry failed validation.
 	// If the serivces config was valid, ServicesErr is nil.
-	// If no apps config file was found, ServicesErr will be fs.ErrNotExist.
+	// If no services config files were found, ServicesErr will be fs.ErrNotExist.
 	ServicesErr error
 }
 
+// Problem is a single problem found while validating a registry.
+type Problem struct {
+	// Pos is the location of the problem. Pos.File is relative to the root of the registry.
+	// If the location is unknown, Pos will be the zero value.
+	Pos resource.Position
+	// Message describes the problem. It does not contain the position.
+	Message string
+}
+
+// Problems returns a flat list of all the problems found in the registry.
+// Missing config files are not considered problems.
+func (vr ValidateResult) Problems() []Problem {
+	var problems []Problem
+	for _, err := range []error{vr.AppsErr, vr.PlaylistsErr, vr.ServicesErr} {
+		if err == nil || errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		errs := errors.List{err}
+		var el errors.List
+		if errors.As(err, &el) {
+			errs = el
+		}
+		for _, err := range errs {
+			problems = append(problems, newProblem(err))
+		}
+	}
+	return problems
+}
+
+func newProblem(err error) Problem {
+	var ve *resource.ValidationError
+	if errors.As(err, &ve) {
+		// Remove the position from the error so it isn't part of the message
+		veCopy := *ve
+		veCopy.Pos = resource.Position{}
+		return Problem{Pos: ve.Pos, Message: veCopy.Error()}
+	}
+	var fe *FileError
+	if errors.As(err, &fe) {
+		return Problem{Pos: fe.Pos, Message: fe.Err.Error()}
+	}
+	return Problem{Message: err.Error()}
+}
+
 // Validate checks to see if the registry located at path is valid. It will read and validate
 // each configuration file in the registry. path is expected to be a valid file path
 // on the local OS filesystem.
@@ -235,75 +319,128 @@ func Validate(path string, opts ValidateOptions) ValidateResult {
 		Name: "local/" + filepath.Base(absPath),
 		Path: absPath,
 	}
+	var otherRegistries []Registry
+	for _, or := range opts.Registries {
+		if filepath.Clean(or.Path) == absPath {
+			r.Name = or.Name
+			continue
+		}
+		otherRegistries = append(otherRegistries, or)
+	}
 	var result ValidateResult
 	if opts.Logger == nil {
 		opts.Logger = progress.NoopTracker{}
 	}
 	opts.Logger.Debugf("Validating registry %s", r.Name)
 
+	// Read other registries first so references to them can be resolved.
+	var refs references
+	if len(otherRegistries) > 0 {
+		opts.Logger.Debug("Reading other registries to resolve references")
+		others, err := ReadAll(otherRegistries, ReadAllOptions{ReadServices: true, Logger: opts.Logger})
+		if err != nil {
+			err = errors.Wrap(err, errors.Meta{
+				Reason: "unable to read other registries to resolve references",
+				Op:     op,
+			})
+			return ValidateResult{err, err, err}
+		}
+		refs.otherServices = others.Services
+		refs.otherPlaylists = others.Playlists
+	}
+
 	// Validate apps.yml
 	opts.Logger.Debug("Validating apps")
 	err = readApps(op, r, readAppsOptions{
 		iosCollection:     &resource.Collection[app.App]{},
 		desktopCollection: &resource.Collection[app.App]{},
+		strict:            opts.Strict,
 	})
 	if err != nil {
 		result.AppsErr = err
 	}
-	// Validate playlists.yml
-	opts.Logger.Debug("Validating playlists")
-	if err := readPlaylists(op, r, &playlist.Collection{}); err != nil {
-		result.PlaylistsErr = err
-	}
 
 	// Validate services.yml
 	opts.Logger.Debug("Validating services")
 	var services resource.Collection[service.Service]
-	_, err = readServices(op, r, readServicesOptions{
+	globalConf, err := readServices(op, r, readServicesOptions{
 		collection: &services,
+		reposPath:  opts.ReposPath,
 		strict:     opts.Strict,
 	})
-	if err != nil {
+	if errors.Is(err, fs.ErrNotExist) {
+		// If there are no services then references to services in the registry can still be checked.
 		result.ServicesErr = err
+		refs.services = &services
 	} else {
-		// Perform additional validations
-		// Keep track of ports to check for conflicting ports
-		usedPorts := make(map[string]string)
 		var errs errors.List
-		for it := services.Iter(); it.Next(); {
-			s := it.Value()
-			// Check for port conflict. Port conflicts shouldn't be allowed in the same registry
-			// since this just causes confusion and a poor user experience.
-			for _, p := range s.Ports {
-				// ports are of the form EXTERNAL:INTERNAL
-				// get external part
-				exposedPort := strings.Split(p, ":")[0]
-				conflict, ok := usedPorts[exposedPort]
-				if !ok {
-					usedPorts[exposedPort] = s.Name
-					continue
+		if err != nil {
+			var el errors.List
+			if errors.As(err, &el) {
+				errs = append(errs, el...)
+			} else {
+				errs = append(errs, err)
+			}
+		}
+		_, cerrs := readCompatibility(op, r)
+		errs = append(errs, cerrs...)
+
+		// Services that failed validation are not in the collection, but they are still defined
+		// so references to them are valid. If a file could not be read at all, it is not known
+		// which services exist, so references to services in the registry cannot be checked.
+		if !globalConf.incomplete {
+			refs.services = &services
+			refs.invalidServices = make(map[string]bool)
+			for name := range globalConf.serviceSources {
+				if _, err := services.Lookup(name); err != nil {
+					refs.invalidServices[name] = true
 				}
-
-				// Handle port conflict
-				msg := fmt.Sprintf("conflicting port %s with service %s", exposedPort, conflict)
-				errs = append(errs, &resource.ValidationError{
-					Resource: s,
-					Messages: []string{msg},
-				})
 			}
 		}
+		// Perform additional validations on the services that passed validation
+		errs = append(errs, validateServices(r, opts, &services, globalConf.serviceSources, refs)...)
 		if len(errs) > 0 {
 			result.ServicesErr = errs
 		}
 	}
+
+	// Validate playlists.yml
+	// This is done after services so that the services in playlists can be checked.
+	opts.Logger.Debug("Validating playlists")
+	var playlists playlist.Collection
+	playlistSources, err := readPlaylists(op, r, &playlists, opts.Strict)
+	if err != nil {
+		result.PlaylistsErr = err
+	} else {
+		refs.playlists = &playlists
+		errs := validatePlaylists(r, playlistSources, refs)
+		if len(errs) > 0 {
+			result.PlaylistsErr = errs
+		}
+	}
 	return result
 }
 
+// JSONSchemas returns a JSON Schema for each type of registry config file, keyed by
+// the name of the schema, i.e. the config file name without the extension.
+// The schemas can be used by editors to provide validation and autocompletion.
+// Files in services.d, playlists.d, and apps.d use the same schema as the
+// corresponding config file.
+func JSONSchemas() map[string][]byte {
+	return map[string][]byte{
+		"apps":      schema.JSONSchema(reflect.TypeOf(registryAppConfig{}), schema.URL("apps"), "tb registry apps"),
+		"playlists": schema.JSONSchema(reflect.TypeOf(map[string]playlist.Playlist{}), schema.URL("playlists"), "tb registry playlists"),
+		"services":  schema.JSONSchema(reflect.TypeOf(registryServiceConfig{}), schema.URL("services"), "tb registry services"),
+	}
+}
+
 // registryServiceConfig represents a services.yml file in a registry.
 type registryServiceConfig struct {
 	Global struct {
 		BaseImages      []string          `yaml:"baseImages"`
 		LoginStrategies []string          `yaml:"loginStrategies"`
+		MinTbVersion    string            `yaml:"minTbVersion"`
+		SchemaVersion   int               `yaml:"schemaVersion"`
 		Variables       map[string]string `yaml:"variables"`
 	} `yaml:"global"`
 	Services map[string]service.Service `yaml:"services"`
@@ -321,37 +458,73 @@ type readServicesOptions struct {
 type serviceGlobalConfig struct {
 	baseImages      []string
 	loginStrategies []string
+	// serviceSources maps the full name of each service to where it was defined.
+	serviceSources map[string]nodeSource
+	// incomplete is true if some files could not be read, so not all services are known.
+	incomplete bool
 }
 
 // readServices reads the service config from the registry r.
+// If there are errors, the returned serviceGlobalConfig still contains the services that were read,
+// and the services that passed validation are still added to the collection.
 func readServices(op errors.Op, r Registry, opts readServicesOptions) (serviceGlobalConfig, error) {
-	var serviceConf registryServiceConfig
-	err := readRegistryFile(op, ServicesFileName, r, &serviceConf)
-	if err != nil {
-		return serviceGlobalConfig{}, err
+	files, errs := readRegistryFiles[registryServiceConfig](op, ServicesFileName, ServicesDirName, r, opts.strict)
+	if len(files) == 0 && len(errs) == 0 {
+		return serviceGlobalConfig{}, notExistError(op, ServicesFileName, ServicesDirName, r)
 	}
 
-	// Set special vars
-	vars := serviceConf.Global.Variables
-	// If no variables are defined in services.yml the map will be nil
-	if vars == nil {
-		vars = make(map[string]string)
+	// Merge all the files together, making sure that nothing is defined more than once.
+	globalConf := serviceGlobalConfig{
+		serviceSources: make(map[string]nodeSource),
+		incomplete:     hasUnreadFiles(files, errs),
+	}
+	vars := make(map[string]string)
+	varSources := make(map[string]nodeSource)
+	services := make(map[string]service.Service)
+	for _, f := range files {
+		globalConf.baseImages = append(globalConf.baseImages, f.data.Global.BaseImages...)
+		globalConf.loginStrategies = append(globalConf.loginStrategies, f.data.Global.LoginStrategies...)
+		for k, v := range f.data.Global.Variables {
+			src := f.source("global", "variables", k)
+			if prev, ok := varSources[k]; ok {
+				msg := fmt.Sprintf("variable %q is already defined at %s", k, prev.pos())
+				errs = append(errs, &FileError{Pos: src.pos(), Err: errors.New(errkind.Invalid, msg, op)})
+				continue
+			}
+			vars[k] = v
+			varSources[k] = src
+		}
+		for n, s := range f.data.Services {
+			s.Name = n
+			s.RegistryName = r.Name
+			src := f.source("services", n)
+			if prev, ok := globalConf.serviceSources[s.FullName()]; ok {
+				errs = append(errs, &resource.ValidationError{
+					Resource: s,
+					Messages: []string{fmt.Sprintf("already defined at %s", prev.pos())},
+					Pos:      src.pos(),
+				})
+				continue
+			}
+			services[n] = s
+			globalConf.serviceSources[s.FullName()] = src
+		}
 	}
+
+	// Set special vars
 	vars["@ROOTPATH"] = opts.rootPath
 	vars["@STATICPATH"] = filepath.Join(r.Path, staticDirName)
 
 	// Add vars for each service name
-	for name := range serviceConf.Services {
+	for name := range services {
 		fullName := resource.FullName(r.Name, name)
 		vars["@"+name] = docker.NormalizeName(fullName)
 	}
 
-	var errs errors.List
-	for n, s := range serviceConf.Services {
-		s.Name = n
-		s.RegistryName = r.Name
+	for _, s := range services {
+		src := globalConf.serviceSources[s.FullName()]
 		if err := service.Validate(s); err != nil {
-			errs = append(errs, err)
+			errs = append(errs, withFieldPos(err, src)...)
 			continue
 		}
 
@@ -396,12 +569,14 @@ func readServices(op errors.Op, r Registry, opts readServicesOptions) (serviceGl
 			errs = append(errs, &resource.ValidationError{
 				Resource: s,
 				Messages: ve.errMsgs,
+				Pos:      src.pos(),
 			})
 			continue
 		}
 
 		// Apply overrides
 		if ok {
+			var err error
 			s, err = service.Override(s, override)
 			if err != nil {
 				msg := fmt.Sprintf("failed to apply override to service %s", s.FullName())
@@ -415,12 +590,9 @@ func readServices(op errors.Op, r Registry, opts readServicesOptions) (serviceGl
 		}
 	}
 	if len(errs) > 0 {
-		return serviceGlobalConfig{}, errs
+		return globalConf, errs
 	}
-	return serviceGlobalConfig{
-		baseImages:      serviceConf.Global.BaseImages,
-		loginStrategies: serviceConf.Global.LoginStrategies,
-	}, nil
+	return globalConf, nil
 }
 
 // variableExpander is a small helper type which expands variables in a service field.
@@ -453,57 +625,98 @@ func (ve *variableExpander) mapping(name string) string {
 }
 
 // readPlaylists reads the playlist config from the registry r.
-func readPlaylists(op errors.Op, r Registry, collection *playlist.Collection) error {
-	playlistMap := make(map[string]playlist.Playlist)
-	err := readRegistryFile(op, PlaylistsFileName, r, &playlistMap)
-	if err != nil {
-		return err
+// If strict is true, unknown fields are considered errors.
+// It returns a map of the full name of each playlist to where it was defined.
+func readPlaylists(op errors.Op, r Registry, collection *playlist.Collection, strict bool) (map[string]nodeSource, error) {
+	files, errs := readRegistryFiles[map[string]playlist.Playlist](op, PlaylistsFileName, PlaylistsDirName, r, strict)
+	if len(files) == 0 && len(errs) == 0 {
+		return nil, notExistError(op, PlaylistsFileName, PlaylistsDirName, r)
 	}
 
-	var errs errors.List
-	for n, p := range playlistMap {
-		// Set necessary fields for each playlist
-		p.Name = n
-		p.RegistryName = r.Name
-
-		// Make sure extends is a full name
-		if p.Extends != "" {
-			registryName, playlistName, err := resource.ParseName(p.Extends)
-			if err != nil {
-				msg := fmt.Sprintf("failed to resolve full name for extends field of playlist %s", p.FullName())
-				errs = append(errs, errors.Wrap(err, errors.Meta{Reason: msg, Op: op}))
+	playlistSources := make(map[string]nodeSource)
+	for _, f := range files {
+		for n, p := range f.data {
+			// Set necessary fields for each playlist
+			p.Name = n
+			p.RegistryName = r.Name
+			src := f.source(n)
+			if prev, ok := playlistSources[p.FullName()]; ok {
+				errs = append(errs, &resource.ValidationError{
+					Resource: p,
+					Messages: []string{fmt.Sprintf("already defined at %s", prev.pos())},
+					Pos:      src.pos(),
+				})
 				continue
 			}
-			if registryName == "" {
-				p.Extends = resource.FullName(r.Name, playlistName)
+			playlistSources[p.FullName()] = src
+
+			// Make sure each extended playlist is a full name
+			extends := make(playlist.NameList, len(p.Extends))
+			for i, name := range p.Extends {
+				registryName, playlistName, err := resource.ParseName(name)
+				if err != nil {
+					msg := fmt.Sprintf("failed to resolve full name for extends field of playlist %s", p.FullName())
+					errs = append(errs, &FileError{
+						Pos: src.itemPos("extends", i),
+						Err: errors.Wrap(err, errors.Meta{Reason: msg, Op: op}),
+					})
+					continue
+				}
+				if registryName == "" {
+					extends[i] = resource.FullName(r.Name, playlistName)
+				} else {
+					extends[i] = name
+				}
+			}
+			if len(extends) > 0 {
+				p.Extends = extends
 			}
-		}
 
-		// Make sure each service name is the full name
-		serviceNames := make([]string, len(p.Services))
-		for i, name := range p.Services {
-			registryName, serviceName, err := resource.ParseName(name)
-			if err != nil {
-				msg := fmt.Sprintf("failed to resolve full name for service %s in playlist %s", name, p.FullName())
-				errs = append(errs, errors.Wrap(err, errors.Meta{Reason: msg, Op: op}))
-				continue
+			// Make sure each service name is the full name
+			serviceNames := make([]string, len(p.Services))
+			for i, name := range p.Services {
+				registryName, serviceName, err := resource.ParseName(name)
+				if err != nil {
+					msg := fmt.Sprintf("failed to resolve full name for service %s in playlist %s", name, p.FullName())
+					errs = append(errs, &FileError{
+						Pos: src.fieldPos("services", i),
+						Err: errors.Wrap(err, errors.Meta{Reason: msg, Op: op}),
+					})
+					continue
+				}
+				if registryName == "" {
+					serviceNames[i] = resource.FullName(r.Name, serviceName)
+				} else {
+					serviceNames[i] = name
+				}
 			}
-			if registryName == "" {
-				serviceNames[i] = resource.FullName(r.Name, serviceName)
-			} else {
-				serviceNames[i] = name
+			p.Services = serviceNames
+
+			// Make sure each excluded service is a full name so it only excludes services from this registry
+			for i, name := range p.Exclude {
+				registryName, serviceName, err := resource.ParseName(name)
+				if err != nil {
+					msg := fmt.Sprintf("failed to resolve full name for excluded service %s in playlist %s", name, p.FullName())
+					errs = append(errs, &FileError{
+						Pos: src.fieldPos("exclude", i),
+						Err: errors.Wrap(err, errors.Meta{Reason: msg, Op: op}),
+					})
+					continue
+				}
+				if registryName == "" {
+					p.Exclude[i] = resource.FullName(r.Name, serviceName)
+				}
+			}
+			if err := collection.Set(p); err != nil {
+				errs = append(errs, err)
+				continue
 			}
-		}
-		p.Services = serviceNames
-		if err := collection.Set(p); err != nil {
-			errs = append(errs, err)
-			continue
 		}
 	}
 	if len(errs) > 0 {
-		return errs
+		return nil, errs
 	}
-	return nil
+	return playlistSources, nil
 }
 
 // registryAppConfig represents an apps.yml file in a registry.
@@ -515,38 +728,61 @@ type registryAppConfig struct {
 type readAppsOptions struct {
 	iosCollection     *resource.Collection[app.App]
 	desktopCollection *resource.Collection[app.App]
+	strict            bool
 }
 
-// readPlaylists reads the app config from the registry r.
+// readApps reads the app config from the registry r.
 func readApps(op errors.Op, r Registry, opts readAppsOptions) error {
-	var appConf registryAppConfig
-	err := readRegistryFile(op, AppsFileName, r, &appConf)
-	if err != nil {
-		return err
+	files, errs := readRegistryFiles[registryAppConfig](op, AppsFileName, AppsDirName, r, opts.strict)
+	if len(files) == 0 && len(errs) == 0 {
+		return notExistError(op, AppsFileName, AppsDirName, r)
 	}
 
-	var errs errors.List
-	// Deal with iOS apps
-	for n, a := range appConf.IOSApps {
-		a.Name = n
-		a.RegistryName = r.Name
-		if err := app.Validate(a, app.TypeiOS); err != nil {
-			errs = append(errs, err)
-			continue
-		}
-		if err := opts.iosCollection.Set(a); err != nil {
-			errs = append(errs, err)
-			continue
+	iosAppSources := make(map[string]nodeSource)
+	desktopAppSources := make(map[string]nodeSource)
+	for _, f := range files {
+		// Deal with iOS apps
+		for n, a := range f.data.IOSApps {
+			a.Name = n
+			a.RegistryName = r.Name
+			src := f.source("iosApps", n)
+			if prev, ok := iosAppSources[a.FullName()]; ok {
+				errs = append(errs, &resource.ValidationError{
+					Resource: a,
+					Messages: []string{fmt.Sprintf("already defined at %s", prev.pos())},
+					Pos:      src.pos(),
+				})
+				continue
+			}
+			iosAppSources[a.FullName()] = src
+			if err := app.Validate(a, app.TypeiOS); err != nil {
+				errs = append(errs, withFieldPos(err, src)...)
+				continue
+			}
+			if err := opts.iosCollection.Set(a); err != nil {
+				errs = append(errs, err)
+				continue
+			}
 		}
-	}
 
-	// Deal with desktop apps
-	for n, a := range appConf.DesktopApps {
-		a.Name = n
-		a.RegistryName = r.Name
-		if err := opts.desktopCollection.Set(a); err != nil {
-			errs = append(errs, err)
-			continue
+		// Deal with desktop apps
+		for n, a := range f.data.DesktopApps {
+			a.Name = n
+			a.RegistryName = r.Name
+			src := f.source("desktopApps", n)
+			if prev, ok := desktopAppSources[a.FullName()]; ok {
+				errs = append(errs, &resource.ValidationError{
+					Resource: a,
+					Messages: []string{fmt.Sprintf("already defined at %s", prev.pos())},
+					Pos:      src.pos(),
+				})
+				continue
+			}
+			desktopAppSources[a.FullName()] = src
+			if err := opts.desktopCollection.Set(a); err != nil {
+				errs = append(errs, err)
+				continue
+			}
 		}
 	}
 	if len(errs) > 0 {
@@ -554,27 +790,3 @@ func readApps(op errors.Op, r Registry, opts readAppsOptions) error {
 	}
 	return nil
 }
-
-// readRegistryFile is a small helper to read a registry file and unmarshal it.
-// If the file does not exist, fs.ErrNotExist will be returned which can be checked
-// with errors.Is.
-func readRegistryFile(op errors.Op, filename string, r Registry, v interface{}) error {
-	fp := filepath.Join(r.Path, filename)
-	f, err := os.Open(fp)
-	if err != nil {
-		return errors.Wrap(err, errors.Meta{
-			Kind:   errkind.IO,
-			Reason: fmt.Sprintf("failed to open file %s in registry %s", filename, r.Name),
-			Op:     op,
-		})
-	}
-	defer f.Close()
-	if err := yaml.NewDecoder(f).Decode(v); err != nil {
-		return errors.Wrap(err, errors.Meta{
-			Kind:   errkind.IO,
-			Reason: fmt.Sprintf("failed to decode %s in registry %s", filename, r.Name),
-			Op:     op,
-		})
-	}
-	return nil
-}
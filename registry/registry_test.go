@@ -1,7 +1,10 @@
 package registry_test
 
 import (
+	"fmt"
 	"io/fs"
+	"os"
+	"path/filepath"
 	"sort"
 	"testing"
 
@@ -191,7 +194,7 @@ func TestReadRegistries(t *testing.T) {
 	}
 
 	is.Equal(tbCorePlayist, playlist.Playlist{
-		Extends: "TouchBistro/tb-registry/db",
+		Extends: playlist.NameList{"TouchBistro/tb-registry/db"},
 		Services: []string{
 			"TouchBistro/tb-registry/venue-core-service",
 		},
@@ -218,7 +221,7 @@ func TestReadRegistries(t *testing.T) {
 	}
 
 	is.Equal(ezExampleZonePlaylist, playlist.Playlist{
-		Extends: "ExampleZone/tb-registry/core",
+		Extends: playlist.NameList{"ExampleZone/tb-registry/core"},
 		Services: []string{
 			"ExampleZone/tb-registry/venue-example-service",
 		},
@@ -322,3 +325,189 @@ func TestValidateErrors(t *testing.T) {
 	is.Equal(serviceErrs[1].Resource.FullName(), "local/invalid-registry-1/venue-core-service")
 	is.Equal(serviceErrs[2].Resource.FullName(), "local/invalid-registry-1/venue-example-service")
 }
+
+func TestReadRegistriesSplitFiles(t *testing.T) {
+	is := is.New(t)
+	registries := []registry.Registry{
+		{
+			Name: "TouchBistro/tb-registry",
+			Path: "testdata/registry-3",
+		},
+	}
+	result, err := registry.ReadAll(registries, registry.ReadAllOptions{
+		ReadApps:     true,
+		ReadServices: true,
+		RootPath:     "/home/test/.tb",
+		ReposPath:    "/home/test/.tb/repos",
+	})
+	is.NoErr(err)
+
+	sort.Strings(result.BaseImages)
+	sort.Strings(result.LoginStrategies)
+	is.Equal(result.BaseImages, []string{"alpine-node", "swift"})
+	is.Equal(result.LoginStrategies, []string{"ecr", "npm"})
+	is.Equal(result.Services.Len(), 3)
+
+	// Variables and service names from services.yml must be usable in services.d.
+	payments, err := result.Services.Get("payments-service")
+	is.NoErr(err)
+	is.Equal(payments, service.Service{
+		Dependencies: []string{"touchbistro-tb-registry-postgres"},
+		EnvVars: map[string]string{
+			"DB_HOST": "touchbistro-tb-registry-postgres",
+		},
+		Mode:  service.ModeRemote,
+		Ports: []string{"8080:8080"},
+		Remote: service.Remote{
+			Image: "12345.dkr.ecr.us-east-1.amazonaws.com/payments-service",
+			Tag:   "master",
+		},
+		Name:         "payments-service",
+		RegistryName: "TouchBistro/tb-registry",
+	})
+	_, err = result.Services.Get("redis")
+	is.NoErr(err)
+
+	list, err := result.Playlists.ServiceNames("payments")
+	is.NoErr(err)
+	is.Equal(list, []string{
+		"TouchBistro/tb-registry/postgres",
+		"TouchBistro/tb-registry/redis",
+		"TouchBistro/tb-registry/payments-service",
+	})
+
+	is.Equal(result.IOSApps.Len(), 1)
+	is.Equal(result.DesktopApps.Len(), 1)
+}
+
+func TestValidateSplitFilesErrors(t *testing.T) {
+	is := is.New(t)
+	result := registry.Validate("testdata/invalid-registry-2", registry.ValidateOptions{
+		Strict: true,
+	})
+	is.True(errors.Is(result.AppsErr, fs.ErrNotExist))
+	is.True(errors.Is(result.PlaylistsErr, fs.ErrNotExist))
+
+	problems := result.Problems()
+	sort.Slice(problems, func(i, j int) bool {
+		return problems[i].Pos.Line < problems[j].Pos.Line
+	})
+	is.Equal(problems, []registry.Problem{
+		{
+			Pos:     resource.Position{File: "services.d/db.yml", Line: 3, Column: 5},
+			Message: `invalid operation: variable "ecr" is already defined at services.yml:3:5`,
+		},
+		{
+			Pos:     resource.Position{File: "services.yml", Line: 4, Column: 3},
+			Message: `invalid operation: invalid minTbVersion "abc": Could not get version from string: "abc"`,
+		},
+		{
+			Pos:     resource.Position{File: "services.d/db.yml", Line: 6, Column: 3},
+			Message: "service: local/invalid-registry-2/postgres: already defined at services.yml:7:3",
+		},
+		// Services that passed validation are still checked against each other,
+		// and the dependency of api on redis is valid even though redis is not.
+		{
+			Pos:     resource.Position{File: "services.yml", Line: 10, Column: 9},
+			Message: "service: local/invalid-registry-2/postgres: conflicting port 5432 with service api",
+		},
+		{
+			Pos:     resource.Position{File: "services.d/db.yml", Line: 12, Column: 5},
+			Message: `service: local/invalid-registry-2/redis: invalid 'mode' value "local", must be 'remote' or 'build'`,
+		},
+		{
+			Pos:     resource.Position{File: "services.d/db.yml", Line: 22, Column: 7},
+			Message: "service: local/invalid-registry-2/memcached: invalid 'timeouts.build' value -5, must not be negative",
+		},
+	})
+}
+
+func TestValidateDecodeErrors(t *testing.T) {
+	is := is.New(t)
+	result := registry.Validate("testdata/invalid-registry-3", registry.ValidateOptions{})
+	is.True(errors.Is(result.AppsErr, fs.ErrNotExist))
+
+	var errs errors.List
+	var fe *registry.FileError
+	is.True(errors.As(result.PlaylistsErr, &errs))
+	is.Equal(len(errs), 1)
+	is.True(errors.As(errs[0], &fe))
+	is.Equal(fe.Pos, resource.Position{File: "playlists.yml", Line: 4})
+
+	problems := result.Problems()
+	is.Equal(len(problems), 3)
+	is.Equal(problems[0].Pos, resource.Position{File: "playlists.yml", Line: 4})
+	// Each unmarshal error should be reported separately
+	is.Equal(problems[1].Pos, resource.Position{File: "services.yml", Line: 4})
+	is.Equal(problems[2].Pos, resource.Position{File: "services.yml", Line: 8})
+}
+
+func TestValidateUnknownFields(t *testing.T) {
+	is := is.New(t)
+	// Unknown fields are only errors in strict mode
+	result := registry.Validate("testdata/invalid-registry-4", registry.ValidateOptions{})
+	is.Equal(len(result.Problems()), 0)
+
+	result = registry.Validate("testdata/invalid-registry-4", registry.ValidateOptions{
+		Strict: true,
+	})
+	var got []string
+	for _, p := range result.Problems() {
+		got = append(got, fmt.Sprintf("%s: %s", p.Pos, p.Message))
+	}
+	sort.Strings(got)
+	is.Equal(got, []string{
+		`apps.yml:7:5: invalid operation: unknown field "colour" in desktopApps.TBApp`,
+		`playlists.yml:4:3: invalid operation: unknown field "extend" in db, did you mean "extends"?`,
+		`services.yml:14:11: invalid operation: unknown field "isNamed" in services.postgres.remote.volumes[0], did you mean "named"?`,
+		`services.yml:2:3: invalid operation: unknown field "baseImage" in global, did you mean "baseImages"?`,
+		`services.yml:6:5: invalid operation: unknown field "envVar" in services.postgres, did you mean "envVars"?`,
+	})
+}
+
+func TestJSONSchemas(t *testing.T) {
+	is := is.New(t)
+	// Make sure the published schemas are up to date. Run 'make schemas' if this fails.
+	for name, s := range registry.JSONSchemas() {
+		want, err := os.ReadFile(filepath.Join("../schemas", name+".schema.json"))
+		is.NoErr(err)
+		is.Equal(string(s), string(want))
+	}
+}
+
+func TestValidateReferences(t *testing.T) {
+	is := is.New(t)
+	problems := func(result registry.ValidateResult) []string {
+		var got []string
+		for _, p := range result.Problems() {
+			got = append(got, fmt.Sprintf("%s: %s", p.Pos, p.Message))
+		}
+		return got
+	}
+
+	// Without other registries, references to them cannot be checked
+	result := registry.Validate("testdata/invalid-registry-5", registry.ValidateOptions{})
+	is.True(errors.Is(result.AppsErr, fs.ErrNotExist))
+	is.Equal(problems(result), []string{
+		"playlists.yml:11:3: playlist: local/invalid-registry-5/a: extends: cycle detected: local/invalid-registry-5/a -> local/invalid-registry-5/b -> local/invalid-registry-5/a",
+		"playlists.yml:13:3: playlist: local/invalid-registry-5/b: extends: cycle detected: local/invalid-registry-5/b -> local/invalid-registry-5/a -> local/invalid-registry-5/b",
+		"playlists.yml:5:7: playlist: local/invalid-registry-5/core: services: local/invalid-registry-5/redis is not a known service",
+		"playlists.yml:17:3: playlist: local/invalid-registry-5/d: extends: local/invalid-registry-5/missing is not a known playlist",
+		"playlists.yml:25:7: playlist: local/invalid-registry-5/f: extends: local/invalid-registry-5/missing is not a known playlist, exclude: local/invalid-registry-5/nope is not a known service",
+		"playlists.yml:29:3: playlist: local/invalid-registry-5/g: extends: cycle detected: local/invalid-registry-5/g -> local/invalid-registry-5/h -> local/invalid-registry-5/g",
+		"playlists.yml:33:3: playlist: local/invalid-registry-5/h: extends: cycle detected: local/invalid-registry-5/h -> local/invalid-registry-5/g -> local/invalid-registry-5/h",
+		`services.yml:2:3: service: local/invalid-registry-5/postgres: container name "local-invalid-registry-5-postgres" conflicts with service local/invalid-registry-5/Postgres`,
+		`services.yml:15:9: service: local/invalid-registry-5/venue-core-service: dependencies: "${@redis}" is not a known service, envFile: "${@STATICPATH}/venue-core-service.env" does not exist`,
+	})
+
+	// With other registries, references to them are resolved
+	result = registry.Validate("testdata/invalid-registry-5", registry.ValidateOptions{
+		Registries: []registry.Registry{
+			{Name: "TouchBistro/tb-registry", Path: "testdata/registry-3"},
+		},
+	})
+	got := problems(result)
+	is.Equal(len(got), 10)
+	is.Equal(got[4], "playlists.yml:21:7: playlist: local/invalid-registry-5/e: services: TouchBistro/tb-registry/missing is not a known service")
+	is.Equal(got[9], `services.yml:15:9: service: local/invalid-registry-5/venue-core-service: dependencies: "${@redis}" is not a known service, dependencies: "touchbistro-tb-registry-missing" is not a known service, envFile: "${@STATICPATH}/venue-core-service.env" does not exist`)
+}
@@ -0,0 +1,257 @@
+package registry
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/TouchBistro/goutils/errors"
+	"github.com/TouchBistro/goutils/file"
+	"github.com/TouchBistro/tb/errkind"
+	"github.com/TouchBistro/tb/resource"
+	"github.com/TouchBistro/tb/resource/service"
+	"gopkg.in/yaml.v3"
+)
+
+// templateDir is the directory in templateFS containing the files for a new registry.
+const templateDir = "template"
+
+//go:embed template
+var templateFS embed.FS
+
+// Scaffold creates a new registry in dir containing config files with commented examples
+// of every supported field, along with a static directory. dir will be created if it does
+// not exist. If any of the files to create already exist in dir, an error will be returned
+// and no files will be created.
+func Scaffold(dir string) error {
+	const op = errors.Op("registry.Scaffold")
+	var paths []string
+	err := fs.WalkDir(templateFS, templateDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		// Should never happen since the files are embedded
+		return errors.Wrap(err, errors.Meta{Kind: errkind.Internal, Op: op})
+	}
+
+	// Check everything first so that we don't leave behind a partial registry.
+	for _, p := range paths {
+		rel, _ := filepath.Rel(templateDir, filepath.FromSlash(p))
+		if dst := filepath.Join(dir, rel); file.Exists(dst) {
+			return errors.New(errkind.Invalid, fmt.Sprintf("%s already exists", dst), op)
+		}
+	}
+	for _, p := range paths {
+		rel, _ := filepath.Rel(templateDir, filepath.FromSlash(p))
+		dst := filepath.Join(dir, rel)
+		data, err := templateFS.ReadFile(p)
+		if err != nil {
+			return errors.Wrap(err, errors.Meta{Kind: errkind.Internal, Op: op})
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return errors.Wrap(err, errors.Meta{
+				Kind:   errkind.IO,
+				Reason: fmt.Sprintf("failed to create directory %s", filepath.Dir(dst)),
+				Op:     op,
+			})
+		}
+		if err := os.WriteFile(dst, data, 0o644); err != nil {
+			return errors.Wrap(err, errors.Meta{
+				Kind:   errkind.IO,
+				Reason: fmt.Sprintf("failed to create %s", dst),
+				Op:     op,
+			})
+		}
+	}
+	return nil
+}
+
+// AddService adds the service s to the services.yml file of the registry at path.
+// s.Name must be set and s must be valid. If a service with the same name is already
+// defined in any of the registry's service config files, an error will be returned.
+//
+// The file is edited in place so that any comments and the order of existing fields
+// are preserved. If services.yml does not exist it will be created.
+func AddService(path string, s service.Service) error {
+	return addServices(errors.Op("registry.AddService"), path, []service.Service{s})
+}
+
+// AddServices adds multiple services to the services.yml file of the registry at path like AddService.
+// All services are checked before any are added, so if any service is invalid or already defined,
+// either in the registry or earlier in services, none are added. The returned error will be an
+// errors.List with an error for each problem.
+func AddServices(path string, services []service.Service) error {
+	return addServices(errors.Op("registry.AddServices"), path, services)
+}
+
+func addServices(op errors.Op, path string, services []service.Service) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return errors.Wrap(err, errors.Meta{
+			Kind:   errkind.IO,
+			Reason: "unable to resolve absolute path to registry",
+			Op:     op,
+		})
+	}
+	r := Registry{Name: "local/" + filepath.Base(absPath), Path: absPath}
+	files, errs := readRegistryFiles[registryServiceConfig](op, ServicesFileName, ServicesDirName, r, false)
+	if len(errs) > 0 {
+		return errs
+	}
+
+	// Check every service before changing anything so that nothing is added if there are problems.
+	// Copy services since the registry name is set on each one.
+	services = append([]service.Service(nil), services...)
+	added := make(map[string]bool)
+	for i, s := range services {
+		if registryName, _, err := resource.ParseName(s.Name); err != nil || registryName != "" {
+			errs = append(errs, errors.New(errkind.Invalid, fmt.Sprintf("invalid service name %q", s.Name), op))
+			continue
+		}
+		s.RegistryName = r.Name
+		services[i] = s
+		if err := service.Validate(s); err != nil {
+			errs = append(errs, errors.Wrap(err, errors.Meta{Op: op}))
+			continue
+		}
+
+		// Make sure the service doesn't already exist in any file.
+		if added[s.Name] {
+			errs = append(errs, &resource.ValidationError{Resource: s, Messages: []string{"defined more than once"}})
+			continue
+		}
+		added[s.Name] = true
+		for _, f := range files {
+			if _, ok := f.data.Services[s.Name]; ok {
+				errs = append(errs, &resource.ValidationError{
+					Resource: s,
+					Messages: []string{fmt.Sprintf("already defined at %s", f.source("services", s.Name).pos())},
+				})
+				break
+			}
+		}
+	}
+	if len(errs) == 1 {
+		return errs[0]
+	} else if len(errs) > 0 {
+		return errs
+	}
+
+	// Decode into a Node so we can manipulate the contents while
+	// preserving comments and ordering
+	fp := filepath.Join(r.Path, ServicesFileName)
+	var doc yaml.Node
+	data, err := os.ReadFile(fp)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return errors.Wrap(err, errors.Meta{
+			Kind:   errkind.IO,
+			Reason: fmt.Sprintf("failed to read %s", fp),
+			Op:     op,
+		})
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return errors.Wrap(err, errors.Meta{
+			Kind:   errkind.IO,
+			Reason: fmt.Sprintf("couldn't read yaml file at %s", fp),
+			Op:     op,
+		})
+	}
+	if doc.Kind == 0 {
+		// Empty or missing file
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return errors.New(errkind.Invalid, fmt.Sprintf("%s does not contain a mapping", fp), op)
+	}
+
+	_, servicesNode := mappingEntry(root, "services")
+	if servicesNode == nil {
+		servicesNode = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "services"}, servicesNode)
+	} else if servicesNode.Tag == "!!null" {
+		// !!null means there are no services defined, i.e. empty key
+		// Update the node to be a mapping so the service can be added to it.
+		servicesNode.Kind = yaml.MappingNode
+		servicesNode.Tag = "!!map"
+		servicesNode.Value = ""
+	}
+
+	for _, s := range services {
+		var serviceNode yaml.Node
+		if err := serviceNode.Encode(s); err != nil {
+			return errors.Wrap(err, errors.Meta{Kind: errkind.Internal, Reason: "failed to encode service", Op: op})
+		}
+		pruneEmpty(&serviceNode)
+		servicesNode.Content = append(
+			servicesNode.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s.Name},
+			&serviceNode,
+		)
+	}
+
+	f, err := os.Create(fp)
+	if err != nil {
+		return errors.Wrap(err, errors.Meta{
+			Kind:   errkind.IO,
+			Reason: fmt.Sprintf("failed to open file %s", fp),
+			Op:     op,
+		})
+	}
+	defer f.Close()
+	encoder := yaml.NewEncoder(f)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return errors.Wrap(err, errors.Meta{
+			Kind:   errkind.IO,
+			Reason: fmt.Sprintf("failed to write %s", fp),
+			Op:     op,
+		})
+	}
+	return nil
+}
+
+// pruneEmpty removes all entries with empty values from the mapping node n and any nested nodes.
+// This makes encoded resources more readable since fields are not marked omitempty.
+func pruneEmpty(n *yaml.Node) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		var content []*yaml.Node
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			pruneEmpty(v)
+			if isEmptyNode(v) {
+				continue
+			}
+			content = append(content, k, v)
+		}
+		n.Content = content
+	case yaml.SequenceNode:
+		for _, item := range n.Content {
+			pruneEmpty(item)
+		}
+	}
+}
+
+func isEmptyNode(n *yaml.Node) bool {
+	switch n.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		return len(n.Content) == 0
+	case yaml.ScalarNode:
+		switch n.Tag {
+		case "!!null":
+			return true
+		case "!!str":
+			return n.Value == ""
+		case "!!bool":
+			return n.Value == "false"
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package registry_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/TouchBistro/goutils/errors"
+	"github.com/TouchBistro/tb/registry"
+	"github.com/TouchBistro/tb/resource/app"
+	"github.com/TouchBistro/tb/resource/playlist"
+	"github.com/TouchBistro/tb/resource/service"
+	"github.com/matryer/is"
+)
+
+func TestScaffold(t *testing.T) {
+	is := is.New(t)
+	dir := filepath.Join(t.TempDir(), "my-registry")
+	is.NoErr(registry.Scaffold(dir))
+	is.True(dirExists(filepath.Join(dir, "static")))
+
+	// The scaffolded registry must be valid
+	result := registry.Validate(dir, registry.ValidateOptions{Strict: true})
+	is.Equal(len(result.Problems()), 0)
+
+	// Every field should have an example
+	for file, types := range map[string][]reflect.Type{
+		registry.ServicesFileName:  {reflect.TypeOf(service.Service{})},
+		registry.PlaylistsFileName: {reflect.TypeOf(playlist.Playlist{})},
+		registry.AppsFileName:      {reflect.TypeOf(app.App{})},
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		is.NoErr(err)
+		for _, name := range yamlFieldNames(types[0]) {
+			if !strings.Contains(string(data), name+":") {
+				t.Errorf("%s has no example of field %s", file, name)
+			}
+		}
+	}
+
+	// Scaffolding again must fail since the files exist
+	is.True(registry.Scaffold(dir) != nil)
+}
+
+func TestAddService(t *testing.T) {
+	is := is.New(t)
+	dir := filepath.Join(t.TempDir(), "my-registry")
+	is.NoErr(registry.Scaffold(dir))
+
+	s := service.Service{
+		Name: "postgres",
+		Mode: service.ModeRemote,
+		Remote: service.Remote{
+			Image: "postgres",
+			Tag:   "12",
+		},
+		Ports: []string{"5432:5432"},
+	}
+	is.NoErr(registry.AddService(dir, s))
+	data, err := os.ReadFile(filepath.Join(dir, registry.ServicesFileName))
+	is.NoErr(err)
+	// Comments must be preserved
+	is.True(strings.Contains(string(data), "# Configuration for the services in this registry."))
+	is.True(strings.Contains(string(data), `services:
+  postgres:
+    mode: remote
+    ports:
+      - 5432:5432
+    remote:
+      image: postgres
+      tag: "12"
+`))
+
+	result := registry.Validate(dir, registry.ValidateOptions{Strict: true})
+	is.Equal(len(result.Problems()), 0)
+
+	// Adding the same service again is an error
+	is.True(registry.AddService(dir, s) != nil)
+	// Invalid services can't be added
+	is.True(registry.AddService(dir, service.Service{Name: "redis", Mode: service.ModeBuild}) != nil)
+}
+
+func TestAddServices(t *testing.T) {
+	is := is.New(t)
+	dir := filepath.Join(t.TempDir(), "my-registry")
+	is.NoErr(registry.Scaffold(dir))
+	servicesPath := filepath.Join(dir, registry.ServicesFileName)
+	before, err := os.ReadFile(servicesPath)
+	is.NoErr(err)
+
+	postgres := service.Service{Name: "postgres", Mode: service.ModeRemote, Remote: service.Remote{Image: "postgres"}}
+	redis := service.Service{Name: "redis", Mode: service.ModeRemote, Remote: service.Remote{Image: "redis"}}
+
+	// Nothing is added if any service is invalid or defined more than once
+	err = registry.AddServices(dir, []service.Service{postgres, {Name: "api", Mode: service.ModeBuild}, redis, redis})
+	var errs errors.List
+	is.True(errors.As(err, &errs))
+	is.Equal(len(errs), 2)
+	is.True(strings.Contains(errs[1].Error(), "redis: defined more than once"))
+	after, err := os.ReadFile(servicesPath)
+	is.NoErr(err)
+	is.Equal(string(after), string(before))
+
+	is.NoErr(registry.AddServices(dir, []service.Service{postgres, redis}))
+	result := registry.Validate(dir, registry.ValidateOptions{Strict: true})
+	is.Equal(len(result.Problems()), 0)
+	after, err = os.ReadFile(servicesPath)
+	is.NoErr(err)
+	is.True(strings.Contains(string(after), "\n  postgres:\n"))
+	is.True(strings.Contains(string(after), "\n  redis:\n"))
+}
+
+func dirExists(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.IsDir()
+}
+
+// yamlFieldNames returns the names of all fields in the struct type t and any nested structs.
+func yamlFieldNames(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		names = append(names, name)
+		ft := f.Type
+		for ft.Kind() == reflect.Slice || ft.Kind() == reflect.Map {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			names = append(names, yamlFieldNames(ft)...)
+		}
+	}
+	return names
+}
@@ -0,0 +1,286 @@
+package registry
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/TouchBistro/goutils/errors"
+	"github.com/TouchBistro/goutils/file"
+	"github.com/TouchBistro/tb/integrations/docker"
+	"github.com/TouchBistro/tb/resource"
+	"github.com/TouchBistro/tb/resource/playlist"
+	"github.com/TouchBistro/tb/resource/service"
+)
+
+// references contains the resources that can be referenced by a registry being validated.
+// A nil collection means the resources are unknown and references to them cannot be checked.
+type references struct {
+	services  *resource.Collection[service.Service]
+	playlists *playlist.Collection
+	// invalidServices contains the full names of the services in the registry that are defined
+	// but failed validation, so they are not in services. References to them are still valid.
+	invalidServices map[string]bool
+	// Resources in other registries, only set if other registries were provided.
+	otherServices  *resource.Collection[service.Service]
+	otherPlaylists *playlist.Collection
+}
+
+// hasService reports whether the service with fullName exists and whether it could be checked.
+func (refs references) hasService(registryName, fullName string) (found, ok bool) {
+	c := refs.otherServices
+	if strings.HasPrefix(fullName, registryName+"/") {
+		c = refs.services
+	}
+	if c == nil {
+		return false, false
+	}
+	if c == refs.services && refs.invalidServices[fullName] {
+		return true, true
+	}
+	_, err := c.Lookup(fullName)
+	return err == nil, true
+}
+
+// playlist returns the playlist with fullName and whether it could be checked.
+// If the playlist does not exist, the zero value is returned.
+func (refs references) playlist(registryName, fullName string) (p playlist.Playlist, found, ok bool) {
+	c := refs.otherPlaylists
+	if strings.HasPrefix(fullName, registryName+"/") {
+		c = refs.playlists
+	}
+	if c == nil {
+		return p, false, false
+	}
+	p, err := c.Lookup(fullName)
+	return p, err == nil, true
+}
+
+// validateServices performs validations on the services in the collection c, which were read
+// from the registry r, that require knowledge of other services. sources contains where each
+// service is defined. Dependencies are only checked if refs.services is set.
+func validateServices(r Registry, opts ValidateOptions, c *resource.Collection[service.Service], sources map[string]nodeSource, refs references) errors.List {
+	// Sort services so that errors are reported in a consistent order.
+	var services []service.Service
+	for it := c.Iter(); it.Next(); {
+		services = append(services, it.Value())
+	}
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].FullName() < services[j].FullName()
+	})
+
+	// Dependencies refer to services by their container name, since that is what
+	// ${@service} expands to. Keep track of them to check dependencies and also
+	// make sure no two services end up with the same container.
+	containerNames := make(map[string]string)
+	if refs.otherServices != nil {
+		for it := refs.otherServices.Iter(); it.Next(); {
+			s := it.Value()
+			containerNames[docker.NormalizeName(s.FullName())] = s.FullName()
+		}
+	}
+	var errs errors.List
+	for _, s := range services {
+		name := docker.NormalizeName(s.FullName())
+		if conflict, ok := containerNames[name]; ok {
+			msg := fmt.Sprintf("container name %q conflicts with service %s", name, conflict)
+			errs = append(errs, &resource.ValidationError{
+				Resource: s,
+				Messages: []string{msg},
+				Pos:      sources[s.FullName()].pos(),
+			})
+			continue
+		}
+		containerNames[name] = s.FullName()
+	}
+	for fullName := range refs.invalidServices {
+		if name := docker.NormalizeName(fullName); containerNames[name] == "" {
+			containerNames[name] = fullName
+		}
+	}
+
+	// Keep track of ports to check for conflicting ports
+	usedPorts := make(map[string]string)
+	staticPath := filepath.Join(r.Path, staticDirName)
+	for _, s := range services {
+		src := sources[s.FullName()]
+		var msgs []string
+		var pos resource.Position
+		addErr := func(msg string, field ...interface{}) {
+			// Report the position of the first problem, the rest are in the messages.
+			if msgs == nil {
+				pos = src.fieldPos(field...)
+			}
+			msgs = append(msgs, msg)
+		}
+
+		// Check for port conflict. Port conflicts shouldn't be allowed in the same registry
+		// since this just causes confusion and a poor user experience.
+		for i, p := range s.Ports {
+			// ports are of the form EXTERNAL:INTERNAL
+			// get external part
+			exposedPort := strings.Split(p, ":")[0]
+			conflict, ok := usedPorts[exposedPort]
+			if !ok {
+				usedPorts[exposedPort] = s.Name
+				continue
+			}
+			addErr(fmt.Sprintf("conflicting port %s with service %s", exposedPort, conflict), "ports", i)
+		}
+
+		for i, dep := range s.Dependencies {
+			if refs.services == nil {
+				// Not all services in the registry are known.
+				break
+			}
+			if _, ok := containerNames[dep]; ok {
+				continue
+			}
+			raw := src.fieldValue("dependencies", i)
+			// A dependency that doesn't use a variable could be the container name of a service
+			// in another registry. Only report it if other registries are known.
+			if refs.otherServices == nil && !strings.Contains(raw, "${") {
+				continue
+			}
+			addErr(fmt.Sprintf("dependencies: %q is not a known service", raw), "dependencies", i)
+		}
+
+		// Check that any paths to files exist. Only paths in the registry's static directory
+		// or in a cloned repo can be checked, anything else is outside of tb's control.
+		var repoPath string
+		if opts.ReposPath != "" && s.HasGitRepo() {
+			repoPath = filepath.Join(opts.ReposPath, s.GitRepo.Name)
+			if !file.Exists(repoPath) {
+				repoPath = ""
+			}
+		}
+		checkPath := func(path string, field ...interface{}) {
+			if path == "" {
+				return
+			}
+			inStatic := pathContains(staticPath, path)
+			inRepo := repoPath != "" && pathContains(repoPath, path)
+			if (inStatic || inRepo) && !file.Exists(path) {
+				addErr(fmt.Sprintf("%s: %q does not exist", fieldName(field), src.fieldValue(field...)), field...)
+			}
+		}
+		checkPath(s.Build.DockerfilePath, "build", "dockerfilePath")
+		checkPath(s.EnvFile, "envFile")
+		for i, v := range s.Build.Volumes {
+			if !v.IsNamed {
+				checkPath(strings.Split(v.Value, ":")[0], "build", "volumes", i, "value")
+			}
+		}
+		for i, v := range s.Remote.Volumes {
+			if !v.IsNamed {
+				checkPath(strings.Split(v.Value, ":")[0], "remote", "volumes", i, "value")
+			}
+		}
+
+		if len(msgs) > 0 {
+			errs = append(errs, &resource.ValidationError{Resource: s, Messages: msgs, Pos: pos})
+		}
+	}
+	return errs
+}
+
+// validatePlaylists performs validations on the playlists in the registry r that require
+// knowledge of other resources. sources contains where each playlist is defined.
+func validatePlaylists(r Registry, sources map[string]nodeSource, refs references) errors.List {
+	names := refs.playlists.Names()
+	sort.Strings(names)
+	var errs errors.List
+	for _, n := range names {
+		p, _, _ := refs.playlist(r.Name, n)
+		src := sources[n]
+		var msgs []string
+		var pos resource.Position
+		for i, sn := range p.Services {
+			if found, ok := refs.hasService(r.Name, sn); ok && !found {
+				if msgs == nil {
+					pos = src.fieldPos("services", i)
+				}
+				msgs = append(msgs, fmt.Sprintf("services: %s is not a known service", sn))
+			}
+		}
+		for i, name := range p.Extends {
+			if _, found, ok := refs.playlist(r.Name, name); ok && !found {
+				if msgs == nil {
+					pos = src.itemPos("extends", i)
+				}
+				msgs = append(msgs, fmt.Sprintf("extends: %s is not a known playlist", name))
+			}
+		}
+		for i, sn := range p.Exclude {
+			if found, ok := refs.hasService(r.Name, sn); ok && !found {
+				if msgs == nil {
+					pos = src.fieldPos("exclude", i)
+				}
+				msgs = append(msgs, fmt.Sprintf("exclude: %s is not a known service", sn))
+			}
+		}
+		if cycle := extendsCycle(r.Name, p, refs); cycle != nil {
+			if msgs == nil {
+				pos = src.fieldPos("extends")
+			}
+			msgs = append(msgs, fmt.Sprintf("extends: cycle detected: %s", strings.Join(cycle, " -> ")))
+		}
+		if len(msgs) > 0 {
+			errs = append(errs, &resource.ValidationError{Resource: p, Messages: msgs, Pos: pos})
+		}
+	}
+	return errs
+}
+
+// extendsCycle follows the playlists extended by p and returns the chain of playlists
+// that leads back to p if there is a cycle, otherwise nil. Only cycles that p is part of
+// are returned, cycles further up are reported by the playlists in them.
+func extendsCycle(registryName string, p playlist.Playlist, refs references) []string {
+	visited := make(map[string]bool)
+	var visit func(cur playlist.Playlist, chain []string) []string
+	visit = func(cur playlist.Playlist, chain []string) []string {
+		for _, name := range cur.Extends {
+			if name == p.FullName() {
+				return append(chain, name)
+			}
+			if visited[name] {
+				continue
+			}
+			visited[name] = true
+			next, found, ok := refs.playlist(registryName, name)
+			if !ok || !found {
+				// Missing playlists are reported on the playlist that extends them.
+				continue
+			}
+			if cycle := visit(next, append(chain, name)); cycle != nil {
+				return cycle
+			}
+		}
+		return nil
+	}
+	return visit(p, []string{p.FullName()})
+}
+
+// pathContains reports whether path is dir or is contained in dir.
+func pathContains(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// fieldName returns the name of a field from a path as used in nodeSource.fieldPos.
+func fieldName(path []interface{}) string {
+	var sb strings.Builder
+	for _, p := range path {
+		switch p := p.(type) {
+		case string:
+			if sb.Len() > 0 {
+				sb.WriteByte('.')
+			}
+			sb.WriteString(p)
+		case int:
+			fmt.Fprintf(&sb, "[%d]", p)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,131 @@
+package registry
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/TouchBistro/goutils/errors"
+	"github.com/TouchBistro/goutils/progress"
+	"github.com/TouchBistro/tb/errkind"
+	"github.com/blang/semver/v4"
+)
+
+// SchemaVersion is the latest version of the registry format supported by this version of tb.
+// Registries can declare the version they use with global.schemaVersion in services.yml.
+// It must be incremented whenever a change is made to the format of registries that older
+// versions of tb cannot understand, so that they refuse to read the registry instead of
+// silently ignoring parts of it.
+const SchemaVersion = 1
+
+// ErrIncompatible is returned when a registry cannot be used by the running version of tb.
+// Updating tb will usually fix the problem.
+const ErrIncompatible errors.String = "registry is not compatible with this version of tb"
+
+// compatibility contains the constraints a registry places on the version of tb that reads it.
+type compatibility struct {
+	// minTbVersion is the raw minTbVersion constraint, empty if not set.
+	minTbVersion      string
+	minTbVersionRange semver.Range
+	// schemaVersion is the version of the registry format, 0 if not set.
+	schemaVersion int
+}
+
+// readCompatibility reads the version constraints declared in the services config of the registry r.
+// Each constraint may only be defined once across all service config files.
+func readCompatibility(op errors.Op, r Registry) (compatibility, errors.List) {
+	// Errors reading the files are reported when reading services, only check the files that could be read.
+	files, _ := readRegistryFiles[registryServiceConfig](op, ServicesFileName, ServicesDirName, r, false)
+	var c compatibility
+	var errs errors.List
+	var minTbVersionSrc, schemaVersionSrc nodeSource
+	for _, f := range files {
+		global := f.data.Global
+		if global.MinTbVersion != "" {
+			src := f.source("global", "minTbVersion")
+			if c.minTbVersion != "" {
+				msg := fmt.Sprintf("minTbVersion is already defined at %s", minTbVersionSrc.pos())
+				errs = append(errs, &FileError{Pos: src.pos(), Err: errors.New(errkind.Invalid, msg, op)})
+			} else if rng, err := parseMinVersion(global.MinTbVersion); err != nil {
+				msg := fmt.Sprintf("invalid minTbVersion %q: %v", global.MinTbVersion, err)
+				errs = append(errs, &FileError{Pos: src.pos(), Err: errors.New(errkind.Invalid, msg, op)})
+			} else {
+				c.minTbVersion, c.minTbVersionRange, minTbVersionSrc = global.MinTbVersion, rng, src
+			}
+		}
+		if global.SchemaVersion != 0 {
+			src := f.source("global", "schemaVersion")
+			if c.schemaVersion != 0 {
+				msg := fmt.Sprintf("schemaVersion is already defined at %s", schemaVersionSrc.pos())
+				errs = append(errs, &FileError{Pos: src.pos(), Err: errors.New(errkind.Invalid, msg, op)})
+			} else if global.SchemaVersion < 0 || global.SchemaVersion > SchemaVersion {
+				msg := fmt.Sprintf(
+					"unsupported schemaVersion %d, the latest version supported by this version of tb is %d",
+					global.SchemaVersion, SchemaVersion,
+				)
+				errs = append(errs, &FileError{Pos: src.pos(), Err: errors.Wrap(ErrIncompatible, errors.Meta{
+					Kind:   errkind.Invalid,
+					Reason: msg,
+					Op:     op,
+				})})
+			} else {
+				c.schemaVersion, schemaVersionSrc = global.SchemaVersion, src
+			}
+		}
+	}
+	return c, errs
+}
+
+// parseMinVersion parses a minTbVersion constraint. It can either be a version, which
+// means any version greater than or equal to it, or a range such as '>=1.2.0 <2.0.0'.
+func parseMinVersion(s string) (semver.Range, error) {
+	if v, err := semver.ParseTolerant(s); err == nil {
+		return semver.MustParseRange(">=" + v.String()), nil
+	}
+	return semver.ParseRange(s)
+}
+
+// checkCompatibility makes sure that the registry r can be read by tbVersion.
+// If tbVersion is empty, the check is skipped. If tbVersion is not a valid version,
+// for example a development build, it is assumed to be compatible since there is no way to know.
+func checkCompatibility(op errors.Op, r Registry, tbVersion string, logger progress.Logger) error {
+	c, errs := readCompatibility(op, r)
+	if len(errs) > 0 {
+		var err error = errs
+		if len(errs) == 1 {
+			// Return the error directly so that it can be checked with errors.Is
+			err = errs[0]
+		}
+		return errors.Wrap(err, errors.Meta{
+			Reason: fmt.Sprintf("unable to determine compatibility of registry %s", r.Name),
+			Op:     op,
+		})
+	}
+	if c.minTbVersion == "" || tbVersion == "" {
+		return nil
+	}
+	v, err := semver.ParseTolerant(tbVersion)
+	if err != nil {
+		logger.Debugf(
+			"Unable to check if registry %s is compatible with tb version %s, it requires tb %s",
+			r.Name, tbVersion, c.minTbVersion,
+		)
+		return nil
+	}
+	// Ignore pre-release info so that pre-releases of a version are considered compatible.
+	v.Pre = nil
+	if !c.minTbVersionRange(v) {
+		required := strings.TrimSpace(c.minTbVersion)
+		if _, err := semver.ParseTolerant(required); err == nil {
+			required += " or later"
+		}
+		return errors.Wrap(ErrIncompatible, errors.Meta{
+			Kind: errkind.Invalid,
+			Reason: fmt.Sprintf(
+				"registry %s requires tb version %s but the current version is %s, please update tb",
+				r.Name, required, tbVersion,
+			),
+			Op: op,
+		})
+	}
+	return nil
+}
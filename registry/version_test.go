@@ -0,0 +1,130 @@
+package registry_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TouchBistro/goutils/progress"
+	"github.com/TouchBistro/tb/registry"
+	"github.com/matryer/is"
+)
+
+func writeServicesFile(t *testing.T, global string) registry.Registry {
+	t.Helper()
+	dir := t.TempDir()
+	data := "global:\n" + global + "services:\n  postgres:\n    mode: remote\n    remote:\n      image: postgres\n"
+	if err := os.WriteFile(filepath.Join(dir, registry.ServicesFileName), []byte(data), 0o644); err != nil {
+		t.Fatalf("failed to write services file: %v", err)
+	}
+	return registry.Registry{Name: "TouchBistro/tb-registry", Path: dir}
+}
+
+func TestReadAllCompatibility(t *testing.T) {
+	tests := []struct {
+		name      string
+		global    string
+		tbVersion string
+		wantErr   string
+		// incompatible is true if the error should be ErrIncompatible
+		incompatible bool
+	}{
+		{
+			name:      "no constraints",
+			global:    "",
+			tbVersion: "1.0.0",
+		},
+		{
+			name:      "version satisfies minimum",
+			global:    "  minTbVersion: 1.2.0\n",
+			tbVersion: "1.2.0",
+		},
+		{
+			name:      "version with v prefix",
+			global:    "  minTbVersion: v1.2.0\n",
+			tbVersion: "v1.3.1",
+		},
+		{
+			name:      "pre-release of minimum version",
+			global:    "  minTbVersion: 1.2.0\n",
+			tbVersion: "1.2.0-rc.1",
+		},
+		{
+			name:         "version too old",
+			global:       "  minTbVersion: 1.2.0\n",
+			tbVersion:    "1.1.9",
+			wantErr:      "registry TouchBistro/tb-registry requires tb version 1.2.0 or later but the current version is 1.1.9, please update tb",
+			incompatible: true,
+		},
+		{
+			name:         "version outside range",
+			global:       "  minTbVersion: '>=1.2.0 <2.0.0'\n",
+			tbVersion:    "2.0.0",
+			wantErr:      "requires tb version >=1.2.0 <2.0.0",
+			incompatible: true,
+		},
+		{
+			name:      "development build",
+			global:    "  minTbVersion: 1.2.0\n",
+			tbVersion: "dev",
+		},
+		{
+			name:      "no version provided",
+			global:    "  minTbVersion: 99.0.0\n",
+			tbVersion: "",
+		},
+		{
+			name:      "supported schema version",
+			global:    "  schemaVersion: 1\n",
+			tbVersion: "1.0.0",
+		},
+		{
+			name:         "unsupported schema version",
+			global:       "  schemaVersion: 2\n",
+			tbVersion:    "",
+			wantErr:      "unsupported schemaVersion 2, the latest version supported by this version of tb is 1",
+			incompatible: true,
+		},
+		{
+			name:      "invalid minTbVersion",
+			global:    "  minTbVersion: latest\n",
+			tbVersion: "1.0.0",
+			wantErr:   `services.yml:2:3: invalid operation: invalid minTbVersion "latest"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			r := writeServicesFile(t, tt.global)
+			result, err := registry.ReadAll([]registry.Registry{r}, registry.ReadAllOptions{
+				ReadServices: true,
+				TbVersion:    tt.tbVersion,
+				Logger:       progress.NoopTracker{},
+			})
+			if tt.wantErr == "" {
+				is.NoErr(err)
+				is.Equal(result.Services.Len(), 1)
+				return
+			}
+			is.True(err != nil)
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %q, want it to contain %q", err, tt.wantErr)
+			}
+			is.Equal(errors.Is(err, registry.ErrIncompatible), tt.incompatible)
+		})
+	}
+}
+
+func TestValidateCompatibility(t *testing.T) {
+	is := is.New(t)
+	r := writeServicesFile(t, "  minTbVersion: not-a-version\n  schemaVersion: 3\n")
+	result := registry.Validate(r.Path, registry.ValidateOptions{})
+	problems := result.Problems()
+	is.Equal(len(problems), 2)
+	is.Equal(problems[0].Pos.Line, 2)
+	is.True(strings.Contains(problems[0].Message, `invalid minTbVersion "not-a-version"`))
+	is.Equal(problems[1].Pos.Line, 3)
+	is.True(strings.Contains(problems[1].Message, "unsupported schemaVersion 3"))
+}
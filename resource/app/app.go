@@ -26,10 +26,11 @@ type App struct {
 
 	// General fields
 
-	Branch  string            `yaml:"branch"`
-	GitRepo string            `yaml:"repo"`
-	EnvVars map[string]string `yaml:"envVars"`
-	Storage Storage           `yaml:"storage"`
+	Branch     string                `yaml:"branch"`
+	Deprecated *resource.Deprecation `yaml:"deprecated,omitempty"`
+	GitRepo    string                `yaml:"repo"`
+	EnvVars    map[string]string     `yaml:"envVars"`
+	Storage    Storage               `yaml:"storage"`
 	// Not part of yaml, set at runtime
 	Name         string `yaml:"-"`
 	RegistryName string `yaml:"-"`
@@ -44,6 +45,11 @@ func (App) Type() resource.Type {
 	return resource.TypeApp
 }
 
+// Deprecation returns the deprecation of a, or nil if a is not deprecated.
+func (a App) Deprecation() *resource.Deprecation {
+	return a.Deprecated
+}
+
 // FullName returns the app name prefixed with the registry name,
 // i.e. '<registry>/<app>'.
 func (a App) FullName() string {
@@ -73,14 +79,15 @@ func Validate(a App, t Type) error {
 		return nil
 	}
 
-	var msgs []string
+	var msgs, fields []string
 	switch strings.ToLower(a.RunsOn) {
 	case "", "all", "ipad", "iphone":
 	default:
+		fields = append(fields, "runsOn")
 		msgs = append(msgs, "'runsOn' value is invalid, must be 'all', 'ipad', or 'iphone'")
 	}
 	if msgs == nil {
 		return nil
 	}
-	return &resource.ValidationError{Resource: a, Messages: msgs}
+	return &resource.ValidationError{Resource: a, Messages: msgs, Fields: fields}
 }
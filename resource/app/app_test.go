@@ -121,6 +121,7 @@ func TestValidate(t *testing.T) {
 			is.True(errors.As(err, &validationErr))
 			is.Equal(validationErr.Resource, tt.app)
 			is.Equal(len(validationErr.Messages), tt.wantMsgLen)
+			is.Equal(len(validationErr.Fields), tt.wantMsgLen)
 		})
 	}
 }
@@ -4,21 +4,36 @@ package playlist
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/TouchBistro/goutils/errors"
 	"github.com/TouchBistro/tb/errkind"
 	"github.com/TouchBistro/tb/internal/util"
 	"github.com/TouchBistro/tb/resource"
+	"github.com/TouchBistro/tb/resource/service"
+	"gopkg.in/yaml.v3"
 )
 
 // Playlist specifies the configuration for a playlist.
 // A playlist is a list of services that can be run together.
 //
-// Playlists can extend another playlist which effectively merges
-// the lists of services together.
+// Playlists can extend other playlists which effectively merges
+// the lists of services together. Services from the extended playlists
+// can be removed using Exclude.
+//
+// Playlists can also customize how their services are run with EnvVars and Overrides.
+// These only apply when the playlist itself is run, they are not inherited by playlists
+// that extend it.
 type Playlist struct {
-	Extends  string   `yaml:"extends,omitempty"`
-	Services []string `yaml:"services"`
+	Deprecated *resource.Deprecation `yaml:"deprecated,omitempty"`
+	Extends    NameList              `yaml:"extends,omitempty"`
+	Exclude    []string              `yaml:"exclude,omitempty"`
+	Services   []string              `yaml:"services"`
+	// EnvVars are set on every service in the playlist.
+	EnvVars map[string]string `yaml:"envVars,omitempty"`
+	// Overrides are applied to the services in the playlist. Keys are service names
+	// or patterns like 'payments-*' that are matched against the services in the playlist.
+	Overrides map[string]service.ServiceOverride `yaml:"overrides,omitempty"`
 	// Not part of yaml, set at runtime
 	Name         string `yaml:"-"`
 	RegistryName string `yaml:"-"`
@@ -28,12 +43,58 @@ func (Playlist) Type() resource.Type {
 	return resource.TypePlaylist
 }
 
+// Deprecation returns the deprecation of p, or nil if p is not deprecated.
+func (p Playlist) Deprecation() *resource.Deprecation {
+	return p.Deprecated
+}
+
 // FullName returns the playlist name prefixed with the registry name,
 // i.e. '<registry>/<playlist>'.
 func (p Playlist) FullName() string {
 	return resource.FullName(p.RegistryName, p.Name)
 }
 
+// NameList is a list of playlist names. In YAML it can be written as either
+// a single name or a list of names, i.e. 'extends: core' is the same as 'extends: [core]'.
+type NameList []string
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (nl *NameList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		var name string
+		if err := value.Decode(&name); err != nil {
+			return err
+		}
+		*nl = NameList{name}
+		return nil
+	}
+	var names []string
+	if err := value.Decode(&names); err != nil {
+		return err
+	}
+	*nl = names
+	return nil
+}
+
+// MarshalYAML implements yaml.Marshaler. A list with a single name is written
+// as a plain string so that files using the short form are preserved.
+func (nl NameList) MarshalYAML() (interface{}, error) {
+	if len(nl) == 1 {
+		return nl[0], nil
+	}
+	return []string(nl), nil
+}
+
+// JSONSchema returns the JSON Schema of a NameList, which is either a string or a list of strings.
+func (NameList) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"oneOf": []interface{}{
+			map[string]interface{}{"type": "string"},
+			map[string]interface{}{"type": []string{"array", "null"}, "items": map[string]interface{}{"type": "string"}},
+		},
+	}
+}
+
 // Collection stores a collection of playlists.
 // Collection allows for efficiently looking up a playlist by its
 // short name (i.e. the name of the playlist without the registry).
@@ -62,7 +123,39 @@ func (c *Collection) Get(name string) (Playlist, error) {
 	if p, ok := c.customPlaylists[name]; ok {
 		return p, nil
 	}
-	return c.collection.Get(name)
+	p, err := c.collection.Get(name)
+	var lookupErr *resource.LookupError
+	if errors.As(err, &lookupErr) && errors.Is(lookupErr.Err, resource.ErrNotFound) {
+		// Suggest custom playlists too since they are not part of the underlying collection.
+		lookupErr.Suggestions = c.Suggestions(name)
+	}
+	return p, err
+}
+
+// Suggestions returns the names of the playlists in the Collection, including custom playlists,
+// that are similar to name, the most similar first.
+func (c *Collection) Suggestions(name string) []string {
+	return resource.SuggestNames(name, append(c.Names(), c.CustomNames()...))
+}
+
+// SetChooseFunc sets the function used by Get to pick a playlist when a short name is ambiguous.
+func (c *Collection) SetChooseFunc(choose resource.ChooseFunc) {
+	c.collection.SetChooseFunc(choose)
+}
+
+// Lookup retrieves the playlist with the given name from the Collection like Get,
+// except that deprecated playlists are returned as is without any warnings.
+func (c *Collection) Lookup(name string) (Playlist, error) {
+	if p, ok := c.customPlaylists[name]; ok {
+		return p, nil
+	}
+	return c.collection.Lookup(name)
+}
+
+// SetDeprecationOptions sets how deprecated playlists are handled by Get.
+// Custom playlists cannot be deprecated since they are managed by the user.
+func (c *Collection) SetDeprecationOptions(opts resource.DeprecationOptions) {
+	c.collection.SetDeprecationOptions(opts)
 }
 
 // Set adds or replaces the playlist in the Collection.
@@ -86,37 +179,83 @@ func (c *Collection) SetCustom(p Playlist) {
 // ServiceNames returns all the service names contained in the playlist with playlistName.
 // It will resolve any extends fields and merge the playlists.
 //
+// The services of each extended playlist come first, in the order they are listed in extends,
+// followed by the services of the playlist itself. Any services listed in exclude are then removed.
+// Exclude only applies to the playlist it is defined in, and playlists that extend it.
+//
 // ServiceNames automatically removes any duplications as a result of merging playlists.
 // For example, if playlist B specifies service S and extends playlist A which also specifies
 // service S, the returned slice will only contain service S once not twice.
 //
 // If a dependency cycle is detected while resolving extends an error will be returned.
+//
+// ServiceNames does not handle deprecated playlists, use Get first to resolve the playlist
+// if deprecations should be handled.
 func (c *Collection) ServiceNames(playlistName string) ([]string, error) {
 	const op = errors.Op("playlist.Collection.ServiceNames")
-	serviceNames, err := c.resolveServiceNames(op, playlistName, make(map[string]bool))
+	serviceNames, err := c.resolveServiceNames(op, playlistName, nil)
 	if err != nil {
 		return nil, err
 	}
 	return util.UniqueStrings(serviceNames), nil
 }
 
-func (c *Collection) resolveServiceNames(op errors.Op, name string, deps map[string]bool) ([]string, error) {
-	p, err := c.Get(name)
+// resolveServiceNames returns the services of the playlist with name, including the services
+// of all the playlists it extends. chain contains the playlists currently being resolved
+// and is used to detect cycles.
+func (c *Collection) resolveServiceNames(op errors.Op, name string, chain []string) ([]string, error) {
+	p, err := c.Lookup(name)
 	if err != nil {
 		return nil, errors.Wrap(err, errors.Meta{Op: op})
 	}
-	if p.Extends == "" {
-		return p.Services, nil
-	}
 	// Check for dependency cycle
-	if deps[p.Extends] {
-		msg := fmt.Sprintf("circular dependency of services, %s and %s", p.Extends, name)
-		return nil, errors.New(errkind.Invalid, msg, op)
-	}
-	// Resolve parent playlist defined in extends
-	deps[name] = true
-	parentServices, err := c.resolveServiceNames(op, p.Extends, deps)
-	return append(parentServices, p.Services...), err
+	fullName := p.FullName()
+	for i, n := range chain {
+		if n == fullName {
+			cycle := append(append([]string(nil), chain[i:]...), fullName)
+			msg := fmt.Sprintf("circular dependency of playlists: %s", strings.Join(cycle, " -> "))
+			return nil, errors.New(errkind.Invalid, msg, op)
+		}
+	}
+	chain = append(chain, fullName)
+
+	// Resolve parent playlists defined in extends
+	var serviceNames []string
+	for _, parent := range p.Extends {
+		parentServices, err := c.resolveServiceNames(op, parent, chain)
+		if err != nil {
+			return nil, err
+		}
+		serviceNames = append(serviceNames, parentServices...)
+	}
+	serviceNames = append(serviceNames, p.Services...)
+	if len(p.Exclude) == 0 {
+		return serviceNames, nil
+	}
+	var included []string
+	for _, sn := range serviceNames {
+		if !excludes(p.Exclude, sn) {
+			included = append(included, sn)
+		}
+	}
+	return included, nil
+}
+
+// excludes reports whether serviceName matches any of the names in exclude.
+// If either name is a short name only the short names are compared, since custom
+// playlists can refer to services by their short names.
+func excludes(exclude []string, serviceName string) bool {
+	sRegistry, sName, _ := resource.ParseName(serviceName)
+	for _, e := range exclude {
+		if e == serviceName {
+			return true
+		}
+		eRegistry, eName, _ := resource.ParseName(e)
+		if (eRegistry == "" || sRegistry == "") && eName == sName {
+			return true
+		}
+	}
+	return false
 }
 
 // Name returns a list of the full names of all playlists in the collection.
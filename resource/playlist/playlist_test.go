@@ -1,11 +1,15 @@
 package playlist_test
 
 import (
+	"errors"
 	"sort"
+	"strings"
 	"testing"
 
+	"github.com/TouchBistro/tb/resource"
 	"github.com/TouchBistro/tb/resource/playlist"
 	"github.com/matryer/is"
+	"gopkg.in/yaml.v3"
 )
 
 func newCollection(t *testing.T, playlists, customPlaylists []playlist.Playlist) *playlist.Collection {
@@ -43,7 +47,7 @@ func TestServiceNames(t *testing.T) {
 			RegistryName: "ExampleZone/tb-registry",
 		},
 		{
-			Extends: "TouchBistro/tb-registry/core",
+			Extends: playlist.NameList{"TouchBistro/tb-registry/core"},
 			Services: []string{
 				"venue-admin-frontend",
 				"partners-config-service",
@@ -53,7 +57,7 @@ func TestServiceNames(t *testing.T) {
 		},
 	}, []playlist.Playlist{
 		{
-			Extends: "vaf-core",
+			Extends: playlist.NameList{"vaf-core"},
 			Services: []string{
 				"legacy-bridge-cloud-service",
 				"loyalty-gateway-service",
@@ -76,11 +80,71 @@ func TestServiceNames(t *testing.T) {
 	is.NoErr(err)
 }
 
+func TestServiceNamesExtendsMultiple(t *testing.T) {
+	is := is.New(t)
+	c := newCollection(t, []playlist.Playlist{
+		{
+			Services:     []string{"TouchBistro/tb-registry/postgres", "TouchBistro/tb-registry/venue-core-service"},
+			Name:         "core",
+			RegistryName: "TouchBistro/tb-registry",
+		},
+		{
+			Extends:      playlist.NameList{"TouchBistro/tb-registry/core"},
+			Services:     []string{"TouchBistro/tb-registry/payments-service", "TouchBistro/tb-registry/analytics-worker"},
+			Name:         "payments",
+			RegistryName: "TouchBistro/tb-registry",
+		},
+		{
+			Extends:      playlist.NameList{"TouchBistro/tb-registry/core"},
+			Services:     []string{"TouchBistro/tb-registry/redis"},
+			Name:         "cache",
+			RegistryName: "TouchBistro/tb-registry",
+		},
+	}, []playlist.Playlist{
+		{
+			Extends:  playlist.NameList{"payments", "cache"},
+			Exclude:  []string{"analytics-worker"},
+			Services: []string{"localstack"},
+			Name:     "my-payments",
+		},
+	})
+
+	list, err := c.ServiceNames("my-payments")
+	is.NoErr(err)
+	is.Equal(list, []string{
+		"TouchBistro/tb-registry/postgres",
+		"TouchBistro/tb-registry/venue-core-service",
+		"TouchBistro/tb-registry/payments-service",
+		"TouchBistro/tb-registry/redis",
+		"localstack",
+	})
+}
+
+func TestNameListYAML(t *testing.T) {
+	is := is.New(t)
+	var playlists map[string]playlist.Playlist
+	err := yaml.Unmarshal([]byte(`
+a:
+  extends: core
+  services: []
+b:
+  extends: [core, payments]
+  services: []
+`), &playlists)
+	is.NoErr(err)
+	is.Equal(playlists["a"].Extends, playlist.NameList{"core"})
+	is.Equal(playlists["b"].Extends, playlist.NameList{"core", "payments"})
+
+	b, err := yaml.Marshal(playlists["a"])
+	is.NoErr(err)
+	is.Equal(string(b), "extends: core\nservices: []\n")
+}
+
 func TestServiceNamesCircularDependency(t *testing.T) {
 	is := is.New(t)
 	c := newCollection(t, []playlist.Playlist{
 		{
-			Extends: "core-2",
+			Extends: playlist.NameList{"core-2"},
 			Services: []string{
 				"postgres",
 			},
@@ -88,7 +152,7 @@ func TestServiceNamesCircularDependency(t *testing.T) {
 			RegistryName: "TouchBistro/tb-registry",
 		},
 		{
-			Extends: "TouchBistro/tb-registry/core",
+			Extends: playlist.NameList{"TouchBistro/tb-registry/core"},
 			Services: []string{
 				"localstack",
 			},
@@ -100,6 +164,7 @@ func TestServiceNamesCircularDependency(t *testing.T) {
 	list, err := c.ServiceNames("core-2")
 	is.Equal(len(list), 0)
 	is.True(err != nil)
+	is.True(strings.HasSuffix(err.Error(), "circular dependency of playlists: TouchBistro/tb-registry/core-2 -> TouchBistro/tb-registry/core -> TouchBistro/tb-registry/core-2"))
 }
 
 func TestServiceNamesNonexistent(t *testing.T) {
@@ -132,7 +197,7 @@ func TestNames(t *testing.T) {
 			RegistryName: "ExampleZone/tb-registry",
 		},
 		{
-			Extends: "TouchBistro/tb-registry/core",
+			Extends: playlist.NameList{"TouchBistro/tb-registry/core"},
 			Services: []string{
 				"venue-admin-frontend",
 				"partners-config-service",
@@ -155,7 +220,7 @@ func TestCustomNames(t *testing.T) {
 	is := is.New(t)
 	c := newCollection(t, nil, []playlist.Playlist{
 		{
-			Extends: "TouchBistro/tb-registry/core",
+			Extends: playlist.NameList{"TouchBistro/tb-registry/core"},
 			Services: []string{
 				"partners-config-service",
 			},
@@ -173,3 +238,17 @@ func TestCustomNames(t *testing.T) {
 	sort.Strings(names)
 	is.Equal(names, []string{"db", "my-core"})
 }
+
+func TestGetSuggestions(t *testing.T) {
+	is := is.New(t)
+	c := newCollection(t, []playlist.Playlist{
+		{Name: "backend", RegistryName: "TouchBistro/tb-registry"},
+	}, []playlist.Playlist{
+		{Name: "my-backend"},
+	})
+	_, err := c.Get("backen")
+	var lookupErr *resource.LookupError
+	is.True(errors.As(err, &lookupErr))
+	is.True(errors.Is(err, resource.ErrNotFound))
+	is.Equal(lookupErr.Suggestions, []string{"TouchBistro/tb-registry/backend", "my-backend"})
+}
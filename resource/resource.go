@@ -7,11 +7,16 @@ package resource
 
 import (
 	"fmt"
+	"path"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/TouchBistro/goutils/errors"
+	"github.com/TouchBistro/goutils/progress"
 	"github.com/TouchBistro/tb/errkind"
+	"github.com/TouchBistro/tb/internal/util"
 )
 
 // ErrInvalidName is returned when a resource name is provided that does not have
@@ -25,6 +30,30 @@ const ErrNotFound errors.String = "resource not found"
 // were found in a Collection.
 const ErrMultipleResources errors.String = "multiple resources found with the same name"
 
+// LookupError is returned by Collection when a resource cannot be resolved.
+// It contains names the user may have meant so they can be shown to help them.
+type LookupError struct {
+	// Err is either ErrNotFound or ErrMultipleResources.
+	Err error
+	// Suggestions contains the full names of resources that may have been meant.
+	// If Err is ErrMultipleResources, it contains all the resources that matched.
+	Suggestions []string
+}
+
+func (e *LookupError) Error() string {
+	if len(e.Suggestions) == 0 {
+		return e.Err.Error()
+	}
+	if errors.Is(e.Err, ErrMultipleResources) {
+		return fmt.Sprintf("%s, use the full name of one of: %s", e.Err, strings.Join(e.Suggestions, ", "))
+	}
+	return fmt.Sprintf("%s, did you mean %s?", e.Err, strings.Join(e.Suggestions, " or "))
+}
+
+func (e *LookupError) Unwrap() error {
+	return e.Err
+}
+
 // Resource represents a resource managed by tb.
 type Resource interface {
 	Type() Type
@@ -44,15 +73,79 @@ func (t Type) String() string {
 	return [...]string{"service", "playlist", "app"}[t]
 }
 
+// Deprecation describes why a resource is deprecated and what should be used instead.
+type Deprecation struct {
+	// Message explains why the resource is deprecated.
+	Message string `yaml:"message"`
+	// Replacement is the name of the resource that should be used instead.
+	// If it is a short name, a resource in the same registry is preferred.
+	Replacement string `yaml:"replacement,omitempty"`
+	// RemoveAfter is when the resource is planned to be removed, usually a date.
+	RemoveAfter string `yaml:"removeAfter,omitempty"`
+}
+
+// Deprecatable is implemented by resources that can be deprecated.
+// Deprecation returns nil if the resource is not deprecated.
+type Deprecatable interface {
+	Deprecation() *Deprecation
+}
+
+// DeprecationOf returns the deprecation of r, or nil if r is not deprecated.
+func DeprecationOf(r Resource) *Deprecation {
+	if d, ok := r.(Deprecatable); ok {
+		return d.Deprecation()
+	}
+	return nil
+}
+
+// Position identifies a location in a config file.
+type Position struct {
+	// File is the path to the file.
+	File string
+	// Line is the line number, starting at 1. A value of 0 means the line is unknown.
+	Line int
+	// Column is the column number, starting at 1. A value of 0 means the column is unknown.
+	Column int
+}
+
+// IsValid reports whether the position has a file.
+func (p Position) IsValid() bool {
+	return p.File != ""
+}
+
+// String returns the position in the form file:line:column.
+// Any unknown parts are omitted.
+func (p Position) String() string {
+	s := p.File
+	if p.Line > 0 {
+		s += fmt.Sprintf(":%d", p.Line)
+		if p.Column > 0 {
+			s += fmt.Sprintf(":%d", p.Column)
+		}
+	}
+	return s
+}
+
 // ValidationError represents a resource having failed validation.
 // It contains the resource that failed validation and a list of validation failure messages.
 type ValidationError struct {
 	Resource Resource
 	Messages []string
+	// Fields contains the path of the field each message is about, using dots to separate
+	// nested fields, e.g. 'remote.image'. Fields[i] corresponds to Messages[i].
+	// It is nil if the fields are not known.
+	Fields []string
+	// Pos is the location of the resource, or of the specific field that failed validation,
+	// in the file where it was defined. It is the zero value if the location is not known.
+	Pos Position
 }
 
 func (ve *ValidationError) Error() string {
 	var sb strings.Builder
+	if ve.Pos.IsValid() {
+		sb.WriteString(ve.Pos.String())
+		sb.WriteString(": ")
+	}
 	sb.WriteString(ve.Resource.Type().String())
 	sb.WriteString(": ")
 	sb.WriteString(ve.Resource.FullName())
@@ -105,6 +198,46 @@ type Collection[R Resource] struct {
 	// nameMap is a map of short names to a list of indices
 	// for each matching resource in resources.
 	nameMap map[string][]int
+	// deprecationOpts controls how Get handles deprecated resources.
+	deprecationOpts DeprecationOptions
+	// mu protects warned and choices, since Get may be called concurrently.
+	mu sync.Mutex
+	// warned contains the full names of deprecated resources that have been warned about
+	// so that each warning is only shown once.
+	warned map[string]bool
+	// choose is used to pick a resource when a short name is ambiguous.
+	choose ChooseFunc
+	// choices stores the full name chosen for each ambiguous name so that
+	// the user is only asked once.
+	choices map[string]string
+}
+
+// ChooseFunc is used by Collection.Get to pick a resource when a short name matches multiple resources.
+// t is the type of the resources, name is the ambiguous name, and candidates are the full names of all
+// the matching resources. It must return the full name of the chosen resource.
+//
+// Calls to a ChooseFunc by the same Collection are never made concurrently.
+type ChooseFunc func(t Type, name string, candidates []string) (string, error)
+
+// SetChooseFunc sets the function used by Get to pick a resource when a short name is ambiguous.
+// If no ChooseFunc is set, Get returns ErrMultipleResources instead.
+func (c *Collection[R]) SetChooseFunc(choose ChooseFunc) {
+	c.choose = choose
+}
+
+// DeprecationOptions customizes how a Collection handles deprecated resources.
+type DeprecationOptions struct {
+	// Logger is used to warn when a deprecated resource is retrieved with Get.
+	// If it is nil, no warnings are shown.
+	Logger progress.Logger
+	// UseReplacement causes Get to return the replacement of a deprecated resource
+	// instead of the resource itself, if it has one.
+	UseReplacement bool
+}
+
+// SetDeprecationOptions sets how deprecated resources are handled by Get.
+func (c *Collection[R]) SetDeprecationOptions(opts DeprecationOptions) {
+	c.deprecationOpts = opts
 }
 
 // Len returns the number of resources stored in the Collection.
@@ -118,10 +251,119 @@ func (c *Collection[R]) Len() int {
 // Get retrieves the resource with the given name from the Collection.
 // name can either be the full name or the short name of the resource.
 //
-// If no resource is found, ErrNotFound is returned. If name is a short name
-// and multiple resources are found, ErrMultipleResources is returned.
+// If the resource is deprecated, Get handles it as configured with SetDeprecationOptions.
+// A warning is logged the first time it is retrieved and, if enabled, its replacement is
+// returned instead. Use Lookup to retrieve a resource without any deprecation handling.
+//
+// If no resource is found, ErrNotFound is returned. If name is a short name and multiple
+// resources are found, the ChooseFunc set with SetChooseFunc is used to pick one. If there is
+// no ChooseFunc, ErrMultipleResources is returned. In both cases the error is a *LookupError
+// containing suggestions of what name may have referred to.
 func (c *Collection[R]) Get(name string) (R, error) {
 	const op = errors.Op("resource.Collection.Get")
+	r, err := c.lookup(op, name)
+	var lookupErr *LookupError
+	if err != nil && c != nil && c.choose != nil && errors.As(err, &lookupErr) && errors.Is(lookupErr.Err, ErrMultipleResources) {
+		var fullName string
+		fullName, err = c.choice(r.Type(), name, lookupErr.Suggestions)
+		if err != nil {
+			return r, errors.Wrap(err, errors.Meta{Reason: fmt.Sprintf("unable to choose %s", name), Op: op})
+		}
+		r, err = c.lookup(op, fullName)
+	}
+	if err != nil {
+		return r, err
+	}
+	return c.handleDeprecation(op, r), nil
+}
+
+// choice returns the full name chosen for the ambiguous name, using the ChooseFunc
+// if no choice has been made yet. The lock is held while choosing so that concurrent
+// calls for the same name only ask once.
+func (c *Collection[R]) choice(t Type, name string, candidates []string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if fullName, ok := c.choices[name]; ok {
+		return fullName, nil
+	}
+	fullName, err := c.choose(t, name, candidates)
+	if err != nil {
+		return "", err
+	}
+	if c.choices == nil {
+		c.choices = make(map[string]string)
+	}
+	c.choices[name] = fullName
+	return fullName, nil
+}
+
+// Lookup retrieves the resource with the given name from the Collection like Get,
+// except that deprecated resources are returned as is without any warnings.
+// It should be used when resolving references between resources.
+func (c *Collection[R]) Lookup(name string) (R, error) {
+	return c.lookup("resource.Collection.Lookup", name)
+}
+
+// handleDeprecation warns about r if it is deprecated and returns the resource to use in its place.
+// op is the operation of the caller and is used for errors looking up the replacement.
+func (c *Collection[R]) handleDeprecation(op errors.Op, r R) R {
+	d := DeprecationOf(r)
+	if d == nil {
+		return r
+	}
+	opts := c.deprecationOpts
+	var replacement R
+	var replacementErr error
+	hasReplacement := false
+	if d.Replacement != "" {
+		// Prefer a resource in the same registry if the replacement is a short name.
+		registryName, _, _ := ParseName(r.FullName())
+		replacement, replacementErr = c.lookup(op, FullName(registryName, d.Replacement))
+		if replacementErr != nil {
+			replacement, replacementErr = c.lookup(op, d.Replacement)
+		}
+		hasReplacement = replacementErr == nil && replacement.FullName() != r.FullName()
+	}
+	if opts.Logger != nil && c.markWarned(r.FullName()) {
+		msg := fmt.Sprintf("%s %s is deprecated", r.Type(), r.FullName())
+		if d.Message != "" {
+			msg += ": " + d.Message
+		}
+		if d.RemoveAfter != "" {
+			msg += fmt.Sprintf(" (it will be removed after %s)", d.RemoveAfter)
+		}
+		opts.Logger.Warnf("⚠️  %s", msg)
+		switch {
+		case hasReplacement && opts.UseReplacement:
+			opts.Logger.Warnf("⚠️  Using %s instead", replacement.FullName())
+		case hasReplacement:
+			opts.Logger.Warnf("⚠️  Use %s instead", replacement.FullName())
+		case d.Replacement != "":
+			opts.Logger.Warnf("⚠️  The replacement %s %s could not be found", r.Type(), d.Replacement)
+		}
+	}
+	if hasReplacement && opts.UseReplacement {
+		return replacement
+	}
+	return r
+}
+
+// markWarned records that a warning about the resource with fullName was shown.
+// It returns false if one was already shown.
+func (c *Collection[R]) markWarned(fullName string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.warned[fullName] {
+		return false
+	}
+	if c.warned == nil {
+		c.warned = make(map[string]bool)
+	}
+	c.warned[fullName] = true
+	return true
+}
+
+func (c *Collection[R]) lookup(op errors.Op, name string) (R, error) {
 	// Create zero value that we can return on error
 	var r R
 	registryName, resourceName, err := ParseName(name)
@@ -131,17 +373,22 @@ func (c *Collection[R]) Get(name string) (R, error) {
 
 	errMeta := errors.Meta{Kind: errkind.Invalid, Reason: name, Op: op}
 	if c == nil {
-		return r, errors.Wrap(ErrNotFound, errMeta)
+		return r, errors.Wrap(&LookupError{Err: ErrNotFound}, errMeta)
 	}
 	bucket, ok := c.nameMap[resourceName]
 	if !ok {
-		return r, errors.Wrap(ErrNotFound, errMeta)
+		return r, errors.Wrap(&LookupError{Err: ErrNotFound, Suggestions: c.Suggestions(name)}, errMeta)
 	}
 
 	// Handle short name
 	if registryName == "" {
 		if len(bucket) > 1 {
-			return r, errors.Wrap(ErrMultipleResources, errMeta)
+			candidates := make([]string, len(bucket))
+			for i, ri := range bucket {
+				candidates[i] = c.resources[ri].FullName()
+			}
+			sort.Strings(candidates)
+			return r, errors.Wrap(&LookupError{Err: ErrMultipleResources, Suggestions: candidates}, errMeta)
 		}
 		return c.resources[bucket[0]], nil
 	}
@@ -151,7 +398,120 @@ func (c *Collection[R]) Get(name string) (R, error) {
 			return r, nil
 		}
 	}
-	return r, errors.Wrap(ErrNotFound, errMeta)
+	return r, errors.Wrap(&LookupError{Err: ErrNotFound, Suggestions: c.Suggestions(name)}, errMeta)
+}
+
+// IsPattern reports whether name is a pattern that can be used with Collection.Match
+// instead of the name of a single resource.
+func IsPattern(name string) bool {
+	return strings.ContainsAny(name, "*?[")
+}
+
+// Match returns all the resources in the Collection that match pattern, sorted by full name.
+// The pattern syntax is the same as path.Match, for example 'payments-*' or 'TouchBistro/tb-registry/*'.
+//
+// If pattern contains a '/' it is matched against the full names of resources,
+// otherwise it is matched against their short names. Since '*' does not match '/',
+// a pattern like 'TouchBistro/*/postgres' can be used to match across registries.
+//
+// If pattern is not a valid pattern, ErrInvalidName is returned. If no resources match,
+// ErrNotFound is returned.
+func (c *Collection[R]) Match(pattern string) ([]R, error) {
+	const op = errors.Op("resource.Collection.Match")
+	fullName := strings.Contains(pattern, "/")
+	var matches []R
+	for it := c.Iter(); it.Next(); {
+		r := it.Value()
+		name := r.FullName()
+		if !fullName {
+			_, name, _ = ParseName(name)
+		}
+		ok, err := path.Match(pattern, name)
+		if err != nil {
+			return nil, errors.Wrap(ErrInvalidName, errors.Meta{
+				Kind:   errkind.Invalid,
+				Reason: fmt.Sprintf("invalid pattern %s", pattern),
+				Op:     op,
+			})
+		}
+		if ok {
+			matches = append(matches, r)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, errors.Wrap(ErrNotFound, errors.Meta{
+			Kind:   errkind.Invalid,
+			Reason: fmt.Sprintf("no resources match %s", pattern),
+			Op:     op,
+		})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].FullName() < matches[j].FullName()
+	})
+	return matches, nil
+}
+
+// Suggestions returns the full names of the resources in the Collection that are similar to name,
+// the most similar first. It is used to help users when name does not match any resource, for example
+// because of a typo, or because name is a full name with the wrong registry.
+func (c *Collection[R]) Suggestions(name string) []string {
+	if c == nil {
+		return nil
+	}
+	fullNames := make([]string, len(c.resources))
+	for i, r := range c.resources {
+		fullNames[i] = r.FullName()
+	}
+	return SuggestNames(name, fullNames)
+}
+
+// maxSuggestions is the maximum number of suggestions returned by SuggestNames.
+const maxSuggestions = 5
+
+// SuggestNames returns the names in candidates that are similar to name, the most similar first.
+// Names are compared without their registry names, so a full name with the wrong registry
+// will suggest the resource in the right registry.
+func SuggestNames(name string, candidates []string) []string {
+	_, resourceName, err := ParseName(name)
+	if err != nil {
+		resourceName = name
+	}
+	resourceName = strings.ToLower(resourceName)
+	// Allow more mistakes in longer names, but always allow at least one.
+	maxDist := max(1, len(resourceName)/3)
+	type suggestion struct {
+		name string
+		dist int
+	}
+	var suggestions []suggestion
+	for _, candidate := range candidates {
+		if candidate == name {
+			continue
+		}
+		_, shortName, err := ParseName(candidate)
+		if err != nil {
+			shortName = candidate
+		}
+		shortName = strings.ToLower(shortName)
+		d := util.EditDistance(resourceName, shortName)
+		// Names that contain each other are likely what was meant even if they are not close,
+		// ex: venue-core instead of venue-core-service.
+		if d > maxDist && !strings.Contains(shortName, resourceName) && !strings.Contains(resourceName, shortName) {
+			continue
+		}
+		suggestions = append(suggestions, suggestion{candidate, d})
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].dist != suggestions[j].dist {
+			return suggestions[i].dist < suggestions[j].dist
+		}
+		return suggestions[i].name < suggestions[j].name
+	})
+	var names []string
+	for i := 0; i < len(suggestions) && i < maxSuggestions; i++ {
+		names = append(names, suggestions[i].name)
+	}
+	return names
 }
 
 // Set adds or replaces the resource in the Collection.
@@ -2,9 +2,13 @@ package resource_test
 
 import (
 	"errors"
+	"fmt"
 	"sort"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/TouchBistro/goutils/progress"
 	"github.com/TouchBistro/tb/resource"
 	"github.com/matryer/is"
 )
@@ -75,6 +79,24 @@ func TestFullName(t *testing.T) {
 	}
 }
 
+func TestPositionString(t *testing.T) {
+	tests := []struct {
+		name string
+		pos  resource.Position
+		want string
+	}{
+		{"file line and column", resource.Position{File: "services.yml", Line: 12, Column: 5}, "services.yml:12:5"},
+		{"file and line", resource.Position{File: "services.yml", Line: 12}, "services.yml:12"},
+		{"file only", resource.Position{File: "services.d/db.yml"}, "services.d/db.yml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			is.Equal(tt.pos.String(), tt.want)
+		})
+	}
+}
+
 func TestCollectionGet(t *testing.T) {
 	c := newCollection(t)
 	tests := []struct {
@@ -159,6 +181,165 @@ func TestCollectionGetEmpty(t *testing.T) {
 	}
 }
 
+func TestCollectionGetSuggestions(t *testing.T) {
+	tests := []struct {
+		name            string
+		lookupName      string
+		wantErr         error
+		wantSuggestions []string
+		wantMsg         string
+	}{
+		{
+			name:            "typo",
+			lookupName:      "postgress",
+			wantErr:         resource.ErrNotFound,
+			wantSuggestions: []string{"ExampleZone/tb-registry/postgres", "TouchBistro/tb-registry/postgres"},
+			wantMsg:         "did you mean ExampleZone/tb-registry/postgres or TouchBistro/tb-registry/postgres?",
+		},
+		{
+			name:            "partial name",
+			lookupName:      "venue-core",
+			wantErr:         resource.ErrNotFound,
+			wantSuggestions: []string{"TouchBistro/tb-registry/venue-core-service"},
+		},
+		{
+			name:            "wrong registry",
+			lookupName:      "ExampleZone/tb-registry/venue-core-service",
+			wantErr:         resource.ErrNotFound,
+			wantSuggestions: []string{"TouchBistro/tb-registry/venue-core-service"},
+		},
+		{
+			name:       "nothing similar",
+			lookupName: "redis",
+			wantErr:    resource.ErrNotFound,
+			wantMsg:    "redis: resource not found",
+		},
+		{
+			name:            "ambiguous short name",
+			lookupName:      "postgres",
+			wantErr:         resource.ErrMultipleResources,
+			wantSuggestions: []string{"ExampleZone/tb-registry/postgres", "TouchBistro/tb-registry/postgres"},
+			wantMsg:         "use the full name of one of: ExampleZone/tb-registry/postgres, TouchBistro/tb-registry/postgres",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			c := newCollection(t)
+			_, err := c.Get(tt.lookupName)
+			is.True(errors.Is(err, tt.wantErr))
+			var lookupErr *resource.LookupError
+			is.True(errors.As(err, &lookupErr))
+			is.Equal(lookupErr.Suggestions, tt.wantSuggestions)
+			if !strings.HasSuffix(err.Error(), tt.wantMsg) {
+				t.Errorf("got error %q, want it to end with %q", err, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestCollectionGetChoose(t *testing.T) {
+	is := is.New(t)
+	c := newCollection(t)
+	calls := 0
+	c.SetChooseFunc(func(typ resource.Type, name string, candidates []string) (string, error) {
+		calls++
+		is.Equal(typ, resource.TypeService)
+		is.Equal(name, "postgres")
+		is.Equal(candidates, []string{"ExampleZone/tb-registry/postgres", "TouchBistro/tb-registry/postgres"})
+		return candidates[1], nil
+	})
+	s, err := c.Get("postgres")
+	is.NoErr(err)
+	is.Equal(s.FullName(), "TouchBistro/tb-registry/postgres")
+
+	// The choice is remembered
+	s, err = c.Get("postgres")
+	is.NoErr(err)
+	is.Equal(s.FullName(), "TouchBistro/tb-registry/postgres")
+	is.Equal(calls, 1)
+
+	// Not called for unambiguous names
+	_, err = c.Get("venue-core-service")
+	is.NoErr(err)
+	is.Equal(calls, 1)
+}
+
+func TestCollectionGetConcurrent(t *testing.T) {
+	is := is.New(t)
+	c := newCollection(t)
+	// Calls are never concurrent so calls does not need to be synchronized.
+	calls := 0
+	c.SetChooseFunc(func(typ resource.Type, name string, candidates []string) (string, error) {
+		calls++
+		return candidates[1], nil
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s, err := c.Get("postgres")
+			is.NoErr(err)
+			is.Equal(s.FullName(), "TouchBistro/tb-registry/postgres")
+		}()
+	}
+	wg.Wait()
+	is.Equal(calls, 1)
+}
+
+func TestCollectionMatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		pattern   string
+		wantNames []string
+		wantErr   error
+	}{
+		{
+			name:      "short name pattern",
+			pattern:   "post*",
+			wantNames: []string{"ExampleZone/tb-registry/postgres", "TouchBistro/tb-registry/postgres"},
+		},
+		{
+			name:      "full name pattern",
+			pattern:   "TouchBistro/tb-registry/*",
+			wantNames: []string{"TouchBistro/tb-registry/postgres", "TouchBistro/tb-registry/venue-core-service"},
+		},
+		{
+			name:      "any registry",
+			pattern:   "*/*/venue-*",
+			wantNames: []string{"TouchBistro/tb-registry/venue-core-service"},
+		},
+		{
+			name:    "short name pattern does not match registry",
+			pattern: "TouchBistro*",
+			wantErr: resource.ErrNotFound,
+		},
+		{
+			name:    "invalid pattern",
+			pattern: "post[gres",
+			wantErr: resource.ErrInvalidName,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			c := newCollection(t)
+			matches, err := c.Match(tt.pattern)
+			if tt.wantErr != nil {
+				is.True(errors.Is(err, tt.wantErr))
+				return
+			}
+			is.NoErr(err)
+			var names []string
+			for _, s := range matches {
+				names = append(names, s.FullName())
+			}
+			is.Equal(names, tt.wantNames)
+		})
+	}
+}
+
 func TestCollectionLen(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -234,6 +415,7 @@ type mockService struct {
 	Name         string
 	RegistryName string
 	Tag          string
+	Deprecated   *resource.Deprecation
 }
 
 func (mockService) Type() resource.Type {
@@ -244,6 +426,20 @@ func (s mockService) FullName() string {
 	return resource.FullName(s.RegistryName, s.Name)
 }
 
+func (s mockService) Deprecation() *resource.Deprecation {
+	return s.Deprecated
+}
+
+// warnLogger is a logger that records all warnings.
+type warnLogger struct {
+	progress.NoopTracker
+	warnings []string
+}
+
+func (l *warnLogger) Warnf(format string, args ...any) {
+	l.warnings = append(l.warnings, fmt.Sprintf(format, args...))
+}
+
 func newCollection(t *testing.T) *resource.Collection[mockService] {
 	// Creates two services with the same name but different registries
 	// and one service that's a unique name
@@ -272,3 +468,83 @@ func newCollection(t *testing.T) *resource.Collection[mockService] {
 	}
 	return &c
 }
+
+func newDeprecatedCollection(t *testing.T) *resource.Collection[mockService] {
+	c := newCollection(t)
+	svcs := []mockService{
+		{
+			Name:         "old-service",
+			RegistryName: "TouchBistro/tb-registry",
+			Deprecated: &resource.Deprecation{
+				Message:     "it has been merged into venue-core-service",
+				Replacement: "venue-core-service",
+				RemoveAfter: "2026-12-31",
+			},
+		},
+		{
+			Name:         "gone-service",
+			RegistryName: "TouchBistro/tb-registry",
+			Deprecated:   &resource.Deprecation{Replacement: "missing-service"},
+		},
+	}
+	for _, s := range svcs {
+		if err := c.Set(s); err != nil {
+			t.Fatalf("failed to add service %s to collection: %v", s.FullName(), err)
+		}
+	}
+	return c
+}
+
+func TestCollectionGetDeprecated(t *testing.T) {
+	is := is.New(t)
+	c := newDeprecatedCollection(t)
+	logger := &warnLogger{}
+	c.SetDeprecationOptions(resource.DeprecationOptions{Logger: logger})
+
+	s, err := c.Get("old-service")
+	is.NoErr(err)
+	is.Equal(s.FullName(), "TouchBistro/tb-registry/old-service")
+	is.Equal(logger.warnings, []string{
+		"⚠️  service TouchBistro/tb-registry/old-service is deprecated: it has been merged into venue-core-service (it will be removed after 2026-12-31)",
+		"⚠️  Use TouchBistro/tb-registry/venue-core-service instead",
+	})
+
+	// Only warn once per resource
+	_, err = c.Get("TouchBistro/tb-registry/old-service")
+	is.NoErr(err)
+	is.Equal(len(logger.warnings), 2)
+
+	_, err = c.Get("gone-service")
+	is.NoErr(err)
+	is.Equal(len(logger.warnings), 4)
+	is.True(strings.Contains(logger.warnings[3], "replacement service missing-service could not be found"))
+}
+
+func TestCollectionGetDeprecatedUseReplacement(t *testing.T) {
+	is := is.New(t)
+	c := newDeprecatedCollection(t)
+	logger := &warnLogger{}
+	c.SetDeprecationOptions(resource.DeprecationOptions{Logger: logger, UseReplacement: true})
+
+	s, err := c.Get("old-service")
+	is.NoErr(err)
+	is.Equal(s.FullName(), "TouchBistro/tb-registry/venue-core-service")
+	is.Equal(logger.warnings[1], "⚠️  Using TouchBistro/tb-registry/venue-core-service instead")
+
+	// A missing replacement falls back to the deprecated resource
+	s, err = c.Get("gone-service")
+	is.NoErr(err)
+	is.Equal(s.FullName(), "TouchBistro/tb-registry/gone-service")
+}
+
+func TestCollectionLookupDeprecated(t *testing.T) {
+	is := is.New(t)
+	c := newDeprecatedCollection(t)
+	logger := &warnLogger{}
+	c.SetDeprecationOptions(resource.DeprecationOptions{Logger: logger, UseReplacement: true})
+
+	s, err := c.Lookup("old-service")
+	is.NoErr(err)
+	is.Equal(s.FullName(), "TouchBistro/tb-registry/old-service")
+	is.Equal(len(logger.warnings), 0)
+}
@@ -4,12 +4,16 @@ package service
 
 import (
 	"fmt"
+	"reflect"
+	"regexp"
 	"strings"
 
 	"github.com/TouchBistro/goutils/errors"
 	"github.com/TouchBistro/tb/errkind"
 	"github.com/TouchBistro/tb/integrations/docker"
+	"github.com/TouchBistro/tb/internal/schema"
 	"github.com/TouchBistro/tb/resource"
+	"gopkg.in/yaml.v3"
 )
 
 const (
@@ -19,16 +23,27 @@ const (
 
 // Service specifies the configuration for a service that can be run by tb.
 type Service struct {
-	Build        Build             `yaml:"build"`
-	Dependencies []string          `yaml:"dependencies"`
-	Entrypoint   []string          `yaml:"entrypoint"`
-	EnvFile      string            `yaml:"envFile"`
-	EnvVars      map[string]string `yaml:"envVars"`
-	GitRepo      GitRepo           `yaml:"repo"`
-	Mode         string            `yaml:"mode"`
-	Ports        []string          `yaml:"ports"`
-	PreRun       string            `yaml:"preRun"`
-	Remote       Remote            `yaml:"remote"`
+	Build        Build                 `yaml:"build"`
+	Dependencies []string              `yaml:"dependencies"`
+	Deprecated   *resource.Deprecation `yaml:"deprecated,omitempty"`
+	Entrypoint   []string              `yaml:"entrypoint"`
+	EnvFile      string                `yaml:"envFile"`
+	EnvVars      map[string]string     `yaml:"envVars"`
+	GitRepo      GitRepo               `yaml:"repo"`
+	Mode         string                `yaml:"mode"`
+	Ports        []string              `yaml:"ports"`
+	PreRun       string                `yaml:"preRun"`
+	Remote       Remote                `yaml:"remote"`
+	Timeouts     Timeouts              `yaml:"timeouts,omitempty"`
+
+	// Metadata fields, these are purely informational and help people understand the service.
+
+	Description string            `yaml:"description,omitempty"`
+	Docs        string            `yaml:"docs,omitempty"`
+	Owners      []string          `yaml:"owners,omitempty"`
+	Slack       string            `yaml:"slack,omitempty"`
+	URLs        map[string]string `yaml:"urls,omitempty"`
+
 	// Not part of yaml, set at runtime
 	Name         string `yaml:"-"`
 	RegistryName string `yaml:"-"`
@@ -53,6 +68,13 @@ type Remote struct {
 	Volumes []Volume `yaml:"volumes"`
 }
 
+// Timeouts sets how long steps of running a service can take, in seconds.
+// A value of 0 means the timeout from the tb config is used.
+type Timeouts struct {
+	Build  int `yaml:"build,omitempty"`
+	PreRun int `yaml:"preRun,omitempty"`
+}
+
 type Volume struct {
 	Value   string `yaml:"value"`
 	IsNamed bool   `yaml:"named"`
@@ -78,6 +100,11 @@ func (Service) Type() resource.Type {
 	return resource.TypeService
 }
 
+// Deprecation returns the deprecation of s, or nil if s is not deprecated.
+func (s Service) Deprecation() *resource.Deprecation {
+	return s.Deprecated
+}
+
 // FullName returns the service name prefixed with the registry name,
 // i.e. '<registry>/<service>'.
 func (s Service) FullName() string {
@@ -86,49 +113,83 @@ func (s Service) FullName() string {
 
 // Validate validates s. If s is invalid a resource.ValidationError will be returned.
 func Validate(s Service) error {
-	var msgs []string
-	if s.Mode != ModeRemote && s.Mode != ModeBuild {
-		msg := fmt.Sprintf("invalid 'mode' value %q, must be 'remote' or 'build'", s.Mode)
+	var msgs, fields []string
+	addErr := func(field, msg string) {
+		fields = append(fields, field)
 		msgs = append(msgs, msg)
 	}
+	if s.Mode != ModeRemote && s.Mode != ModeBuild {
+		addErr("mode", fmt.Sprintf("invalid 'mode' value %q, must be 'remote' or 'build'", s.Mode))
+	}
 	if s.Mode == ModeRemote && s.Remote.Image == "" {
-		msgs = append(msgs, "'mode' is set to 'remote' but 'remote.image' was not provided")
+		addErr("mode", "'mode' is set to 'remote' but 'remote.image' was not provided")
 	}
 	if s.Mode == ModeBuild && s.Build.DockerfilePath == "" {
-		msgs = append(msgs, "'mode' is set to 'build' but 'build.dockerfilePath' was not provided")
+		addErr("mode", "'mode' is set to 'build' but 'build.dockerfilePath' was not provided")
+	}
+	if s.Timeouts.Build < 0 {
+		addErr("timeouts.build", fmt.Sprintf("invalid 'timeouts.build' value %d, must not be negative", s.Timeouts.Build))
+	}
+	if s.Timeouts.PreRun < 0 {
+		addErr("timeouts.preRun", fmt.Sprintf("invalid 'timeouts.preRun' value %d, must not be negative", s.Timeouts.PreRun))
 	}
 	if msgs == nil {
 		return nil
 	}
-	return &resource.ValidationError{Resource: s, Messages: msgs}
+	return &resource.ValidationError{Resource: s, Messages: msgs, Fields: fields}
 }
 
 // ServiceOverride defines the overrides that should be applied to a Service.
 // It is a subset of the fields of Service, since not all fields are allowed to
 // be overridden.
 type ServiceOverride struct {
-	Build   BuildOverride     `yaml:"build"`
-	EnvVars map[string]string `yaml:"envVars"`
-	GitRepo GitRepoOverride   `yaml:"repo"`
-	Mode    string            `yaml:"mode"`
-	PreRun  string            `yaml:"preRun"`
-	Remote  RemoteOverride    `yaml:"remote"`
+	Build        BuildOverride      `yaml:"build,omitempty"`
+	Dependencies DependencyOverride `yaml:"dependencies,omitempty"`
+	Entrypoint   []string           `yaml:"entrypoint,omitempty"`
+	EnvFile      string             `yaml:"envFile,omitempty"`
+	EnvVars      map[string]string  `yaml:"envVars,omitempty"`
+	GitRepo      GitRepoOverride    `yaml:"repo,omitempty"`
+	Mode         string             `yaml:"mode,omitempty"`
+	Ports        []string           `yaml:"ports,omitempty"`
+	PreRun       string             `yaml:"preRun,omitempty"`
+	Remote       RemoteOverride     `yaml:"remote,omitempty"`
+	Timeouts     Timeouts           `yaml:"timeouts,omitempty"`
 }
 
 type BuildOverride struct {
-	Command string `yaml:"command"`
-	Target  string `yaml:"target"`
+	Args    map[string]string `yaml:"args,omitempty"`
+	Command string            `yaml:"command,omitempty"`
+	Target  string            `yaml:"target,omitempty"`
+	Volumes VolumeOverride    `yaml:"volumes,omitempty"`
+}
+
+// DependencyOverride adds and removes dependencies of a service.
+// Dependencies are full service names, i.e. '<registry>/<service>'.
+type DependencyOverride struct {
+	Add    []string `yaml:"add,omitempty"`
+	Remove []string `yaml:"remove,omitempty"`
 }
 
 type GitRepoOverride struct {
-	Path string `yaml:"path"`
+	Path string `yaml:"path,omitempty"`
 }
 
 type RemoteOverride struct {
-	Command string `yaml:"command"`
-	Tag     string `yaml:"tag"`
+	Command string         `yaml:"command,omitempty"`
+	Tag     string         `yaml:"tag,omitempty"`
+	Volumes VolumeOverride `yaml:"volumes,omitempty"`
 }
 
+// VolumeOverride adds and removes volumes of a service.
+// Volumes are removed by their value, i.e. '<source>:<target>'.
+type VolumeOverride struct {
+	Add    []Volume `yaml:"add,omitempty"`
+	Remove []string `yaml:"remove,omitempty"`
+}
+
+// portRegex matches a docker compose port mapping, i.e. '[ip:][host:]container[/protocol]'.
+var portRegex = regexp.MustCompile(`^(\d{1,3}(\.\d{1,3}){3}:)?(\d+(-\d+)?:)?\d+(-\d+)?(/(tcp|udp))?$`)
+
 // Override applies the overrides from o to s. If applying the override
 // results in an invalid configuration, Override will return an error.
 func Override(s Service, o ServiceOverride) (Service, error) {
@@ -147,8 +208,64 @@ func Override(s Service, o ServiceOverride) (Service, error) {
 			return s, errors.New(errkind.Invalid, msg, op)
 		}
 	}
+	for _, p := range o.Ports {
+		if !portRegex.MatchString(p) {
+			msg := fmt.Sprintf("invalid override value %q for '%s.ports', must be of the form '[host:]container[/protocol]'", p, s.FullName())
+			return s, errors.New(errkind.Invalid, msg, op)
+		}
+	}
+	if o.Timeouts.Build < 0 || o.Timeouts.PreRun < 0 {
+		msg := fmt.Sprintf("invalid override value for '%s.timeouts', timeouts must not be negative", s.FullName())
+		return s, errors.New(errkind.Invalid, msg, op)
+	}
+	for _, volumes := range [][]Volume{o.Build.Volumes.Add, o.Remote.Volumes.Add} {
+		for _, v := range volumes {
+			if v.Value == "" {
+				msg := fmt.Sprintf("invalid override value for '%s.volumes', volumes to add must have a value", s.FullName())
+				return s, errors.New(errkind.Invalid, msg, op)
+			}
+		}
+	}
+
+	// Volumes and dependencies are removed before adding so that a value can be replaced.
+	// The slices are copied since they are shared with the original service.
+	var err error
+	if s.Build.Volumes, err = overrideVolumes(op, s, "build.volumes", s.Build.Volumes, o.Build.Volumes); err != nil {
+		return s, err
+	}
+	if s.Remote.Volumes, err = overrideVolumes(op, s, "remote.volumes", s.Remote.Volumes, o.Remote.Volumes); err != nil {
+		return s, err
+	}
+	if len(o.Dependencies.Add) > 0 || len(o.Dependencies.Remove) > 0 {
+		deps := append([]string(nil), s.Dependencies...)
+		for _, name := range o.Dependencies.Remove {
+			// Dependencies are stored as container names.
+			i := indexOf(deps, docker.NormalizeName(name))
+			if i == -1 {
+				msg := fmt.Sprintf("cannot remove %q from '%s.dependencies', it is not a dependency", name, s.FullName())
+				return s, errors.New(errkind.Invalid, msg, op)
+			}
+			deps = append(deps[:i], deps[i+1:]...)
+		}
+		for _, name := range o.Dependencies.Add {
+			if dep := docker.NormalizeName(name); indexOf(deps, dep) == -1 {
+				deps = append(deps, dep)
+			}
+		}
+		s.Dependencies = deps
+	}
 
 	// Apply overrides
+	if o.Build.Args != nil {
+		args := make(map[string]string, len(s.Build.Args)+len(o.Build.Args))
+		for k, v := range s.Build.Args {
+			args[k] = v
+		}
+		for k, v := range o.Build.Args {
+			args[k] = v
+		}
+		s.Build.Args = args
+	}
 	if o.Build.Command != "" {
 		s.Build.Command = o.Build.Command
 	}
@@ -156,9 +273,23 @@ func Override(s Service, o ServiceOverride) (Service, error) {
 		s.Build.Target = o.Build.Target
 	}
 	if o.EnvVars != nil {
-		for v, val := range o.EnvVars {
-			s.EnvVars[v] = val
+		envVars := make(map[string]string, len(s.EnvVars)+len(o.EnvVars))
+		for k, v := range s.EnvVars {
+			envVars[k] = v
+		}
+		for k, v := range o.EnvVars {
+			envVars[k] = v
 		}
+		s.EnvVars = envVars
+	}
+	if o.Entrypoint != nil {
+		s.Entrypoint = o.Entrypoint
+	}
+	if o.EnvFile != "" {
+		s.EnvFile = o.EnvFile
+	}
+	if o.Ports != nil {
+		s.Ports = o.Ports
 	}
 	if o.PreRun != "" {
 		s.PreRun = o.PreRun
@@ -172,9 +303,128 @@ func Override(s Service, o ServiceOverride) (Service, error) {
 	if o.Remote.Tag != "" {
 		s.Remote.Tag = o.Remote.Tag
 	}
+	if o.Timeouts.Build != 0 {
+		s.Timeouts.Build = o.Timeouts.Build
+	}
+	if o.Timeouts.PreRun != 0 {
+		s.Timeouts.PreRun = o.Timeouts.PreRun
+	}
 	return s, nil
 }
 
+// SetOverrideField sets the field of o at path to value and returns the updated override.
+// path is a dot separated list of yaml field names, like 'build.command' or 'envVars.FOO'.
+// Everything after a map field is a single key, so 'envVars.LOG.LEVEL' sets the LOG.LEVEL env var.
+// Fields that are lists are set by splitting value on commas, volumes to add are set using
+// their values.
+func SetOverrideField(o ServiceOverride, path, value string) (ServiceOverride, error) {
+	const op = errors.Op("service.SetOverrideField")
+	keys, t, err := overrideField(path)
+	if err != nil {
+		return o, errors.Wrap(err, errors.Meta{Kind: errkind.Invalid, Reason: "invalid override field", Op: op})
+	}
+
+	var valueNode *yaml.Node
+	switch t.Kind() {
+	case reflect.Slice:
+		valueNode = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if value == "" {
+			break
+		}
+		for _, item := range strings.Split(value, ",") {
+			itemNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: item}
+			if t.Elem().Kind() == reflect.Struct {
+				// Only volumes are lists of structs, so set the volume value.
+				itemNode = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+					{Kind: yaml.ScalarNode, Tag: "!!str", Value: "value"},
+					itemNode,
+				}}
+			}
+			valueNode.Content = append(valueNode.Content, itemNode)
+		}
+	case reflect.Struct, reflect.Map:
+		msg := fmt.Sprintf("override field %s cannot be set to a value, set one of its fields instead", path)
+		return o, errors.New(errkind.Invalid, msg, op)
+	case reflect.Int:
+		// Let the yaml package resolve the type so the value can be decoded into an int.
+		valueNode = &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+	default:
+		valueNode = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	}
+
+	// Build a document containing only the field and decode it into o,
+	// this leaves all other fields as is and merges maps.
+	for i := len(keys) - 1; i >= 0; i-- {
+		valueNode = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: keys[i]},
+			valueNode,
+		}}
+	}
+	if err := valueNode.Decode(&o); err != nil {
+		return o, errors.Wrap(err, errors.Meta{
+			Kind:   errkind.Invalid,
+			Reason: fmt.Sprintf("invalid value %q for override field %s", value, path),
+			Op:     op,
+		})
+	}
+	return o, nil
+}
+
+// overrideField splits path into the keys of an override field and returns the type of the field.
+// Map keys, like env var names, can contain dots so everything after a map field is a single key.
+func overrideField(path string) ([]string, reflect.Type, error) {
+	ot := reflect.TypeOf(ServiceOverride{})
+	keys := strings.Split(path, ".")
+	for i := 1; i < len(keys); i++ {
+		t, err := schema.FieldType(ot, strings.Join(keys[:i], "."))
+		if err != nil {
+			return nil, nil, err
+		}
+		if t.Kind() == reflect.Map {
+			key := strings.Join(keys[i:], ".")
+			if key == "" {
+				return nil, nil, fmt.Errorf("invalid path %q, keys must not be empty", path)
+			}
+			return append(keys[:i:i], key), t.Elem(), nil
+		}
+	}
+	t, err := schema.FieldType(ot, path)
+	return keys, t, err
+}
+
+// overrideVolumes applies o to volumes and returns the resulting volumes.
+// field is the name of the field being overridden and is used in error messages.
+func overrideVolumes(op errors.Op, s Service, field string, volumes []Volume, o VolumeOverride) ([]Volume, error) {
+	if len(o.Add) == 0 && len(o.Remove) == 0 {
+		return volumes, nil
+	}
+	result := append([]Volume(nil), volumes...)
+	for _, value := range o.Remove {
+		i := -1
+		for j, v := range result {
+			if v.Value == value {
+				i = j
+				break
+			}
+		}
+		if i == -1 {
+			msg := fmt.Sprintf("cannot remove %q from '%s.%s', it is not a volume", value, s.FullName(), field)
+			return nil, errors.New(errkind.Invalid, msg, op)
+		}
+		result = append(result[:i], result[i+1:]...)
+	}
+	return append(result, o.Add...), nil
+}
+
+func indexOf(s []string, v string) int {
+	for i, e := range s {
+		if e == v {
+			return i
+		}
+	}
+	return -1
+}
+
 // DISCUSS(@cszatmary): Does this make sense here? I honestly struggled with where to put this the most.
 // I considerered the following:
 // config: Does not seem like config's business though as config deals with the higher level glue code.
@@ -110,6 +110,20 @@ func TestValidate(t *testing.T) {
 			wantErr:    true,
 			wantMsgLen: 1,
 		},
+		{
+			name: "negative timeouts",
+			service: service.Service{
+				Mode: service.ModeRemote,
+				Remote: service.Remote{
+					Image: "postgres",
+				},
+				Timeouts:     service.Timeouts{Build: -1, PreRun: -60},
+				Name:         "postgres",
+				RegistryName: "TouchBistro/tb-registry",
+			},
+			wantErr:    true,
+			wantMsgLen: 2,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -123,6 +137,7 @@ func TestValidate(t *testing.T) {
 			is.True(errors.As(err, &validationErr))
 			is.Equal(validationErr.Resource, tt.service)
 			is.Equal(len(validationErr.Messages), tt.wantMsgLen)
+			is.Equal(len(validationErr.Fields), tt.wantMsgLen)
 		})
 	}
 }
@@ -153,6 +168,7 @@ func TestOverride(t *testing.T) {
 		Remote: service.Remote{
 			Image: "venue-core-service",
 		},
+		Timeouts:     service.Timeouts{Build: 1800, PreRun: 600},
 		Name:         "venue-core-service",
 		RegistryName: "TouchBistro/tb-registry",
 	}
@@ -170,6 +186,7 @@ func TestOverride(t *testing.T) {
 			Command: "tail -f /dev/null",
 			Tag:     "master",
 		},
+		Timeouts: service.Timeouts{Build: 3600},
 	}
 
 	overridden, err := service.Override(s, o)
@@ -201,9 +218,113 @@ func TestOverride(t *testing.T) {
 			Image:   "venue-core-service",
 			Tag:     "master",
 		},
+		Timeouts:     service.Timeouts{Build: 3600, PreRun: 600},
 		Name:         "venue-core-service",
 		RegistryName: "TouchBistro/tb-registry",
 	})
+
+	// The original service must not be modified.
+	is.Equal(s.EnvVars, map[string]string{"HTTP_PORT": "8080"})
+}
+
+func TestOverrideListFields(t *testing.T) {
+	is := is.New(t)
+	s := service.Service{
+		Build: service.Build{
+			Args:           map[string]string{"NODE_ENV": "development"},
+			DockerfilePath: ".tb/repos/TouchBistro/venue-core-service",
+			Volumes: []service.Volume{
+				{Value: ".tb/repos/TouchBistro/venue-core-service:/home/node/app"},
+				{Value: "venue-core-service-node_modules:/home/node/app/node_modules", IsNamed: true},
+			},
+		},
+		Dependencies: []string{"touchbistro-tb-registry-postgres", "touchbistro-tb-registry-redis"},
+		Entrypoint:   []string{"bash", "entrypoints/docker.sh"},
+		EnvFile:      ".tb/repos/TouchBistro/venue-core-service/.env.example",
+		Mode:         service.ModeBuild,
+		Ports:        []string{"8081:8080"},
+		Name:         "venue-core-service",
+		RegistryName: "TouchBistro/tb-registry",
+	}
+	o := service.ServiceOverride{
+		Build: service.BuildOverride{
+			Args: map[string]string{"NODE_ENV": "test", "DEBUG": "true"},
+			Volumes: service.VolumeOverride{
+				Add:    []service.Volume{{Value: "~/certs:/certs"}},
+				Remove: []string{"venue-core-service-node_modules:/home/node/app/node_modules"},
+			},
+		},
+		Dependencies: service.DependencyOverride{
+			Add:    []string{"TouchBistro/tb-registry/localstack", "TouchBistro/tb-registry/postgres"},
+			Remove: []string{"TouchBistro/tb-registry/redis"},
+		},
+		Entrypoint: []string{"sh"},
+		EnvFile:    "/home/user/venue-core-service.env",
+		Ports:      []string{"9091:8080", "127.0.0.1:9229:9229/tcp"},
+	}
+
+	overridden, err := service.Override(s, o)
+	is.NoErr(err)
+	is.Equal(overridden.Build.Args, map[string]string{"NODE_ENV": "test", "DEBUG": "true"})
+	is.Equal(overridden.Build.Volumes, []service.Volume{
+		{Value: ".tb/repos/TouchBistro/venue-core-service:/home/node/app"},
+		{Value: "~/certs:/certs"},
+	})
+	is.Equal(overridden.Dependencies, []string{"touchbistro-tb-registry-postgres", "touchbistro-tb-registry-localstack"})
+	is.Equal(overridden.Entrypoint, []string{"sh"})
+	is.Equal(overridden.EnvFile, "/home/user/venue-core-service.env")
+	is.Equal(overridden.Ports, []string{"9091:8080", "127.0.0.1:9229:9229/tcp"})
+
+	// The original service must not be modified.
+	is.Equal(s.Build.Args, map[string]string{"NODE_ENV": "development"})
+	is.Equal(len(s.Build.Volumes), 2)
+	is.Equal(s.Dependencies, []string{"touchbistro-tb-registry-postgres", "touchbistro-tb-registry-redis"})
+}
+
+func TestSetOverrideField(t *testing.T) {
+	is := is.New(t)
+	o := service.ServiceOverride{
+		EnvVars: map[string]string{"LOG_LEVEL": "info"},
+	}
+	var err error
+	for _, f := range []struct{ path, value string }{
+		{"envVars.DEBUG", "true"},
+		{"envVars.LOG.LEVEL", "debug"},
+		{"build.args.npm.config.registry", "https://npm.example.com"},
+		{"mode", "build"},
+		{"build.target", "dev"},
+		{"ports", "9091:8080,9229:9229"},
+		{"dependencies.remove", "TouchBistro/tb-registry/redis"},
+		{"remote.volumes.add", "/tmp/data:/data"},
+		{"timeouts.build", "2400"},
+	} {
+		o, err = service.SetOverrideField(o, f.path, f.value)
+		is.NoErr(err)
+	}
+	is.Equal(o, service.ServiceOverride{
+		Build: service.BuildOverride{
+			Args:   map[string]string{"npm.config.registry": "https://npm.example.com"},
+			Target: "dev",
+		},
+		Dependencies: service.DependencyOverride{
+			Remove: []string{"TouchBistro/tb-registry/redis"},
+		},
+		EnvVars: map[string]string{"LOG_LEVEL": "info", "DEBUG": "true", "LOG.LEVEL": "debug"},
+		Mode:    service.ModeBuild,
+		Ports:   []string{"9091:8080", "9229:9229"},
+		Remote: service.RemoteOverride{
+			Volumes: service.VolumeOverride{Add: []service.Volume{{Value: "/tmp/data:/data"}}},
+		},
+		Timeouts: service.Timeouts{Build: 2400},
+	})
+
+	_, err = service.SetOverrideField(o, "timeouts.preRun", "15m")
+	is.True(err != nil) // timeouts must be integers
+
+	for _, path := range []string{"image", "build", "envVars", "envVars.", "mode.value"} {
+		_, err = service.SetOverrideField(o, path, "x")
+		is.True(err != nil) // field cannot be set
+	}
 }
 
 func TestOverrideError(t *testing.T) {
@@ -253,6 +374,70 @@ func TestOverrideError(t *testing.T) {
 				Mode: service.ModeBuild,
 			},
 		},
+		{
+			name: "invalid port",
+			service: service.Service{
+				Mode:         service.ModeRemote,
+				Name:         "postgres",
+				RegistryName: "TouchBistro/tb-registry",
+			},
+			override: service.ServiceOverride{
+				Ports: []string{"5432:postgres"},
+			},
+		},
+		{
+			name: "remove unknown volume",
+			service: service.Service{
+				Mode: service.ModeRemote,
+				Remote: service.Remote{
+					Image:   "postgres",
+					Volumes: []service.Volume{{Value: "postgres:/var/lib/postgresql/data", IsNamed: true}},
+				},
+				Name:         "postgres",
+				RegistryName: "TouchBistro/tb-registry",
+			},
+			override: service.ServiceOverride{
+				Remote: service.RemoteOverride{
+					Volumes: service.VolumeOverride{Remove: []string{"/var/lib/postgresql/data"}},
+				},
+			},
+		},
+		{
+			name: "add volume without value",
+			service: service.Service{
+				Mode:         service.ModeRemote,
+				Name:         "postgres",
+				RegistryName: "TouchBistro/tb-registry",
+			},
+			override: service.ServiceOverride{
+				Build: service.BuildOverride{
+					Volumes: service.VolumeOverride{Add: []service.Volume{{IsNamed: true}}},
+				},
+			},
+		},
+		{
+			name: "remove unknown dependency",
+			service: service.Service{
+				Dependencies: []string{"touchbistro-tb-registry-postgres"},
+				Mode:         service.ModeRemote,
+				Name:         "venue-core-service",
+				RegistryName: "TouchBistro/tb-registry",
+			},
+			override: service.ServiceOverride{
+				Dependencies: service.DependencyOverride{Remove: []string{"TouchBistro/tb-registry/redis"}},
+			},
+		},
+		{
+			name: "negative timeout",
+			service: service.Service{
+				Mode:         service.ModeRemote,
+				Name:         "postgres",
+				RegistryName: "TouchBistro/tb-registry",
+			},
+			override: service.ServiceOverride{
+				Timeouts: service.Timeouts{PreRun: -1},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {